@@ -0,0 +1,255 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/auth"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/client"
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "build graph on distbuild cluster",
+	Args:  cobra.NoArgs,
+	RunE:  runBuild,
+}
+
+var (
+	flagBuildGraph       string
+	flagBuildCoordinator string
+	flagBuildSourceDir   string
+	flagBuildTrace       string
+	flagBuildToken       string
+	flagBuildOut         string
+	flagBuildVerify      bool
+)
+
+func init() {
+	rootCmd.AddCommand(buildCmd)
+
+	buildCmd.Flags().StringVar(&flagBuildGraph, "graph", "", "path to build graph in JSON or YAML format")
+	buildCmd.Flags().StringVar(&flagBuildCoordinator, "coordinator", "http://localhost:8080", "coordinator endpoint")
+	buildCmd.Flags().StringVar(&flagBuildSourceDir, "source-dir", ".", "directory containing source files of the graph")
+	buildCmd.Flags().StringVar(&flagBuildToken, "token", "", "token to authenticate to coordinator (default $DISTBUILD_TOKEN)")
+	buildCmd.Flags().StringVar(&flagBuildOut, "out", "", "download outputs of the graph to this directory")
+	buildCmd.Flags().BoolVar(&flagBuildVerify, "verify", false, "run every job on two workers and report jobs with different outputs")
+	buildCmd.Flags().StringVar(&flagBuildTrace, "trace", "", "write build timeline in Chrome trace event format to this file")
+
+	_ = buildCmd.MarkFlagRequired("graph")
+}
+
+var (
+	errBuildFailed     = errors.New("build failed")
+	errNotReproducible = errors.New("build is not reproducible")
+)
+
+func runBuild(cmd *cobra.Command, args []string) error {
+	graph, err := loadGraph(flagBuildGraph)
+	if err != nil {
+		return err
+	}
+
+	l, err := newLogger()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = l.Sync() }()
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	c := client.NewClient(l.Named("client"), flagBuildCoordinator, flagBuildSourceDir)
+	if tlsEnabled() {
+		cert, ca, err := loadTLS()
+		if err != nil {
+			return err
+		}
+		token := flagBuildToken
+		if token == "" {
+			token = os.Getenv("DISTBUILD_TOKEN")
+		}
+		c.SetAuth(token, auth.ClientConfig(cert, ca))
+	}
+	if flagBuildVerify {
+		c.SetVerify(true)
+	}
+	if flagBuildOut != "" {
+		c.SetOutputDir(flagBuildOut)
+	}
+	if flagBuildTrace != "" {
+		c.SetTraceOutput(flagBuildTrace)
+	}
+
+	lsn := newProgressListener(graph, os.Stdout, os.Stderr)
+	if err := c.Build(ctx, *graph, lsn); err != nil {
+		return err
+	}
+
+	if err := lsn.Summary(); err != nil {
+		return err
+	}
+
+	if failed := lsn.Failed(); failed != 0 {
+		return fmt.Errorf("%w: %d of %d jobs failed", errBuildFailed, failed, len(graph.Jobs))
+	}
+	if mismatched := lsn.Mismatched(); mismatched != 0 {
+		return fmt.Errorf("%w: %d of %d jobs produced different outputs", errNotReproducible, mismatched, len(graph.Jobs))
+	}
+	return nil
+}
+
+// progressListener prints job output and per-job progress.
+//
+// Job output is forwarded as is, progress lines are written to stderr.
+type progressListener struct {
+	names  map[build.ID]string
+	total  int
+	stdout io.Writer
+	stderr io.Writer
+
+	mu         sync.Mutex
+	finished   int
+	failed     int
+	mismatched int
+	flaky      []string
+}
+
+var (
+	_ client.BuildListener  = (*progressListener)(nil)
+	_ client.FlakyListener  = (*progressListener)(nil)
+	_ client.VerifyListener = (*progressListener)(nil)
+)
+
+func newProgressListener(graph *build.Graph, stdout, stderr io.Writer) *progressListener {
+	names := map[build.ID]string{}
+	for _, job := range graph.Jobs {
+		names[job.ID] = job.Name
+	}
+
+	return &progressListener{
+		names:  names,
+		total:  len(graph.Jobs),
+		stdout: stdout,
+		stderr: stderr,
+	}
+}
+
+func (l *progressListener) name(jobID build.ID) string {
+	if name, ok := l.names[jobID]; ok && name != "" {
+		return name
+	}
+	return jobID.String()
+}
+
+func (l *progressListener) OnJobStdout(jobID build.ID, stdout []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, err := l.stdout.Write(stdout)
+	return err
+}
+
+func (l *progressListener) OnJobStderr(jobID build.ID, stderr []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, err := l.stderr.Write(stderr)
+	return err
+}
+
+func (l *progressListener) OnJobFinished(jobID build.ID) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.finished++
+	_, err := fmt.Fprintf(l.stderr, "[%d/%d] ok   %s\n", l.finished, l.total, l.name(jobID))
+	return err
+}
+
+func (l *progressListener) OnJobFailed(jobID build.ID, code int, message string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.finished++
+	l.failed++
+
+	reason := fmt.Sprintf("exit code %d", code)
+	if message != "" {
+		reason = message
+	}
+
+	_, err := fmt.Fprintf(l.stderr, "[%d/%d] FAIL %s: %s\n", l.finished, l.total, l.name(jobID), reason)
+	return err
+}
+
+func (l *progressListener) OnJobFlaky(jobID build.ID, failures []api.JobResult) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.flaky = append(l.flaky, fmt.Sprintf("%s (passed after %d failed attempts)", l.name(jobID), len(failures)))
+	return nil
+}
+
+func (l *progressListener) OnJobMismatch(jobID build.ID, mismatch *api.JobMismatch) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.mismatched++
+
+	_, err := fmt.Fprintf(l.stderr, "MISMATCH %s: outputs differ between %s and %s\n",
+		l.name(jobID), mismatch.Workers[0], mismatch.Workers[1])
+	if err != nil {
+		return err
+	}
+
+	for _, diff := range mismatch.Diffs {
+		if _, err := fmt.Fprintf(l.stderr, "  %s\n", diff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Summary prints jobs that passed only after retries.
+func (l *progressListener) Summary() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.flaky) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(l.stderr, "flaky jobs:\n"); err != nil {
+		return err
+	}
+	for _, job := range l.flaky {
+		if _, err := fmt.Fprintf(l.stderr, "  %s\n", job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Failed returns number of failed jobs.
+func (l *progressListener) Failed() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.failed
+}
+
+// Mismatched returns number of jobs that produced different outputs in verify mode.
+func (l *progressListener) Mismatched() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.mismatched
+}
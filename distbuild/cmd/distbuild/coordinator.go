@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/auth"
+	"gitlab.com/slon/shad-go/distbuild/pkg/dist"
+	"gitlab.com/slon/shad-go/distbuild/pkg/filecache"
+	"gitlab.com/slon/shad-go/distbuild/pkg/journal"
+)
+
+var coordinatorCmd = &cobra.Command{
+	Use:   "coordinator",
+	Short: "run build coordinator",
+	Args:  cobra.NoArgs,
+	RunE:  runCoordinator,
+}
+
+var (
+	flagCoordinatorListen    string
+	flagCoordinatorDir       string
+	flagCoordinatorCacheSize int64
+	flagCoordinatorJournal   bool
+	flagCoordinatorTokens    string
+
+	flagCoordinatorHeartbeatInterval time.Duration
+	flagCoordinatorMissedHeartbeats  int
+)
+
+func init() {
+	rootCmd.AddCommand(coordinatorCmd)
+
+	coordinatorCmd.Flags().StringVar(&flagCoordinatorListen, "listen", ":8080", "address to serve coordinator API on")
+	coordinatorCmd.Flags().StringVar(&flagCoordinatorDir, "cache-dir", "distbuild-coordinator", "directory for source file cache and journal")
+	coordinatorCmd.Flags().Int64Var(&flagCoordinatorCacheSize, "cache-size", 0, "total size limit of source file cache, including upload chunks, in bytes; 0 means unlimited")
+	coordinatorCmd.Flags().BoolVar(&flagCoordinatorJournal, "journal", true, "persist coordinator state across restarts")
+	coordinatorCmd.Flags().StringVar(&flagCoordinatorTokens, "tokens-file", "", "file with client tokens, one per line; required with --tls-ca")
+
+	coordinatorCmd.Flags().DurationVar(&flagCoordinatorHeartbeatInterval, "heartbeat-interval", time.Second, "how often workers must send heartbeats")
+	coordinatorCmd.Flags().IntVar(&flagCoordinatorMissedHeartbeats, "missed-heartbeats", 5, "number of missed heartbeats after which worker is considered dead")
+}
+
+func runCoordinator(cmd *cobra.Command, args []string) error {
+	tlsConfig, err := serverTLS()
+	if err != nil {
+		return err
+	}
+
+	if flagCoordinatorTokens != "" && tlsConfig == nil {
+		return errors.New("--tokens-file requires TLS")
+	}
+
+	// With TLS the coordinator accepts builds only with a token, so an empty token set locks out every client.
+	if flagCoordinatorTokens == "" && tlsConfig != nil {
+		return errors.New("TLS requires --tokens-file: without client tokens no build can be started")
+	}
+
+	if flagCoordinatorHeartbeatInterval <= 0 || flagCoordinatorMissedHeartbeats <= 0 {
+		return errors.New("--heartbeat-interval and --missed-heartbeats must be positive")
+	}
+
+	if flagCoordinatorCacheSize < 0 {
+		return errors.New("--cache-size must not be negative")
+	}
+
+	l, err := newLogger()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = l.Sync() }()
+
+	fileCache, err := filecache.New(filepath.Join(flagCoordinatorDir, "filecache"))
+	if err != nil {
+		return err
+	}
+	fileCache.SetMaxSize(flagCoordinatorCacheSize)
+
+	var j *journal.Journal
+	if flagCoordinatorJournal {
+		j, err = journal.Open(filepath.Join(flagCoordinatorDir, "journal"))
+		if err != nil {
+			return err
+		}
+		defer func() { _ = j.Close() }()
+	}
+
+	coordinator := dist.NewCoordinator(l.Named("coordinator"), fileCache, j)
+	defer coordinator.Stop()
+
+	coordinator.SetLiveness(dist.LivenessConfig{
+		HeartbeatInterval: flagCoordinatorHeartbeatInterval,
+		MissedHeartbeats:  flagCoordinatorMissedHeartbeats,
+	})
+
+	if tlsConfig != nil {
+		tokens, err := readTokens(flagCoordinatorTokens)
+		if err != nil {
+			return err
+		}
+		coordinator.SetAuth(tokens)
+
+		// The coordinator fetches artifacts from workers with its own certificate.
+		cert, ca, err := loadTLS()
+		if err != nil {
+			return err
+		}
+		coordinator.SetTLS(auth.ClientConfig(cert, ca))
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	l.Info("coordinator is listening", zap.String("addr", flagCoordinatorListen))
+	return serve(ctx, flagCoordinatorListen, tlsConfig, coordinator)
+}
+
+// serve serves handler on addr until ctx is cancelled. When config is not nil, serve uses TLS.
+func serve(ctx context.Context, addr string, config *tls.Config, handler http.Handler) error {
+	srv := &http.Server{Addr: addr, Handler: handler, TLSConfig: config}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if config != nil {
+			errCh <- srv.ListenAndServeTLS("", "")
+		} else {
+			errCh <- srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		return err
+	}
+
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
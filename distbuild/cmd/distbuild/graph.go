@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+// loadGraph reads build.Graph serialized by encoding/json or gopkg.in/yaml.v2.
+//
+// Format is chosen by file extension: .yaml and .yml files are parsed as YAML, everything else as JSON.
+// Invalid graphs are rejected with build.Validate before anything is sent to the coordinator.
+func loadGraph(path string) (*build.Graph, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	unmarshal := json.Unmarshal
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		unmarshal = yaml.UnmarshalStrict
+	}
+
+	var graph build.Graph
+	if err := unmarshal(content, &graph); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if err := build.Validate(&graph); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &graph, nil
+}
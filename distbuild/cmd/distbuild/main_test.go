@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/verify"
+)
+
+var expectedGraph = &build.Graph{
+	SourceFiles: map[build.ID]string{
+		{'a'}: "a.txt",
+	},
+	Jobs: []build.Job{
+		{
+			ID:     build.ID{'b'},
+			Name:   "cat",
+			Inputs: []string{"a.txt"},
+			Cmds: []build.Cmd{
+				{Exec: []string{"cat", "{{.SourceDir}}/a.txt"}},
+			},
+		},
+		{
+			ID:   build.ID{'c'},
+			Name: "write",
+			Deps: []build.ID{{'b'}},
+			Cmds: []build.Cmd{
+				{CatTemplate: "OK", CatOutput: "{{.OutputDir}}/out.txt"},
+			},
+			Output: "write",
+		},
+	},
+}
+
+func TestLoadGraph(t *testing.T) {
+	for _, path := range []string{"testdata/graph.json", "testdata/graph.yaml"} {
+		t.Run(path, func(t *testing.T) {
+			graph, err := loadGraph(path)
+			require.NoError(t, err)
+			require.Equal(t, expectedGraph, graph)
+		})
+	}
+}
+
+func TestLoadGraphRoundTrip(t *testing.T) {
+	for ext, marshal := range map[string]func(any) ([]byte, error){
+		".json": json.Marshal,
+		".yaml": yaml.Marshal,
+	} {
+		t.Run(ext, func(t *testing.T) {
+			content, err := marshal(expectedGraph)
+			require.NoError(t, err)
+
+			path := filepath.Join(t.TempDir(), "graph"+ext)
+			require.NoError(t, os.WriteFile(path, content, 0666))
+
+			graph, err := loadGraph(path)
+			require.NoError(t, err)
+
+			// yaml.v2 marshals nil slices as empty ones, so compare serialized graphs.
+			loaded, err := marshal(graph)
+			require.NoError(t, err)
+			require.Equal(t, string(content), string(loaded))
+		})
+	}
+}
+
+func TestProgressListener(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	lsn := newProgressListener(expectedGraph, &stdout, &stderr)
+
+	require.NoError(t, lsn.OnJobStdout(build.ID{'b'}, []byte("foo")))
+	require.NoError(t, lsn.OnJobFinished(build.ID{'b'}))
+	require.NoError(t, lsn.OnJobFailed(build.ID{'c'}, 2, ""))
+
+	require.Equal(t, "foo", stdout.String())
+	require.Equal(t, "[1/2] ok   cat\n[2/2] FAIL write: exit code 2\n", stderr.String())
+	require.Equal(t, 1, lsn.Failed())
+}
+
+func TestProgressListenerFlaky(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	lsn := newProgressListener(expectedGraph, &stdout, &stderr)
+
+	require.NoError(t, lsn.Summary())
+	require.Empty(t, stderr.String())
+
+	require.NoError(t, lsn.OnJobFlaky(build.ID{'b'}, []api.JobResult{{ID: build.ID{'b'}, ExitCode: 1}}))
+	require.NoError(t, lsn.OnJobFinished(build.ID{'b'}))
+	require.NoError(t, lsn.Summary())
+
+	require.Equal(t, "[1/2] ok   cat\nflaky jobs:\n  cat (passed after 1 failed attempts)\n", stderr.String())
+}
+
+func TestProgressListenerMismatch(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	lsn := newProgressListener(expectedGraph, &stdout, &stderr)
+
+	require.NoError(t, lsn.OnJobMismatch(build.ID{'c'}, &api.JobMismatch{
+		ID:      build.ID{'c'},
+		Workers: [2]api.WorkerID{"w0", "w1"},
+		Diffs: []verify.FileDiff{
+			{Path: "out.txt", Kind: verify.DiffContent, Offset: 12},
+			{Path: "stamp", Kind: verify.DiffOnlySecond},
+		},
+	}))
+	require.NoError(t, lsn.OnJobFinished(build.ID{'c'}))
+
+	require.Equal(t, "MISMATCH write: outputs differ between w0 and w1\n"+
+		"  out.txt: content differs at byte 12\n"+
+		"  stamp: only in second artifact\n"+
+		"[1/2] ok   write\n", stderr.String())
+	require.Equal(t, 0, lsn.Failed())
+	require.Equal(t, 1, lsn.Mismatched())
+}
+
+func TestReadTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	require.NoError(t, os.WriteFile(path, []byte("# ci\nfoo\n\n  bar  \n"), 0600))
+
+	tokens, err := readTokens(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"foo", "bar"}, tokens)
+
+	require.NoError(t, os.WriteFile(path, []byte("# nothing\n"), 0600))
+	_, err = readTokens(path)
+	require.Error(t, err)
+}
+
+func TestLoadInvalidGraph(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graph.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"Jobs": [
+		{"ID": "6100000000000000000000000000000000000000", "Name": "a", "Inputs": ["missing.txt"]}
+	]}`), 0666))
+
+	_, err := loadGraph(path)
+	require.Error(t, err)
+
+	var input *build.MissingInputError
+	require.ErrorAs(t, err, &input)
+	require.Equal(t, "missing.txt", input.Input)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"strings"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/auth"
+)
+
+var (
+	flagTLSCA   string
+	flagTLSCert string
+	flagTLSKey  string
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&flagTLSCA, "tls-ca", "", "PEM file with cluster CA; enables TLS")
+	rootCmd.PersistentFlags().StringVar(&flagTLSCert, "tls-cert", "", "PEM file with certificate of this component")
+	rootCmd.PersistentFlags().StringVar(&flagTLSKey, "tls-key", "", "PEM file with private key of this component")
+}
+
+func tlsEnabled() bool {
+	return flagTLSCA != ""
+}
+
+// loadTLS loads cluster CA and component certificate from flags.
+//
+// cert is nil when --tls-cert is not set.
+func loadTLS() (cert *tls.Certificate, ca *x509.CertPool, err error) {
+	if !tlsEnabled() {
+		return nil, nil, errors.New("--tls-ca is not set")
+	}
+
+	ca, err = auth.LoadCertPool(flagTLSCA)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if flagTLSCert == "" && flagTLSKey == "" {
+		return nil, ca, nil
+	}
+
+	c, err := tls.LoadX509KeyPair(flagTLSCert, flagTLSKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &c, ca, nil
+}
+
+// serverTLS returns TLS config for component server or nil when TLS is disabled.
+func serverTLS() (*tls.Config, error) {
+	if !tlsEnabled() {
+		return nil, nil
+	}
+
+	cert, ca, err := loadTLS()
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return nil, errors.New("--tls-cert and --tls-key are required to serve TLS")
+	}
+	return auth.ServerConfig(*cert, ca), nil
+}
+
+// readTokens reads client tokens, one per line. Empty lines and lines starting with # are skipped.
+func readTokens(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, line)
+	}
+
+	if len(tokens) == 0 {
+		return nil, errors.New("no tokens in " + path)
+	}
+	return tokens, nil
+}
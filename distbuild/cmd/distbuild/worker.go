@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/artifact"
+	"gitlab.com/slon/shad-go/distbuild/pkg/auth"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/filecache"
+	"gitlab.com/slon/shad-go/distbuild/pkg/sandbox"
+	"gitlab.com/slon/shad-go/distbuild/pkg/worker"
+)
+
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "run build worker",
+	Args:  cobra.NoArgs,
+	RunE:  runWorker,
+}
+
+var (
+	flagWorkerCoordinator string
+	flagWorkerCacheDir    string
+	flagWorkerCacheSize   int64
+	flagWorkerSlots       int
+	flagWorkerListen      string
+	flagWorkerAdvertise   string
+	flagWorkerLabels      []string
+
+	flagWorkerSandbox     bool
+	flagWorkerCPUTime     time.Duration
+	flagWorkerMemoryLimit int64
+)
+
+func init() {
+	rootCmd.AddCommand(workerCmd)
+
+	workerCmd.Flags().StringVar(&flagWorkerCoordinator, "coordinator", "", "coordinator endpoint, e.g. http://coordinator:8080")
+	workerCmd.Flags().StringVar(&flagWorkerCacheDir, "cache-dir", "distbuild-worker", "directory for file and artifact caches")
+	workerCmd.Flags().Int64Var(&flagWorkerCacheSize, "cache-size", 0, "total size limit of file and artifact caches in bytes; 0 means unlimited")
+	workerCmd.Flags().IntVar(&flagWorkerSlots, "slots", 1, "number of jobs to run concurrently")
+	workerCmd.Flags().StringVar(&flagWorkerListen, "listen", ":8081", "address to serve artifacts to other workers on")
+	workerCmd.Flags().StringVar(&flagWorkerAdvertise, "advertise", "", "endpoint other workers use to reach this worker (default http(s)://<hostname>:<listen port>)")
+	workerCmd.Flags().StringSliceVar(&flagWorkerLabels, "label", nil, "worker label in key=value form; may be repeated")
+
+	workerCmd.Flags().BoolVar(&flagWorkerSandbox, "sandbox", false, "run jobs inside sandbox")
+	workerCmd.Flags().DurationVar(&flagWorkerCPUTime, "cpu-time-limit", 0, "cpu time limit of sandboxed job")
+	workerCmd.Flags().Int64Var(&flagWorkerMemoryLimit, "memory-limit", 0, "memory limit of sandboxed job in bytes")
+
+	_ = workerCmd.MarkFlagRequired("coordinator")
+}
+
+func advertiseEndpoint() (string, error) {
+	if flagWorkerAdvertise != "" {
+		return flagWorkerAdvertise, nil
+	}
+
+	host, port, err := net.SplitHostPort(flagWorkerListen)
+	if err != nil {
+		return "", fmt.Errorf("invalid --listen: %w", err)
+	}
+
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		if host, err = os.Hostname(); err != nil {
+			return "", err
+		}
+	}
+	scheme := "http://"
+	if tlsEnabled() {
+		scheme = "https://"
+	}
+	return scheme + net.JoinHostPort(host, port), nil
+}
+
+func runWorker(cmd *cobra.Command, args []string) error {
+	if flagWorkerSlots < 1 {
+		return errors.New("--slots must be positive")
+	}
+
+	if flagWorkerCacheSize < 0 {
+		return errors.New("--cache-size must not be negative")
+	}
+
+	endpoint, err := advertiseEndpoint()
+	if err != nil {
+		return err
+	}
+
+	tlsConfig, err := serverTLS()
+	if err != nil {
+		return err
+	}
+
+	l, err := newLogger()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = l.Sync() }()
+
+	fileCache, err := filecache.New(filepath.Join(flagWorkerCacheDir, "filecache"))
+	if err != nil {
+		return err
+	}
+
+	artifacts, err := artifact.NewCache(filepath.Join(flagWorkerCacheDir, "artifacts"))
+	if err != nil {
+		return err
+	}
+
+	// --cache-size is the total budget of the worker, the file cache splits its half further between files and chunks.
+	fileCacheSize, artifactsSize := filecache.SplitSize(flagWorkerCacheSize)
+	fileCache.SetMaxSize(fileCacheSize)
+	artifacts.SetMaxSize(artifactsSize)
+
+	w := worker.New(api.WorkerID(endpoint), flagWorkerCoordinator, l.Named("worker"), fileCache, artifacts)
+	w.SetSlots(flagWorkerSlots)
+	if len(flagWorkerLabels) != 0 {
+		w.SetResources(build.Resources{}, flagWorkerLabels)
+	}
+	if tlsConfig != nil {
+		cert, ca, err := loadTLS()
+		if err != nil {
+			return err
+		}
+		w.SetTLS(auth.ClientConfig(cert, ca))
+	}
+	if flagWorkerSandbox {
+		w.SetSandbox(sandbox.New(sandbox.Config{
+			CPUTime: flagWorkerCPUTime,
+			Memory:  flagWorkerMemoryLimit,
+		}))
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	l.Info("worker is starting",
+		zap.String("id", endpoint),
+		zap.String("coordinator", flagWorkerCoordinator),
+		zap.Int("slots", flagWorkerSlots))
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return serve(ctx, flagWorkerListen, tlsConfig, w)
+	})
+	g.Go(func() error {
+		return w.Run(ctx)
+	})
+
+	err = g.Wait()
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package disttest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+func TestActionCacheReplay(t *testing.T) {
+	env := newEnv(t, singleWorkerConfig)
+
+	cachedCounter := filepath.Join(env.RootDir, "cached")
+	noCacheCounter := filepath.Join(env.RootDir, "nocache")
+
+	graph := build.Graph{
+		Jobs: []build.Job{
+			{
+				ID:   build.ID{'a'},
+				Name: "cached",
+				Cmds: []build.Cmd{
+					{Exec: []string{"bash", "-c", "echo run >> " + cachedCounter}, Environ: os.Environ()}, // No-hermetic, for testing purposes.
+					{Exec: []string{"echo", "OK"}},
+					{Exec: []string{"bash", "-c", "echo ERR > /dev/stderr"}},
+				},
+			},
+			{
+				ID:   build.ID{'b'},
+				Name: "test",
+				Cmds: []build.Cmd{
+					{Exec: []string{"bash", "-c", "echo run >> " + noCacheCounter}, Environ: os.Environ()},
+					{Exec: []string{"echo", "TEST"}},
+				},
+				NoCache: true,
+			},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		recorder := NewRecorder()
+		require.NoError(t, env.Client.Build(env.Ctx, graph, recorder))
+
+		assert.Equal(t, &JobResult{Stdout: "OK\n", Stderr: "ERR\n", Code: new(int)}, recorder.Jobs[build.ID{'a'}])
+		assert.Equal(t, &JobResult{Stdout: "TEST\n", Code: new(int)}, recorder.Jobs[build.ID{'b'}])
+	}
+
+	cachedRuns, err := os.ReadFile(cachedCounter)
+	require.NoError(t, err)
+	require.Equal(t, "run\n", string(cachedRuns))
+
+	noCacheRuns, err := os.ReadFile(noCacheCounter)
+	require.NoError(t, err)
+	require.Equal(t, "run\nrun\n", string(noCacheRuns))
+}
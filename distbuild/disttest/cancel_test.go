@@ -0,0 +1,160 @@
+package disttest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+func TestBuildCancel(t *testing.T) {
+	env := newEnv(t, singleWorkerConfig)
+
+	pidFile := filepath.Join(env.RootDir, "sleep.pid")
+
+	graph := build.Graph{
+		Jobs: []build.Job{
+			{
+				ID:   build.ID{'s'},
+				Name: "sleep",
+				Cmds: []build.Cmd{
+					{Exec: []string{"bash", "-c", "echo $$ > " + pidFile + "; exec sleep 1000"}, Environ: os.Environ()},
+				},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(env.Ctx)
+	defer cancel()
+
+	buildDone := make(chan error, 1)
+	go func() {
+		buildDone <- env.Client.Build(ctx, graph, NewRecorder())
+	}()
+
+	waitFile(t, pidFile)
+
+	pidStr, err := os.ReadFile(pidFile)
+	require.NoError(t, err)
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(pidStr)))
+	require.NoError(t, err)
+
+	cancel()
+
+	err = <-buildDone
+	require.Truef(t, errors.Is(err, context.Canceled), "%v", err)
+
+	process, err := os.FindProcess(pid)
+	require.NoError(t, err)
+
+	// Отмена сборки должна убить процесс джоба на воркере.
+	require.Eventually(t, func() bool {
+		return process.Signal(syscall.Signal(0)) != nil
+	}, 10*time.Second, 10*time.Millisecond, "job process %d is still running", pid)
+
+	// Worker is free again.
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, echoGraph, recorder))
+	require.Equal(t, &JobResult{Stdout: "OK\n", Code: new(int)}, recorder.Jobs[build.ID{'a'}])
+}
+
+// statusChan передаёт в каналы всё, что координатор пишет в api.StatusWriter.
+type statusChan struct {
+	started chan *api.BuildStarted
+	updates chan *api.StatusUpdate
+}
+
+func newStatusChan() *statusChan {
+	return &statusChan{
+		started: make(chan *api.BuildStarted, 1),
+		updates: make(chan *api.StatusUpdate, 16),
+	}
+}
+
+func (s *statusChan) Started(rsp *api.BuildStarted) error {
+	s.started <- rsp
+	return nil
+}
+
+func (s *statusChan) Updated(update *api.StatusUpdate) error {
+	s.updates <- update
+	return nil
+}
+
+func TestBuildCancelAttached(t *testing.T) {
+	env := newEnv(t, singleWorkerConfig)
+
+	pidFile := filepath.Join(env.RootDir, "sleep.pid")
+
+	graph := build.Graph{
+		Jobs: []build.Job{
+			{
+				ID:   build.ID{'s'},
+				Name: "sleep",
+				Cmds: []build.Cmd{
+					{Exec: []string{"bash", "-c", "echo $$ > " + pidFile + "; exec sleep 1000"}, Environ: os.Environ()},
+				},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(env.Ctx)
+	defer cancel()
+
+	buildDone := make(chan error, 1)
+	go func() {
+		buildDone <- env.Client.Build(ctx, graph, NewRecorder())
+	}()
+
+	waitFile(t, pidFile)
+
+	builds := env.Coordinator.Builds()
+	require.Len(t, builds, 1)
+
+	// Второй слушатель подключается к сборке так же, как переподключившийся клиент.
+	status := newStatusChan()
+	attachDone := make(chan error, 1)
+	go func() {
+		attachDone <- env.Coordinator.BuildService().AttachBuild(env.Ctx, builds[0].ID, status)
+	}()
+
+	select {
+	case started := <-status.started:
+		require.Equal(t, builds[0].ID, started.ID)
+	case <-time.After(10 * time.Second):
+		t.Fatal("AttachBuild did not send BuildStarted")
+	}
+
+	cancel()
+
+	err := <-buildDone
+	require.Truef(t, errors.Is(err, context.Canceled), "%v", err)
+
+	// Отмена завершает сборку, и все подключённые слушатели получают BuildFailed с Cancelled.
+	for {
+		select {
+		case update := <-status.updates:
+			require.Nil(t, update.BuildFinished)
+			if update.BuildFailed == nil {
+				continue
+			}
+
+			require.True(t, update.BuildFailed.Cancelled, update.BuildFailed.Error)
+			require.NoError(t, <-attachDone)
+			return
+		case <-time.After(10 * time.Second):
+			t.Fatal("attached listener did not receive BuildFailed")
+		}
+	}
+}
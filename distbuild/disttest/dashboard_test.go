@@ -0,0 +1,120 @@
+package disttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/auth"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+func fetchStatus(url string, v any) error {
+	rsp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", rsp.Status)
+	}
+	return json.NewDecoder(rsp.Body).Decode(v)
+}
+
+func getStatus(t *testing.T, url string, v any) {
+	t.Helper()
+
+	require.NoError(t, fetchStatus(url, v))
+}
+
+func TestDashboard(t *testing.T) {
+	env := newEnv(t, threeWorkerConfig)
+
+	graph := build.Graph{
+		Jobs: []build.Job{
+			{
+				ID:   build.ID{'a'},
+				Name: "sleep",
+				Cmds: []build.Cmd{{Exec: []string{"sleep", "1"}}},
+			},
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- env.Client.Build(env.Ctx, graph, NewRecorder())
+	}()
+
+	// Условие Eventually выполняется в отдельной горутине, поэтому ошибку нельзя проверять через require.
+	require.Eventually(t, func() bool {
+		var builds []api.BuildStatus
+		if err := fetchStatus(env.CoordinatorEndpoint+"/status/builds", &builds); err != nil {
+			return false
+		}
+
+		return len(builds) == 1 &&
+			builds[0].Jobs[0].State == api.JobStateRunning &&
+			builds[0].Jobs[0].WorkerID != ""
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, <-done)
+
+	var builds []api.BuildStatus
+	getStatus(t, env.CoordinatorEndpoint+"/status/builds", &builds)
+	require.Empty(t, builds)
+
+	var workers []api.WorkerStatus
+	getStatus(t, env.CoordinatorEndpoint+"/status/workers", &workers)
+	require.Len(t, workers, 3)
+
+	artifacts := 0
+	for _, w := range workers {
+		artifacts += w.Artifacts
+	}
+	require.Equal(t, 1, artifacts)
+
+	rsp, err := http.Get(env.CoordinatorEndpoint + "/dashboard")
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "Workers (3)")
+}
+
+func TestDashboardTLS(t *testing.T) {
+	env := newEnv(t, &Config{WorkerCount: 1, TLS: true})
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+
+	browser := &http.Client{Jar: jar, Transport: &http.Transport{TLSClientConfig: auth.ClientConfig(nil, env.CA.Pool())}}
+
+	get := func(path string) (int, string) {
+		rsp, err := browser.Get(env.CoordinatorEndpoint + path)
+		require.NoError(t, err)
+		defer rsp.Body.Close()
+
+		body, err := io.ReadAll(rsp.Body)
+		require.NoError(t, err)
+		return rsp.StatusCode, string(body)
+	}
+
+	code, _ := get("/dashboard")
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	code, body := get("/dashboard?token=" + ClientToken)
+	require.Equal(t, http.StatusOK, code)
+	require.Contains(t, body, "Workers (1)")
+
+	code, _ = get("/status/workers")
+	require.Equal(t, http.StatusOK, code)
+}
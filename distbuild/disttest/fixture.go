@@ -2,7 +2,9 @@ package disttest
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"flag"
 	"fmt"
 	"net"
 	"net/http"
@@ -10,17 +12,25 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 
 	"gitlab.com/slon/shad-go/distbuild/pkg/api"
 	"gitlab.com/slon/shad-go/distbuild/pkg/artifact"
+	"gitlab.com/slon/shad-go/distbuild/pkg/auth"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
 	"gitlab.com/slon/shad-go/distbuild/pkg/client"
 	"gitlab.com/slon/shad-go/distbuild/pkg/dist"
 	"gitlab.com/slon/shad-go/distbuild/pkg/filecache"
+	"gitlab.com/slon/shad-go/distbuild/pkg/grpcapi"
+	"gitlab.com/slon/shad-go/distbuild/pkg/journal"
 	"gitlab.com/slon/shad-go/distbuild/pkg/worker"
 	"gitlab.com/slon/shad-go/tools/testtool"
 
@@ -39,6 +49,20 @@ type env struct {
 	WorkerCache []*artifact.Cache
 
 	HTTP *http.Server
+
+	// CoordinatorEndpoint и WorkerEndpoints - адреса, по которым компоненты обслуживают HTTP запросы.
+	CoordinatorEndpoint string
+	WorkerEndpoints     []string
+
+	// CA выпускает сертификаты компонентов. Заполняется только при Config.TLS.
+	CA *TestCA
+
+	config      *Config
+	coordinator atomic.Pointer[dist.Coordinator]
+	journal     *journal.Journal
+
+	workerCancel []context.CancelFunc
+	workerKilled []*atomic.Bool
 }
 
 const (
@@ -47,8 +71,27 @@ const (
 
 type Config struct {
 	WorkerCount int
+
+	// WorkerLabels задаёт метки воркеров. Ключ - номер воркера.
+	WorkerLabels map[int][]string
+
+	// CoordinatorJournal включает журнал координатора. Без журнала RestartCoordinator
+	// теряет всё состояние.
+	CoordinatorJournal bool
+
+	// GRPC переключает API сборки и heartbeat-ы на gRPC транспорт из пакета grpcapi.
+	// Флаг -transport=grpc включает его во всех тестах.
+	GRPC bool
+
+	// TLS включает mTLS между компонентами и авторизацию клиента по токену ClientToken.
+	TLS bool
 }
 
+var flagTransport = flag.String("transport", "http", "transport of build and heartbeat API: http or grpc")
+
+// ClientToken - токен, с которым клиент ходит к координатору при Config.TLS.
+const ClientToken = "distbuild-test-token"
+
 func newEnv(t *testing.T, config *Config) (e *env) {
 	t.Cleanup(func() {
 		goleak.VerifyNone(t)
@@ -74,6 +117,7 @@ func newEnv(t *testing.T, config *Config) (e *env) {
 
 	env := &env{
 		RootDir: rootDir,
+		config:  config,
 	}
 
 	cfg := zap.NewDevelopmentConfig()
@@ -103,7 +147,15 @@ func newEnv(t *testing.T, config *Config) (e *env) {
 	port, err := testtool.GetFreePort()
 	require.NoError(t, err)
 	addr := "127.0.0.1:" + port
-	coordinatorEndpoint := "http://" + addr + "/coordinator"
+
+	scheme := "http://"
+	if config.TLS {
+		scheme = "https://"
+		env.CA = NewTestCA(t)
+	}
+
+	coordinatorEndpoint := scheme + addr + "/coordinator"
+	env.CoordinatorEndpoint = coordinatorEndpoint
 
 	var cancelRootContext func()
 	env.Ctx, cancelRootContext = context.WithCancel(context.Background())
@@ -114,17 +166,26 @@ func newEnv(t *testing.T, config *Config) (e *env) {
 		coordinatorEndpoint,
 		filepath.Join(absCWD, "testdata", t.Name()))
 
-	coordinatorCache, err := filecache.New(filepath.Join(env.RootDir, "coordinator", "filecache"))
-	require.NoError(t, err)
+	if config.TLS {
+		env.Client.SetAuth(ClientToken, auth.ClientConfig(nil, env.CA.Pool()))
+	}
 
-	env.Coordinator = dist.NewCoordinator(
-		env.Logger.Named("coordinator"),
-		coordinatorCache,
-	)
-	t.Cleanup(env.Coordinator.Stop)
+	env.startCoordinator(t)
+	t.Cleanup(env.stopCoordinator)
+
+	var grpcAddr string
+	if config.GRPC || *flagTransport == "grpc" {
+		grpcAddr = env.startGRPC(t)
+		conn := env.dialGRPC(t, grpcAddr, nil, ClientToken)
+		env.Client.SetBuildService(grpcapi.NewBuildClient(env.Logger.Named("grpc"), conn))
+	}
 
 	router := http.NewServeMux()
-	router.Handle("/coordinator/", http.StripPrefix("/coordinator", env.Coordinator))
+	router.Handle("/coordinator/", http.StripPrefix("/coordinator", http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			env.coordinator.Load().ServeHTTP(w, r)
+		},
+	)))
 
 	for i := 0; i < config.WorkerCount; i++ {
 		workerName := fmt.Sprintf("worker%d", i)
@@ -139,7 +200,7 @@ func newEnv(t *testing.T, config *Config) (e *env) {
 		require.NoError(t, err)
 
 		workerPrefix := fmt.Sprintf("/worker/%d", i)
-		workerID := api.WorkerID("http://" + addr + workerPrefix)
+		workerID := api.WorkerID(scheme + addr + workerPrefix)
 
 		w := worker.New(
 			workerID,
@@ -149,10 +210,39 @@ func newEnv(t *testing.T, config *Config) (e *env) {
 			artifacts,
 		)
 
+		var cert *tls.Certificate
+		if config.TLS {
+			c := env.CA.Issue(t, string(workerID))
+			cert = &c
+			w.SetTLS(auth.ClientConfig(cert, env.CA.Pool()))
+		}
+
+		if grpcAddr != "" {
+			conn := env.dialGRPC(t, grpcAddr, cert, "")
+			w.SetHeartbeatService(grpcapi.NewHeartbeatClient(env.Logger.Named(workerName), conn))
+		}
+
+		if labels, ok := config.WorkerLabels[i]; ok {
+			w.SetResources(build.Resources{}, labels)
+		}
+
 		env.Workers = append(env.Workers, w)
+		env.WorkerEndpoints = append(env.WorkerEndpoints, string(workerID))
 		env.WorkerCache = append(env.WorkerCache, artifacts)
 
-		router.Handle(workerPrefix+"/", http.StripPrefix(workerPrefix, w))
+		killed := &atomic.Bool{}
+		env.workerKilled = append(env.workerKilled, killed)
+
+		router.Handle(workerPrefix+"/", http.StripPrefix(workerPrefix, http.HandlerFunc(
+			func(rw http.ResponseWriter, r *http.Request) {
+				if killed.Load() {
+					http.Error(rw, "worker is killed", http.StatusServiceUnavailable)
+					return
+				}
+
+				w.ServeHTTP(rw, r)
+			},
+		)))
 	}
 
 	env.HTTP = &http.Server{
@@ -163,6 +253,10 @@ func newEnv(t *testing.T, config *Config) (e *env) {
 	lsn, err := net.Listen("tcp", env.HTTP.Addr)
 	require.NoError(t, err)
 
+	if config.TLS {
+		lsn = tls.NewListener(lsn, auth.ServerConfig(env.CA.Issue(t, coordinatorEndpoint), env.CA.Pool()))
+	}
+
 	go func() {
 		err := env.HTTP.Serve(lsn)
 		if err != http.ErrServerClosed {
@@ -176,8 +270,11 @@ func newEnv(t *testing.T, config *Config) (e *env) {
 	})
 
 	for _, w := range env.Workers {
+		ctx, cancel := context.WithCancel(env.Ctx)
+		env.workerCancel = append(env.workerCancel, cancel)
+
 		go func(w *worker.Worker) {
-			err := w.Run(env.Ctx)
+			err := w.Run(ctx)
 			if errors.Is(err, context.Canceled) {
 				return
 			}
@@ -198,6 +295,130 @@ func newEnv(t *testing.T, config *Config) (e *env) {
 	return env
 }
 
+func (env *env) startCoordinator(t *testing.T) {
+	coordinatorDir := filepath.Join(env.RootDir, "coordinator")
+
+	coordinatorCache, err := filecache.New(filepath.Join(coordinatorDir, "filecache"))
+	require.NoError(t, err)
+
+	env.journal = nil
+	if env.config.CoordinatorJournal {
+		env.journal, err = journal.Open(filepath.Join(coordinatorDir, "journal"))
+		require.NoError(t, err)
+	}
+
+	env.Coordinator = dist.NewCoordinator(
+		env.Logger.Named("coordinator"),
+		coordinatorCache,
+		env.journal,
+	)
+	if env.config.TLS {
+		env.Coordinator.SetAuth([]string{ClientToken})
+
+		cert := env.CA.Issue(t, env.CoordinatorEndpoint)
+		env.Coordinator.SetTLS(auth.ClientConfig(&cert, env.CA.Pool()))
+	}
+	env.coordinator.Store(env.Coordinator)
+}
+
+// coordinatorServices передаёт вызовы текущему координатору, чтобы gRPC сервер переживал RestartCoordinator.
+type coordinatorServices struct {
+	env *env
+}
+
+func (s coordinatorServices) StartBuild(ctx context.Context, request *api.BuildRequest, w api.StatusWriter) error {
+	return s.env.coordinator.Load().BuildService().StartBuild(ctx, request, w)
+}
+
+func (s coordinatorServices) SignalBuild(ctx context.Context, buildID build.ID, signal *api.SignalRequest) (*api.SignalResponse, error) {
+	return s.env.coordinator.Load().BuildService().SignalBuild(ctx, buildID, signal)
+}
+
+func (s coordinatorServices) AttachBuild(ctx context.Context, buildID build.ID, w api.StatusWriter) error {
+	return s.env.coordinator.Load().BuildService().AttachBuild(ctx, buildID, w)
+}
+
+func (s coordinatorServices) Heartbeat(ctx context.Context, req *api.HeartbeatRequest) (*api.HeartbeatResponse, error) {
+	return s.env.coordinator.Load().HeartbeatService().Heartbeat(ctx, req)
+}
+
+// startGRPC запускает gRPC сервер координатора на отдельном порту и возвращает его адрес.
+//
+// При Config.TLS сервер работает по TLS с сертификатом координатора и авторизует вызовы так же,
+// как HTTP API после SetAuth (grpcapi.ServerAuth).
+func (env *env) startGRPC(t *testing.T) string {
+	port, err := testtool.GetFreePort()
+	require.NoError(t, err)
+
+	lsn, err := net.Listen("tcp", "127.0.0.1:"+port)
+	require.NoError(t, err)
+
+	services := coordinatorServices{env: env}
+
+	var opts []grpc.ServerOption
+	if env.config.TLS {
+		opts = grpcapi.ServerAuth(env.Logger.Named("grpc"), []string{ClientToken})
+		serverConfig := auth.ServerConfig(env.CA.Issue(t, env.CoordinatorEndpoint), env.CA.Pool())
+		opts = append(opts, grpc.Creds(credentials.NewTLS(serverConfig)))
+	}
+
+	server := grpc.NewServer(opts...)
+	grpcapi.RegisterBuildService(server, env.Logger.Named("grpc"), services)
+	grpcapi.RegisterHeartbeatService(server, env.Logger.Named("grpc"), services)
+
+	go func() { _ = server.Serve(lsn) }()
+	t.Cleanup(server.Stop)
+
+	return lsn.Addr().String()
+}
+
+// dialGRPC подключается к gRPC серверу координатора. При Config.TLS соединение предъявляет cert,
+// а если token не пустой, каждый вызов несёт токен клиента сборки.
+func (env *env) dialGRPC(t *testing.T, addr string, cert *tls.Certificate, token string) *grpc.ClientConn {
+	creds := insecure.NewCredentials()
+	var opts []grpc.DialOption
+	if env.config.TLS {
+		creds = credentials.NewTLS(auth.ClientConfig(cert, env.CA.Pool()))
+		if token != "" {
+			opts = append(opts, grpc.WithPerRPCCredentials(grpcapi.TokenCredentials(token)))
+		}
+	}
+
+	conn, err := grpc.Dial(addr, append(opts, grpc.WithTransportCredentials(creds))...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+func (env *env) stopCoordinator() {
+	env.Coordinator.Stop()
+
+	if env.journal != nil {
+		_ = env.journal.Close()
+	}
+}
+
+// RestartCoordinator останавливает координатора и запускает на его месте новый.
+//
+// Новый координатор слушает тот же адрес и использует ту же рабочую директорию.
+func (env *env) RestartCoordinator(t *testing.T) {
+	env.Logger.Info("restarting coordinator")
+
+	env.stopCoordinator()
+	env.startCoordinator(t)
+}
+
+// KillWorker останавливает i-ый воркер посреди работы.
+//
+// Воркер перестаёт ходить к координатору с heartbeat-ами, а его HTTP хендлер начинает отвечать ошибкой.
+func (env *env) KillWorker(i int) {
+	env.Logger.Info("killing worker", zap.Int("worker", i))
+
+	env.workerKilled[i].Store(true)
+	env.workerCancel[i]()
+}
+
 func newWinFileSink(u *url.URL) (zap.Sink, error) {
 	if len(u.Opaque) > 0 {
 		// Remove leading slash left by url.Parse()
@@ -0,0 +1,34 @@
+package disttest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+var grpcConfig = &Config{
+	WorkerCount: 1,
+	GRPC:        true,
+}
+
+func TestGRPCSingleCommand(t *testing.T) {
+	env := newEnv(t, grpcConfig)
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, echoGraph, recorder))
+
+	require.Len(t, recorder.Jobs, 1)
+	require.Equal(t, &JobResult{Stdout: "OK\n", Code: new(int)}, recorder.Jobs[echoGraph.Jobs[0].ID])
+}
+
+func TestGRPCTLS(t *testing.T) {
+	env := newEnv(t, &Config{WorkerCount: 1, GRPC: true, TLS: true})
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, artifactTransferGraph, recorder))
+
+	require.Len(t, recorder.Jobs, 2)
+	require.Equal(t, &JobResult{Stdout: "OK", Code: new(int)}, recorder.Jobs[build.ID{'b'}])
+}
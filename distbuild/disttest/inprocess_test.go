@@ -0,0 +1,77 @@
+package disttest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+var inProcessGraph = build.Graph{
+	SourceFiles: map[build.ID]string{
+		{'c'}: "conf/server.yaml",
+		{'s'}: "app.sh",
+	},
+	Jobs: []build.Job{
+		{
+			ID:     build.ID{'a'},
+			Name:   "package",
+			Inputs: []string{"conf/server.yaml", "app.sh"},
+			Cmds: []build.Cmd{
+				{MkdirOutput: "{{.OutputDir}}/bin"},
+				{CopySource: "{{.SourceDir}}/app.sh", CopyOutput: "{{.OutputDir}}/bin/app.sh"},
+				{CopySource: "{{.SourceDir}}/conf", CopyOutput: "{{.OutputDir}}/etc"},
+				{SymlinkTarget: "bin/app.sh", SymlinkOutput: "{{.OutputDir}}/app"},
+				{EnvFileOutput: "{{.OutputDir}}/etc/env", Environ: []string{"APP_CONFIG=etc/server.yaml"}},
+			},
+		},
+		{
+			ID:   build.ID{'b'},
+			Name: "check",
+			Deps: []build.ID{{'a'}},
+			Cmds: []build.Cmd{
+				{
+					Exec: []string{"sh", "-c", "cd {{index .Deps \"6100000000000000000000000000000000000000\"}} && " +
+						"sh app && cat etc/server.yaml etc/env"},
+				},
+			},
+		},
+	},
+}
+
+func TestInProcessCmds(t *testing.T) {
+	env := newEnv(t, threeWorkerConfig)
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, inProcessGraph, recorder))
+
+	require.Equal(t, &JobResult{Code: new(int)}, recorder.Jobs[build.ID{'a'}])
+	require.Equal(t, &JobResult{
+		Stdout: "app\nlisten: 8080\nAPP_CONFIG=etc/server.yaml\n",
+		Code:   new(int),
+	}, recorder.Jobs[build.ID{'b'}])
+}
+
+func TestInProcessCmdEscape(t *testing.T) {
+	env := newEnv(t, singleWorkerConfig)
+
+	graph := build.Graph{
+		Jobs: []build.Job{
+			{
+				ID:   build.ID{'a'},
+				Name: "escape",
+				Cmds: []build.Cmd{
+					{SymlinkTarget: "../../etc/passwd", SymlinkOutput: "{{.OutputDir}}/passwd"},
+				},
+			},
+		},
+	}
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, graph, recorder))
+
+	result := recorder.Jobs[build.ID{'a'}]
+	require.NotNil(t, result)
+	require.Contains(t, result.Error, "escapes output directory")
+}
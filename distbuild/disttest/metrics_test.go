@@ -0,0 +1,43 @@
+package disttest
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/metrics"
+)
+
+func scrape(t *testing.T, endpoint string) string {
+	t.Helper()
+
+	rsp, err := http.Get(endpoint + metrics.Path)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+
+	body, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	env := newEnv(t, singleWorkerConfig)
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, echoGraph, recorder))
+
+	coordinator := scrape(t, env.CoordinatorEndpoint)
+	require.Contains(t, coordinator, "distbuild_coordinator_workers 1")
+	require.Contains(t, coordinator, "distbuild_scheduler_queue_depth")
+	require.Contains(t, coordinator, `distbuild_scheduler_job_latency_seconds_count{locality="miss"} 1`)
+	require.Contains(t, coordinator, `distbuild_cache_hits_total{cache="files"}`)
+
+	worker := scrape(t, env.WorkerEndpoints[0])
+	require.Contains(t, worker, "distbuild_worker_heartbeat_rtt_seconds_count")
+	require.Contains(t, worker, "distbuild_worker_free_slots")
+	require.Contains(t, worker, `distbuild_cache_added_bytes_total{cache="artifacts"}`)
+}
@@ -0,0 +1,99 @@
+package disttest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+type streamRecorder struct {
+	*Recorder
+
+	stdoutTimes []time.Time
+	finishTime  time.Time
+}
+
+func (r *streamRecorder) OnJobStdout(jobID build.ID, stdout []byte) error {
+	r.stdoutTimes = append(r.stdoutTimes, time.Now())
+	return r.Recorder.OnJobStdout(jobID, stdout)
+}
+
+func (r *streamRecorder) OnJobFinished(jobID build.ID) error {
+	r.finishTime = time.Now()
+	return r.Recorder.OnJobFinished(jobID)
+}
+
+func TestJobOutputStreaming(t *testing.T) {
+	env := newEnv(t, singleWorkerConfig)
+
+	graph := build.Graph{
+		Jobs: []build.Job{
+			{
+				ID:   build.ID{'a'},
+				Name: "slow echo",
+				Cmds: []build.Cmd{
+					{Exec: []string{"echo", "A"}},
+					{Exec: []string{"sleep", "1"}, Environ: os.Environ()},
+					{Exec: []string{"echo", "B"}},
+				},
+			},
+		},
+	}
+
+	recorder := &streamRecorder{Recorder: NewRecorder()}
+	require.NoError(t, env.Client.Build(env.Ctx, graph, recorder))
+
+	assert.Equal(t, &JobResult{Stdout: "A\nB\n", Code: new(int)}, recorder.Jobs[build.ID{'a'}])
+
+	require.GreaterOrEqual(t, len(recorder.stdoutTimes), 2)
+	assert.Greater(t, recorder.finishTime.Sub(recorder.stdoutTimes[0]), time.Millisecond*500)
+}
+
+// blockingRecorder не читает вывод, пока не закроется release.
+type blockingRecorder struct {
+	*Recorder
+
+	release <-chan struct{}
+}
+
+func (r *blockingRecorder) OnJobStdout(jobID build.ID, stdout []byte) error {
+	<-r.release
+	return r.Recorder.OnJobStdout(jobID, stdout)
+}
+
+func TestSlowClientDoesNotStallHeartbeats(t *testing.T) {
+	env := newEnv(t, threeWorkerConfig)
+
+	runs := filepath.Join(env.RootDir, "runs")
+	graph := build.Graph{
+		Jobs: []build.Job{
+			{
+				ID:   build.ID{'a'},
+				Name: "noisy",
+				Cmds: []build.Cmd{
+					// No-hermetic, for testing purposes.
+					{Exec: []string{"sh", "-c", "echo run >> " + runs + "; head -c 4000000 /dev/zero | tr '\\0' x"}},
+				},
+			},
+		},
+	}
+
+	release := make(chan struct{})
+	time.AfterFunc(time.Second*2, func() { close(release) })
+
+	recorder := &blockingRecorder{Recorder: NewRecorder(), release: release}
+	require.NoError(t, env.Client.Build(env.Ctx, graph, recorder))
+
+	assert.Len(t, recorder.Jobs[build.ID{'a'}].Stdout, 4000000)
+
+	// Если бы координатор задержал heartbeat-ы, воркера посчитали бы мёртвым и джоб запустился бы ещё раз.
+	content, err := os.ReadFile(runs)
+	require.NoError(t, err)
+	assert.Equal(t, "run\n", string(content))
+}
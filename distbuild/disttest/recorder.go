@@ -1,6 +1,7 @@
 package disttest
 
 import (
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
 	"gitlab.com/slon/shad-go/distbuild/pkg/build"
 )
 
@@ -14,11 +15,23 @@ type JobResult struct {
 
 type Recorder struct {
 	Jobs map[build.ID]*JobResult
+
+	// Flaky хранит число упавших попыток джобов, прошедших после перезапуска.
+	Flaky map[build.ID]int
+
+	// Failures хранит результаты упавших попыток из StatusUpdate.JobFlaky.
+	Failures map[build.ID][]api.JobResult
+
+	// Mismatches хранит расхождения, найденные в режиме проверки воспроизводимости.
+	Mismatches map[build.ID]*api.JobMismatch
 }
 
 func NewRecorder() *Recorder {
 	return &Recorder{
-		Jobs: map[build.ID]*JobResult{},
+		Jobs:       map[build.ID]*JobResult{},
+		Flaky:      map[build.ID]int{},
+		Failures:   map[build.ID][]api.JobResult{},
+		Mismatches: map[build.ID]*api.JobMismatch{},
 	}
 }
 
@@ -55,3 +68,14 @@ func (r *Recorder) OnJobFailed(jobID build.ID, code int, error string) error {
 	j.Error = error
 	return nil
 }
+
+func (r *Recorder) OnJobFlaky(jobID build.ID, failures []api.JobResult) error {
+	r.Flaky[jobID] = len(failures)
+	r.Failures[jobID] = failures
+	return nil
+}
+
+func (r *Recorder) OnJobMismatch(jobID build.ID, mismatch *api.JobMismatch) error {
+	r.Mismatches[jobID] = mismatch
+	return nil
+}
@@ -0,0 +1,59 @@
+package disttest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+func TestJobPlacement(t *testing.T) {
+	env := newEnv(t, &Config{
+		WorkerCount:  3,
+		WorkerLabels: map[int][]string{1: {"has=postgres"}},
+	})
+
+	graph := build.Graph{
+		Jobs: []build.Job{
+			{
+				ID:   build.ID{'a'},
+				Name: "integration test",
+				Cmds: []build.Cmd{
+					{Exec: []string{"echo", "OK"}},
+				},
+				Requirements: build.Requirements{Labels: []string{"has=postgres"}},
+			},
+		},
+	}
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, graph, recorder))
+	assert.Equal(t, &JobResult{Stdout: "OK\n", Code: new(int)}, recorder.Jobs[build.ID{'a'}])
+
+	_, unlock, err := env.WorkerCache[1].Get(build.ID{'a'})
+	require.NoError(t, err)
+	unlock()
+}
+
+func TestUnsatisfiableJob(t *testing.T) {
+	env := newEnv(t, singleWorkerConfig)
+
+	graph := build.Graph{
+		Jobs: []build.Job{
+			{
+				ID:   build.ID{'a'},
+				Name: "huge link",
+				Cmds: []build.Cmd{
+					{Exec: []string{"echo", "OK"}},
+				},
+				Requirements: build.Requirements{Resources: build.Resources{Memory: 1 << 60}},
+			},
+		},
+	}
+
+	err := env.Client.Build(env.Ctx, graph, NewRecorder())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "huge link")
+}
@@ -0,0 +1,68 @@
+package disttest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+var journalConfig = &Config{WorkerCount: 1, CoordinatorJournal: true}
+
+// waitFile ждёт, пока джоб создаст файл path. Если файл так и не появился, тест падает, а не зависает.
+func waitFile(t *testing.T, path string) {
+	t.Helper()
+
+	require.Eventuallyf(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, 10*time.Second, 10*time.Millisecond, "file %s was not created", path)
+}
+
+func TestCoordinatorRestart(t *testing.T) {
+	env := newEnv(t, journalConfig)
+
+	startedMarker := filepath.Join(env.RootDir, "started")
+
+	graph := build.Graph{
+		Jobs: []build.Job{
+			{
+				ID:   build.ID{'a'},
+				Name: "echo",
+				Cmds: []build.Cmd{
+					{Exec: []string{"echo", "A"}},
+				},
+			},
+			{
+				ID:   build.ID{'b'},
+				Name: "slow echo",
+				Cmds: []build.Cmd{
+					{CatTemplate: "started", CatOutput: startedMarker}, // No-hermetic, for testing purposes.
+					{Exec: []string{"sleep", "1"}, Environ: os.Environ()},
+					{Exec: []string{"echo", "B"}},
+				},
+				Deps: []build.ID{{'a'}},
+			},
+		},
+	}
+
+	recorder := NewRecorder()
+	buildDone := make(chan error, 1)
+	go func() {
+		buildDone <- env.Client.Build(env.Ctx, graph, recorder)
+	}()
+
+	waitFile(t, startedMarker)
+	env.RestartCoordinator(t)
+
+	require.NoError(t, <-buildDone)
+
+	assert.Len(t, recorder.Jobs, 2)
+	assert.Equal(t, &JobResult{Stdout: "A\n", Code: new(int)}, recorder.Jobs[build.ID{'a'}])
+	assert.Equal(t, &JobResult{Stdout: "B\n", Code: new(int)}, recorder.Jobs[build.ID{'b'}])
+}
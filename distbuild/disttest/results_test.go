@@ -0,0 +1,100 @@
+package disttest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+func resultGraph(id byte, version string) build.Graph {
+	return build.Graph{
+		Jobs: []build.Job{
+			{
+				ID:   build.ID{id},
+				Name: "compile",
+				Cmds: []build.Cmd{
+					{CatTemplate: version, CatOutput: "{{.OutputDir}}/lib.a"},
+				},
+			},
+			{
+				ID:   build.ID{id + 1},
+				Name: "link",
+				Deps: []build.ID{{id}},
+				Cmds: []build.Cmd{
+					{CatTemplate: version, CatOutput: "{{.OutputDir}}/app"},
+					{Exec: []string{"mkdir", "{{.OutputDir}}/" + version}},
+				},
+				Output: "bin/app",
+			},
+		},
+	}
+}
+
+func TestDownloadOutputs(t *testing.T) {
+	env := newEnv(t, threeWorkerConfig)
+
+	out := filepath.Join(env.RootDir, "out")
+	env.Client.SetOutputDir(out)
+
+	require.NoError(t, env.Client.Build(env.Ctx, resultGraph('a', "v1"), NewRecorder()))
+
+	app, err := os.ReadFile(filepath.Join(out, "bin", "app", "app"))
+	require.NoError(t, err)
+	require.Equal(t, "v1", string(app))
+	require.DirExists(t, filepath.Join(out, "bin", "app", "v1"))
+
+	// Только джобы с Output попадают в выходную директорию.
+	require.NoFileExists(t, filepath.Join(out, "lib.a"))
+
+	require.NoError(t, env.Client.Build(env.Ctx, resultGraph('c', "v2"), NewRecorder()))
+
+	app, err = os.ReadFile(filepath.Join(out, "bin", "app", "app"))
+	require.NoError(t, err)
+	require.Equal(t, "v2", string(app))
+	require.NoDirExists(t, filepath.Join(out, "bin", "app", "v1"))
+
+	// Временные директории не остаются рядом с результатом.
+	entries, err := os.ReadDir(filepath.Join(out, "bin"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestDownloadOutputsTLS(t *testing.T) {
+	env := newEnv(t, &Config{WorkerCount: 3, TLS: true})
+
+	out := filepath.Join(env.RootDir, "out")
+	env.Client.SetOutputDir(out)
+
+	require.NoError(t, env.Client.Build(env.Ctx, resultGraph('a', "v1"), NewRecorder()))
+
+	app, err := os.ReadFile(filepath.Join(out, "bin", "app", "app"))
+	require.NoError(t, err)
+	require.Equal(t, "v1", string(app))
+}
+
+func TestFailedOutputNotDownloaded(t *testing.T) {
+	env := newEnv(t, singleWorkerConfig)
+
+	out := filepath.Join(env.RootDir, "out")
+	env.Client.SetOutputDir(out)
+
+	graph := build.Graph{
+		Jobs: []build.Job{
+			{
+				ID:     build.ID{'a'},
+				Name:   "fail",
+				Cmds:   []build.Cmd{{Exec: []string{"false"}}},
+				Output: "fail",
+			},
+		},
+	}
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, graph, recorder))
+	require.Equal(t, 1, *recorder.Jobs[build.ID{'a'}].Code)
+	require.NoDirExists(t, filepath.Join(out, "fail"))
+}
@@ -0,0 +1,100 @@
+package disttest
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+// flakyGraph содержит джоб, который падает при первом запуске и проходит при втором.
+func flakyGraph(marker string, retry build.RetryPolicy) build.Graph {
+	return build.Graph{
+		Jobs: []build.Job{
+			{
+				ID:    build.ID{'a'},
+				Name:  "flaky",
+				Retry: retry,
+				Cmds: []build.Cmd{
+					// No-hermetic, for testing purposes.
+					{Exec: []string{"sh", "-c", fmt.Sprintf("if [ -e %[1]s ]; then echo OK; else touch %[1]s; exit 3; fi", marker)}},
+				},
+			},
+		},
+	}
+}
+
+func TestFlakyJob(t *testing.T) {
+	env := newEnv(t, threeWorkerConfig)
+
+	graph := flakyGraph(filepath.Join(env.RootDir, "marker"), build.RetryPolicy{MaxAttempts: 2, ExitCodes: []int{3}})
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, graph, recorder))
+
+	require.Equal(t, &JobResult{Stdout: "OK\n", Code: new(int)}, recorder.Jobs[build.ID{'a'}])
+	require.Equal(t, map[build.ID]int{{'a'}: 1}, recorder.Flaky)
+
+	// Повторная попытка досталась другому воркеру: артефакт успешной попытки лежит не там, где джоб упал.
+	failure := recorder.Failures[build.ID{'a'}][0]
+	require.NotNil(t, failure.Trace)
+
+	var succeededOn []api.WorkerID
+	for i, cache := range env.WorkerCache {
+		if _, unlock, err := cache.Get(build.ID{'a'}); err == nil {
+			unlock()
+			succeededOn = append(succeededOn, api.WorkerID(env.WorkerEndpoints[i]))
+		}
+	}
+	require.NotEmpty(t, succeededOn)
+	require.NotContains(t, succeededOn, failure.Trace.WorkerID)
+}
+
+func TestNonRetryableFailure(t *testing.T) {
+	env := newEnv(t, threeWorkerConfig)
+
+	graph := flakyGraph(filepath.Join(env.RootDir, "marker"), build.RetryPolicy{MaxAttempts: 2, ExitCodes: []int{1}})
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, graph, recorder))
+
+	code := 3
+	require.Equal(t, &JobResult{Code: &code}, recorder.Jobs[build.ID{'a'}])
+	require.Empty(t, recorder.Flaky)
+}
+
+func TestFlakyJobCachedOutput(t *testing.T) {
+	env := newEnv(t, threeWorkerConfig)
+
+	marker := filepath.Join(env.RootDir, "marker")
+	graph := build.Graph{
+		Jobs: []build.Job{
+			{
+				ID:    build.ID{'a'},
+				Name:  "flaky",
+				Retry: build.RetryPolicy{MaxAttempts: 2, ExitCodes: []int{3}},
+				Cmds: []build.Cmd{
+					// No-hermetic, for testing purposes. Третий запуск напечатал бы RERUN,
+					// но во второй сборке результат джоба должен взяться из кеша.
+					{Exec: []string{"sh", "-c", fmt.Sprintf(
+						"if [ -e %[1]s.ok ]; then echo RERUN; elif [ -e %[1]s ]; then touch %[1]s.ok; echo OK; else touch %[1]s; echo FAIL; exit 3; fi",
+						marker,
+					)}},
+				},
+			},
+		},
+	}
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, graph, recorder))
+	require.Equal(t, &JobResult{Stdout: "OK\n", Code: new(int)}, recorder.Jobs[build.ID{'a'}])
+
+	// Вывод упавшей попытки не попал в кеш результатов вместе с выводом успешной.
+	recorder = NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, graph, recorder))
+	require.Equal(t, &JobResult{Stdout: "OK\n", Code: new(int)}, recorder.Jobs[build.ID{'a'}])
+}
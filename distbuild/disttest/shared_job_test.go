@@ -0,0 +1,88 @@
+package disttest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+func TestSharedJobCancel(t *testing.T) {
+	env := newEnv(t, threeWorkerConfig)
+
+	counter := filepath.Join(env.RootDir, "counter")
+
+	sharedJob := build.Job{
+		ID:   build.ID{'a'},
+		Name: "shared",
+		Cmds: []build.Cmd{
+			{Exec: []string{"bash", "-c", "echo run >> " + counter}, Environ: os.Environ()}, // No-hermetic, for testing purposes.
+			{Exec: []string{"sleep", "1"}, Environ: os.Environ()},
+			{Exec: []string{"echo", "OK"}},
+		},
+	}
+
+	firstGraph := build.Graph{Jobs: []build.Job{sharedJob}}
+	secondGraph := build.Graph{
+		Jobs: []build.Job{
+			sharedJob,
+			{
+				ID:   build.ID{'b'},
+				Name: "dependent",
+				Cmds: []build.Cmd{
+					{Exec: []string{"echo", "B"}},
+				},
+				Deps: []build.ID{{'a'}},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(env.Ctx)
+	defer cancel()
+
+	firstDone := make(chan error, 1)
+	go func() {
+		firstDone <- env.Client.Build(ctx, firstGraph, NewRecorder())
+	}()
+
+	waitFile(t, counter)
+
+	recorder := NewRecorder()
+	secondDone := make(chan error, 1)
+	go func() {
+		secondDone <- env.Client.Build(env.Ctx, secondGraph, recorder)
+	}()
+
+	// Общий джоб выдан воркеру в обеих сборках, значит вторая сборка уже подписалась на него в шедулере.
+	require.Eventually(t, func() bool {
+		running := 0
+		for _, b := range env.Coordinator.Builds() {
+			for _, job := range b.Jobs {
+				if job.ID == sharedJob.ID && job.State == api.JobStateRunning {
+					running++
+				}
+			}
+		}
+		return running == 2
+	}, 10*time.Second, 10*time.Millisecond)
+
+	cancel()
+	err := <-firstDone
+	require.Truef(t, errors.Is(err, context.Canceled), "%v", err)
+
+	require.NoError(t, <-secondDone)
+	assert.Equal(t, &JobResult{Stdout: "OK\n", Code: new(int)}, recorder.Jobs[build.ID{'a'}])
+	assert.Equal(t, &JobResult{Stdout: "B\n", Code: new(int)}, recorder.Jobs[build.ID{'b'}])
+
+	runs, err := os.ReadFile(counter)
+	require.NoError(t, err)
+	require.Equal(t, "run\n", string(runs))
+}
@@ -0,0 +1,75 @@
+package disttest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/auth"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+var tlsConfig = &Config{
+	WorkerCount: 1,
+	TLS:         true,
+}
+
+func TestTLS(t *testing.T) {
+	env := newEnv(t, tlsConfig)
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, echoGraph, recorder))
+
+	require.Len(t, recorder.Jobs, 1)
+	require.Equal(t, &JobResult{Stdout: "OK\n", Code: new(int)}, recorder.Jobs[echoGraph.Jobs[0].ID])
+}
+
+// TestTLSSourceFiles проверяет, что клиент без сертификата может залить исходники по токену.
+func TestTLSSourceFiles(t *testing.T) {
+	env := newEnv(t, tlsConfig)
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, sourceFilesGraph, recorder))
+
+	require.Len(t, recorder.Jobs, 1)
+	require.Equal(t, &JobResult{Stdout: "foo", Stderr: "bar", Code: new(int)}, recorder.Jobs[build.ID{'a'}])
+}
+
+func TestTLSRejectsStrangers(t *testing.T) {
+	env := newEnv(t, tlsConfig)
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, echoGraph, recorder))
+
+	post := func(client *http.Client, url string) int {
+		rsp, err := client.Post(url, "application/json", nil)
+		require.NoError(t, err)
+		defer rsp.Body.Close()
+		return rsp.StatusCode
+	}
+
+	anonymous := auth.HTTPClient(auth.ClientConfig(nil, env.CA.Pool()))
+	require.Equal(t, http.StatusUnauthorized, post(anonymous, env.CoordinatorEndpoint+"/build"))
+	require.Equal(t, http.StatusForbidden, post(anonymous, env.CoordinatorEndpoint+"/heartbeat"))
+	require.Equal(t, http.StatusUnauthorized, post(anonymous, env.CoordinatorEndpoint+"/file/chunks/missing"))
+
+	// Исходники скачивают только воркеры, токен клиента для этого не подходит.
+	rsp, err := anonymous.Get(env.CoordinatorEndpoint + "/file?id=" + build.ID{'a'}.String())
+	require.NoError(t, err)
+	_ = rsp.Body.Close()
+	require.Equal(t, http.StatusForbidden, rsp.StatusCode)
+
+	artifactURL := env.WorkerEndpoints[0] + "/artifact?id=" + echoGraph.Jobs[0].ID.String()
+
+	rsp, err = anonymous.Get(artifactURL)
+	require.NoError(t, err)
+	_ = rsp.Body.Close()
+	require.Equal(t, http.StatusForbidden, rsp.StatusCode)
+
+	stranger := env.CA.Issue(t, "https://127.0.0.1/worker/stranger")
+	rsp, err = auth.HTTPClient(auth.ClientConfig(&stranger, env.CA.Pool())).Get(artifactURL)
+	require.NoError(t, err)
+	_ = rsp.Body.Close()
+	require.Equal(t, http.StatusForbidden, rsp.StatusCode)
+}
@@ -0,0 +1,42 @@
+package disttest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	env := newEnv(t, singleWorkerConfig)
+
+	path := filepath.Join(t.TempDir(), "trace.json")
+	env.Client.SetTraceOutput(path)
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, echoGraph, recorder))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var file struct {
+		TraceEvents []struct {
+			Name string `json:"name"`
+			Ph   string `json:"ph"`
+		} `json:"traceEvents"`
+	}
+	require.NoError(t, json.Unmarshal(content, &file))
+
+	spans := map[string]bool{}
+	for _, e := range file.TraceEvents {
+		if e.Ph == "X" {
+			spans[e.Name] = true
+		}
+	}
+
+	require.True(t, spans[echoGraph.Jobs[0].Name], "job span is missing: %v", spans)
+	require.True(t, spans["cmd 0"])
+	require.True(t, spans["commit"])
+}
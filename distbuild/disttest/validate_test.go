@@ -0,0 +1,37 @@
+package disttest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+var cycleGraph = build.Graph{
+	Jobs: []build.Job{
+		{
+			ID:   build.ID{'a'},
+			Name: "a",
+			Deps: []build.ID{{'b'}},
+			Cmds: []build.Cmd{{Exec: []string{"echo", "a"}}},
+		},
+		{
+			ID:   build.ID{'b'},
+			Name: "b",
+			Deps: []build.ID{{'a'}},
+			Cmds: []build.Cmd{{Exec: []string{"echo", "b"}}},
+		},
+	},
+}
+
+func TestInvalidGraph(t *testing.T) {
+	env := newEnv(t, singleWorkerConfig)
+
+	recorder := NewRecorder()
+	err := env.Client.Build(env.Ctx, cycleGraph, recorder)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "dependency cycle")
+	require.Contains(t, err.Error(), `"a"`)
+	require.Empty(t, recorder.Jobs)
+}
@@ -0,0 +1,112 @@
+package disttest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/verify"
+)
+
+var reproducibleGraph = build.Graph{
+	Jobs: []build.Job{
+		{
+			ID:   build.ID{'a'},
+			Name: "write",
+			Cmds: []build.Cmd{
+				{CatTemplate: "OK", CatOutput: "{{.OutputDir}}/out.txt"},
+			},
+		},
+		{
+			ID:   build.ID{'b'},
+			Name: "cat",
+			Deps: []build.ID{{'a'}},
+			Cmds: []build.Cmd{
+				{Exec: []string{"cat", "{{index .Deps \"6100000000000000000000000000000000000000\"}}/out.txt"}},
+			},
+		},
+	},
+}
+
+var timestampGraph = build.Graph{
+	Jobs: []build.Job{
+		{
+			ID:   build.ID{'t'},
+			Name: "stamp",
+			Cmds: []build.Cmd{
+				{Exec: []string{"sh", "-c", "printf 'built at ' > {{.OutputDir}}/stamp && date +%s%N | tee -a {{.OutputDir}}/stamp"}},
+				{CatTemplate: "OK", CatOutput: "{{.OutputDir}}/out.txt"},
+			},
+		},
+		{
+			ID:   build.ID{'u'},
+			Name: "use",
+			Deps: []build.ID{{'t'}},
+			Cmds: []build.Cmd{
+				{Exec: []string{"cat", "{{index .Deps \"7400000000000000000000000000000000000000\"}}/stamp"}},
+			},
+		},
+	},
+}
+
+func TestVerifyReproducible(t *testing.T) {
+	env := newEnv(t, threeWorkerConfig)
+	env.Client.SetVerify(true)
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, reproducibleGraph, recorder))
+
+	require.Equal(t, &JobResult{Stdout: "OK", Code: new(int)}, recorder.Jobs[build.ID{'b'}])
+	require.Empty(t, recorder.Mismatches)
+}
+
+func TestVerifyMismatch(t *testing.T) {
+	env := newEnv(t, threeWorkerConfig)
+	env.Client.SetVerify(true)
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, timestampGraph, recorder))
+
+	mismatch := recorder.Mismatches[build.ID{'t'}]
+	require.NotNil(t, mismatch)
+	require.NotEqual(t, mismatch.Workers[0], mismatch.Workers[1])
+
+	require.Len(t, mismatch.Diffs, 1)
+	require.Equal(t, "stamp", mismatch.Diffs[0].Path)
+	require.Equal(t, verify.DiffContent, mismatch.Diffs[0].Kind)
+	require.GreaterOrEqual(t, mismatch.Diffs[0].Offset, int64(len("built at ")))
+
+	// Зависимый джоб оба раза видит артефакт первого запуска, поэтому сам по себе воспроизводим.
+	require.Len(t, recorder.Mismatches, 1)
+	require.Equal(t, "built at "+recorder.Jobs[build.ID{'t'}].Stdout, recorder.Jobs[build.ID{'u'}].Stdout)
+
+	// Недетерминированный джоб не попадает в кеш результатов, поэтому обычная сборка выполняет его заново.
+	env.Client.SetVerify(false)
+
+	rerun := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, timestampGraph, rerun))
+	require.NotEqual(t, recorder.Jobs[build.ID{'t'}].Stdout, rerun.Jobs[build.ID{'t'}].Stdout)
+}
+
+func TestVerifyMismatchTLS(t *testing.T) {
+	env := newEnv(t, &Config{WorkerCount: 3, TLS: true})
+	env.Client.SetVerify(true)
+
+	recorder := NewRecorder()
+	require.NoError(t, env.Client.Build(env.Ctx, timestampGraph, recorder))
+
+	mismatch := recorder.Mismatches[build.ID{'t'}]
+	require.NotNil(t, mismatch)
+	require.Len(t, mismatch.Diffs, 1)
+	require.Equal(t, "stamp", mismatch.Diffs[0].Path)
+}
+
+func TestVerifyNeedsTwoWorkers(t *testing.T) {
+	env := newEnv(t, singleWorkerConfig)
+	env.Client.SetVerify(true)
+
+	recorder := NewRecorder()
+	require.Error(t, env.Client.Build(env.Ctx, reproducibleGraph, recorder))
+	require.Empty(t, recorder.Jobs)
+}
@@ -0,0 +1,60 @@
+package disttest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+func TestWorkerDeath(t *testing.T) {
+	env := newEnv(t, threeWorkerConfig)
+
+	marker := filepath.Join(env.RootDir, "marker")
+
+	// On first run the job records its output dir and hangs. On second run it succeeds.
+	script := `if [ -e ` + marker + ` ]; then echo OK; else echo {{.OutputDir}} > ` + marker + `; exec sleep 1000; fi`
+
+	graph := build.Graph{
+		Jobs: []build.Job{
+			{
+				ID:   build.ID{'a'},
+				Name: "hang once",
+				Cmds: []build.Cmd{
+					{Exec: []string{"bash", "-c", script}, Environ: os.Environ()},
+				},
+			},
+		},
+	}
+
+	recorder := NewRecorder()
+	buildDone := make(chan error, 1)
+	go func() {
+		buildDone <- env.Client.Build(env.Ctx, graph, recorder)
+	}()
+
+	waitFile(t, marker)
+
+	outputDir, err := os.ReadFile(marker)
+	require.NoError(t, err)
+
+	victim := -1
+	for i := range env.Workers {
+		workerDir := filepath.Join(env.RootDir, fmt.Sprintf("worker%d", i)) + string(filepath.Separator)
+		if strings.HasPrefix(string(outputDir), workerDir) {
+			victim = i
+		}
+	}
+	require.NotEqual(t, -1, victim, "job output dir %q is outside of worker dirs", outputDir)
+
+	env.KillWorker(victim)
+
+	require.NoError(t, <-buildDone)
+	assert.Equal(t, &JobResult{Stdout: "OK\n", Code: new(int)}, recorder.Jobs[build.ID{'a'}])
+}
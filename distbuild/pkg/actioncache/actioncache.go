@@ -0,0 +1,214 @@
+package actioncache
+
+import (
+	"sort"
+	"sync"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/journal"
+)
+
+// DefaultMaxOutputSize задаёт максимальный суммарный размер stdout и stderr
+// джоба, который кеш готов хранить.
+const DefaultMaxOutputSize = 1 << 20
+
+// Entry описывает закешированный результат джоба.
+type Entry struct {
+	// Result содержит полный stdout и stderr джоба.
+	Result api.JobResult
+
+	// Workers перечисляет воркеров, у которых в кеше лежит артефакт джоба.
+	Workers []api.WorkerID
+}
+
+type output struct {
+	stdout, stderr []byte
+	truncated      bool
+}
+
+// Cache хранит успешные результаты джобов на координаторе.
+//
+// Выход джоба целиком определяется его ID, поэтому при повторной сборке джоб
+// можно не запускать, если его артефакт всё ещё лежит хотя бы на одном воркере.
+// Все методы Cache concurrency safe.
+type Cache struct {
+	maxOutputSize int
+
+	mu        sync.Mutex
+	results   map[build.ID]*api.JobResult
+	artifacts map[build.ID]map[api.WorkerID]struct{}
+	running   map[build.ID]*output
+}
+
+func New(maxOutputSize int) *Cache {
+	return &Cache{
+		maxOutputSize: maxOutputSize,
+		results:       map[build.ID]*api.JobResult{},
+		artifacts:     map[build.ID]map[api.WorkerID]struct{}{},
+		running:       map[build.ID]*output{},
+	}
+}
+
+// Restore создаёт кеш по состоянию, восстановленному из журнала координатора.
+func Restore(maxOutputSize int, state *journal.State) *Cache {
+	c := New(maxOutputSize)
+	for _, res := range state.JobResults {
+		if res.Error == nil && res.ExitCode == 0 {
+			restored := *res
+			restored.Trace = nil
+			c.putResult(&restored)
+		}
+	}
+
+	for id, workers := range state.Artifacts {
+		for _, w := range workers {
+			c.addArtifact(w, id)
+		}
+	}
+	return c
+}
+
+// AppendOutput накапливает вывод бегущего джоба из HeartbeatRequest.JobOutput.
+//
+// Если вывод джоба превысил maxOutputSize, кеш перестаёт его копить, и результат
+// этого джоба не будет закеширован.
+func (c *Cache) AppendOutput(out *api.JobOutput) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o, ok := c.running[out.ID]
+	if !ok {
+		o = &output{}
+		c.running[out.ID] = o
+	}
+
+	if o.truncated {
+		return
+	}
+
+	if len(o.stdout)+len(o.stderr)+len(out.Stdout)+len(out.Stderr) > c.maxOutputSize {
+		o.stdout, o.stderr, o.truncated = nil, nil, true
+		return
+	}
+
+	o.stdout = append(o.stdout, out.Stdout...)
+	o.stderr = append(o.stderr, out.Stderr...)
+}
+
+// OnJobFinished запоминает результат джоба, выполненного на воркере workerID.
+//
+// Неуспешные результаты не кешируются, но накопленный вывод джоба выбрасывается и для них,
+// поэтому вывод упавшей попытки не склеивается с выводом её перезапуска.
+func (c *Cache) OnJobFinished(workerID api.WorkerID, res *api.JobResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o := c.running[res.ID]
+	delete(c.running, res.ID)
+
+	if res.Error != nil || res.ExitCode != 0 {
+		return
+	}
+
+	// Трейс описывает конкретный запуск, а закешированные результаты приходят клиенту без трейса.
+	full := *res
+	full.Cached = false
+	full.Trace = nil
+	if o != nil {
+		if o.truncated {
+			return
+		}
+
+		full.Stdout = append(o.stdout, res.Stdout...)
+		full.Stderr = append(o.stderr, res.Stderr...)
+	}
+
+	if len(full.Stdout)+len(full.Stderr) > c.maxOutputSize {
+		return
+	}
+
+	c.putResult(&full)
+	c.addArtifact(workerID, res.ID)
+}
+
+// Forget выбрасывает вывод бегущего джоба, накопленный через AppendOutput.
+//
+// Координатор вызывает Forget, когда джоб перестаёт выполняться, не прислав FinishedJob: его убили
+// через JobsToKill, воркер умер или шедулер вернул джоб в очередь. Иначе вывод прерванной попытки
+// остался бы в памяти и склеился бы с выводом следующей.
+func (c *Cache) Forget(id build.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.running, id)
+}
+
+func (c *Cache) putResult(res *api.JobResult) {
+	c.results[res.ID] = res
+}
+
+// AddArtifact запоминает, что артефакт лежит на воркере.
+func (c *Cache) AddArtifact(workerID api.WorkerID, id build.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.addArtifact(workerID, id)
+}
+
+func (c *Cache) addArtifact(workerID api.WorkerID, id build.ID) {
+	workers, ok := c.artifacts[id]
+	if !ok {
+		workers = map[api.WorkerID]struct{}{}
+		c.artifacts[id] = workers
+	}
+	workers[workerID] = struct{}{}
+}
+
+// RemoveArtifact забывает, что артефакт лежит на воркере.
+func (c *Cache) RemoveArtifact(workerID api.WorkerID, id build.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.artifacts[id], workerID)
+	if len(c.artifacts[id]) == 0 {
+		delete(c.artifacts, id)
+	}
+}
+
+// RemoveWorker забывает все артефакты воркера.
+func (c *Cache) RemoveWorker(workerID api.WorkerID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, workers := range c.artifacts {
+		delete(workers, workerID)
+		if len(workers) == 0 {
+			delete(c.artifacts, id)
+		}
+	}
+}
+
+// Get возвращает закешированный результат джоба.
+//
+// Результат возвращается, только если артефакт джоба всё ещё лежит хотя бы на одном воркере.
+// У возвращённого результата выставлено поле Cached.
+func (c *Cache) Get(id build.ID) (*Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res, ok := c.results[id]
+	if !ok || len(c.artifacts[id]) == 0 {
+		return nil, false
+	}
+
+	e := &Entry{Result: *res}
+	e.Result.Cached = true
+	for w := range c.artifacts[id] {
+		e.Workers = append(e.Workers, w)
+	}
+	sort.Slice(e.Workers, func(i, j int) bool {
+		return e.Workers[i] < e.Workers[j]
+	})
+	return e, true
+}
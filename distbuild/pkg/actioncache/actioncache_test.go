@@ -0,0 +1,140 @@
+package actioncache_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/actioncache"
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/journal"
+)
+
+func TestActionCache(t *testing.T) {
+	c := actioncache.New(actioncache.DefaultMaxOutputSize)
+
+	id := build.ID{'a'}
+
+	_, ok := c.Get(id)
+	require.False(t, ok)
+
+	c.AppendOutput(&api.JobOutput{ID: id, Stdout: []byte("foo")})
+	c.AppendOutput(&api.JobOutput{ID: id, Stderr: []byte("err")})
+	c.OnJobFinished("w0", &api.JobResult{ID: id, Stdout: []byte("bar")})
+	c.AddArtifact("w1", id)
+
+	e, ok := c.Get(id)
+	require.True(t, ok)
+	require.Equal(t, &actioncache.Entry{
+		Result: api.JobResult{
+			ID:     id,
+			Stdout: []byte("foobar"),
+			Stderr: []byte("err"),
+			Cached: true,
+		},
+		Workers: []api.WorkerID{"w0", "w1"},
+	}, e)
+
+	c.RemoveArtifact("w0", id)
+	c.RemoveWorker("w1")
+
+	_, ok = c.Get(id)
+	require.False(t, ok)
+}
+
+func TestActionCacheSkipsFailures(t *testing.T) {
+	c := actioncache.New(4)
+
+	errorString := "failed"
+	c.OnJobFinished("w0", &api.JobResult{ID: build.ID{'a'}, ExitCode: 1})
+	c.OnJobFinished("w0", &api.JobResult{ID: build.ID{'b'}, Error: &errorString})
+
+	c.AppendOutput(&api.JobOutput{ID: build.ID{'c'}, Stdout: []byte("foo")})
+	c.AppendOutput(&api.JobOutput{ID: build.ID{'c'}, Stdout: []byte("bar")})
+	c.OnJobFinished("w0", &api.JobResult{ID: build.ID{'c'}})
+
+	for _, id := range []build.ID{{'a'}, {'b'}, {'c'}} {
+		_, ok := c.Get(id)
+		require.False(t, ok, "%v", id)
+	}
+}
+
+func TestActionCacheRestore(t *testing.T) {
+	c := actioncache.Restore(actioncache.DefaultMaxOutputSize, &journal.State{
+		JobResults: map[build.ID]*api.JobResult{
+			{'a'}: {ID: build.ID{'a'}, Stdout: []byte("OK")},
+			{'b'}: {ID: build.ID{'b'}, ExitCode: 1},
+		},
+		Artifacts: map[build.ID][]api.WorkerID{
+			{'a'}: {"w0"},
+			{'b'}: {"w0"},
+		},
+	})
+
+	e, ok := c.Get(build.ID{'a'})
+	require.True(t, ok)
+	require.Equal(t, []byte("OK"), e.Result.Stdout)
+
+	_, ok = c.Get(build.ID{'b'})
+	require.False(t, ok)
+}
+
+func TestActionCacheForget(t *testing.T) {
+	c := actioncache.New(actioncache.DefaultMaxOutputSize)
+
+	id := build.ID{'a'}
+
+	c.AppendOutput(&api.JobOutput{ID: id, Stdout: []byte("killed")})
+	c.Forget(id)
+
+	c.AppendOutput(&api.JobOutput{ID: id, Stdout: []byte("foo")})
+	c.OnJobFinished("w0", &api.JobResult{ID: id, Stdout: []byte("bar")})
+
+	e, ok := c.Get(id)
+	require.True(t, ok)
+	require.Equal(t, []byte("foobar"), e.Result.Stdout)
+}
+
+func TestActionCacheRetry(t *testing.T) {
+	c := actioncache.New(actioncache.DefaultMaxOutputSize)
+
+	id := build.ID{'a'}
+
+	c.AppendOutput(&api.JobOutput{ID: id, Stdout: []byte("FAIL\n")})
+	c.OnJobFinished("w0", &api.JobResult{ID: id, ExitCode: 3})
+
+	_, ok := c.Get(id)
+	require.False(t, ok)
+
+	// Шедулер вернул джоб в очередь, и координатор вызывает Forget перед следующей попыткой.
+	c.Forget(id)
+
+	c.AppendOutput(&api.JobOutput{ID: id, Stdout: []byte("OK\n")})
+	c.OnJobFinished("w1", &api.JobResult{ID: id})
+
+	e, ok := c.Get(id)
+	require.True(t, ok)
+	require.Equal(t, []byte("OK\n"), e.Result.Stdout)
+	require.Equal(t, []api.WorkerID{"w1"}, e.Workers)
+}
+
+func TestActionCacheDropsTrace(t *testing.T) {
+	trace := &api.JobTrace{WorkerID: "w0"}
+
+	c := actioncache.New(actioncache.DefaultMaxOutputSize)
+	c.OnJobFinished("w0", &api.JobResult{ID: build.ID{'a'}, Trace: trace})
+
+	e, ok := c.Get(build.ID{'a'})
+	require.True(t, ok)
+	require.Nil(t, e.Result.Trace)
+
+	c = actioncache.Restore(actioncache.DefaultMaxOutputSize, &journal.State{
+		JobResults: map[build.ID]*api.JobResult{{'a'}: {ID: build.ID{'a'}, Trace: trace}},
+		Artifacts:  map[build.ID][]api.WorkerID{{'a'}: {"w0"}},
+	})
+
+	e, ok = c.Get(build.ID{'a'})
+	require.True(t, ok)
+	require.Nil(t, e.Result.Trace)
+}
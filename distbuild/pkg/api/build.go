@@ -4,10 +4,15 @@ import (
 	"context"
 
 	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/verify"
 )
 
 type BuildRequest struct {
 	Graph build.Graph
+
+	// Verify включает проверку воспроизводимости: координатор выполняет каждый джоб на двух
+	// разных воркерах и сравнивает их артефакты. Расхождения приходят в StatusUpdate.JobMismatch.
+	Verify bool
 }
 
 type BuildStarted struct {
@@ -16,13 +21,42 @@ type BuildStarted struct {
 }
 
 type StatusUpdate struct {
+	JobOutput     *JobOutput
 	JobFinished   *JobResult
+	JobFlaky      *JobFlaky
+	JobMismatch   *JobMismatch
 	BuildFailed   *BuildFailed
 	BuildFinished *BuildFinished
 }
 
+// JobFlaky сообщает, что джоб падал, но прошёл после перезапуска.
+//
+// Координатор посылает JobFlaky перед JobFinished с успешным результатом джоба.
+type JobFlaky struct {
+	ID build.ID
+
+	// Failures содержит результаты упавших попыток в порядке запуска.
+	Failures []JobResult
+}
+
+// JobMismatch сообщает, что два запуска джоба в режиме BuildRequest.Verify дали разные артефакты.
+//
+// Координатор посылает JobMismatch перед JobFinished джоба.
+type JobMismatch struct {
+	ID build.ID
+
+	// Workers - воркеры, на которых выполнялись первый и второй запуск.
+	Workers [2]WorkerID
+
+	// Diffs перечисляет отличающиеся файлы артефакта второго запуска относительно первого.
+	Diffs []verify.FileDiff
+}
+
 type BuildFailed struct {
 	Error string
+
+	// Cancelled выставляется, если сборка была отменена клиентом через сигнал Cancel.
+	Cancelled bool
 }
 
 type BuildFinished struct {
@@ -30,8 +64,16 @@ type BuildFinished struct {
 
 type UploadDone struct{}
 
+// Cancel отменяет сборку.
+//
+// Координатор убирает из очереди все джобы сборки, которые ещё не начали выполняться,
+// останавливает уже запущенные джобы и завершает сборку с BuildFailed{Cancelled: true}.
+// Это обновление получают все слушатели сборки, в том числе подключившиеся через AttachBuild.
+type Cancel struct{}
+
 type SignalRequest struct {
 	UploadDone *UploadDone
+	Cancel     *Cancel
 }
 
 type SignalResponse struct {
@@ -43,11 +85,30 @@ type StatusWriter interface {
 }
 
 type Service interface {
+	// StartBuild запускает сборку графа из request.
+	//
+	// Граф проверяется через build.Validate до того, как клиенту отправляется BuildStarted.
+	// Некорректный граф отклоняется ошибкой *build.ValidationError, и клиент ничего не заливает.
 	StartBuild(ctx context.Context, request *BuildRequest, w StatusWriter) error
 	SignalBuild(ctx context.Context, buildID build.ID, signal *SignalRequest) (*SignalResponse, error)
+
+	// AttachBuild подключается к уже запущенной сборке.
+	//
+	// Первым сообщением в w передаётся BuildStarted, после чего заново передаются результаты всех
+	// уже завершённых джобов сборки.
+	AttachBuild(ctx context.Context, buildID build.ID, w StatusWriter) error
 }
 
 type StatusReader interface {
 	Close() error
 	Next() (*StatusUpdate, error)
 }
+
+// BuildServiceClient описывает клиентскую сторону Service.
+//
+// Его реализуют BuildClient, который ходит по HTTP, и grpcapi.BuildClient.
+type BuildServiceClient interface {
+	StartBuild(ctx context.Context, request *BuildRequest) (*BuildStarted, StatusReader, error)
+	SignalBuild(ctx context.Context, buildID build.ID, signal *SignalRequest) (*SignalResponse, error)
+	AttachBuild(ctx context.Context, buildID build.ID) (*BuildStarted, StatusReader, error)
+}
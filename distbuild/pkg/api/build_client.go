@@ -4,6 +4,7 @@ package api
 
 import (
 	"context"
+	"net/http"
 
 	"go.uber.org/zap"
 
@@ -13,10 +14,19 @@ import (
 type BuildClient struct {
 }
 
+var _ BuildServiceClient = (*BuildClient)(nil)
+
 func NewBuildClient(l *zap.Logger, endpoint string) *BuildClient {
 	panic("implement me")
 }
 
+// SetHTTPClient задаёт http.Client, через который ходит клиент. По умолчанию используется http.DefaultClient.
+//
+// Через него клиенту передаются настройки TLS и авторизации, см. пакет auth.
+func (c *BuildClient) SetHTTPClient(client *http.Client) {
+	panic("implement me")
+}
+
 func (c *BuildClient) StartBuild(ctx context.Context, request *BuildRequest) (*BuildStarted, StatusReader, error) {
 	panic("implement me")
 }
@@ -24,3 +34,7 @@ func (c *BuildClient) StartBuild(ctx context.Context, request *BuildRequest) (*B
 func (c *BuildClient) SignalBuild(ctx context.Context, buildID build.ID, signal *SignalRequest) (*SignalResponse, error) {
 	panic("implement me")
 }
+
+func (c *BuildClient) AttachBuild(ctx context.Context, buildID build.ID) (*BuildStarted, StatusReader, error) {
+	panic("implement me")
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"time"
 
 	"gitlab.com/slon/shad-go/distbuild/pkg/build"
 )
@@ -10,6 +11,8 @@ import (
 type JobResult struct {
 	ID build.ID
 
+	// Stdout и Stderr содержат только тот вывод джоба, который ещё не был отправлен
+	// координатору через HeartbeatRequest.JobOutput.
 	Stdout, Stderr []byte
 
 	ExitCode int
@@ -17,7 +20,55 @@ type JobResult struct {
 	// Error описывает сообщение об ошибке, из-за которого джоб не удалось выполнить.
 	//
 	// Если Error == nil, значит джоб завершился успешно.
+	//
+	// Если джоб упёрся в ограничение песочницы воркера, Error называет это ограничение.
 	Error *string
+
+	// Cached выставляется координатором, если джоб не запускался, а его результат взят из кеша.
+	Cached bool
+
+	// Trace описывает, на что ушло время джоба. У закешированных результатов Trace == nil.
+	Trace *JobTrace
+
+	// Digest - verify.Manifest.Sum выходной директории джоба. Воркер заполняет его только для
+	// джобов с JobSpec.Verify.
+	Digest *build.ID
+}
+
+// JobTrace содержит времена фаз выполнения джоба.
+//
+// Поля Scheduled и Picked заполняет координатор, остальные - воркер. Времена сняты
+// часами разных машин, поэтому могут быть немного рассинхронизированы.
+type JobTrace struct {
+	// WorkerID и Slot задают воркера и номер слота воркера (от 0 до числа слотов), в котором выполнялся джоб.
+	WorkerID WorkerID
+	Slot     int
+
+	// Scheduled - момент, когда координатор поставил джоб в очередь шедулера.
+	Scheduled time.Time
+
+	// Picked - момент, когда координатор отдал джоб воркеру в HeartbeatResponse.
+	Picked time.Time
+
+	// DepsDownloaded - момент, когда воркер скачал исходные файлы и артефакты зависимостей.
+	DepsDownloaded time.Time
+
+	// Cmds содержит начало и конец каждой команды из Job.Cmds.
+	Cmds []CmdTrace
+
+	// Committed - момент, когда воркер закоммитил артефакт джоба в кеш.
+	Committed time.Time
+}
+
+type CmdTrace struct {
+	Start, End time.Time
+}
+
+// JobOutput описывает очередной кусок вывода бегущего джоба.
+type JobOutput struct {
+	ID build.ID
+
+	Stdout, Stderr []byte
 }
 
 type WorkerID string
@@ -41,12 +92,30 @@ type HeartbeatRequest struct {
 	// FreeSlots сообщает, сколько еще процессов можно запустить на этом воркере.
 	FreeSlots int
 
+	// Capacity сообщает, сколько всего ресурсов есть у воркера.
+	Capacity build.Resources
+
+	// FreeResources сообщает, сколько ресурсов воркера не занято бегущими джобами.
+	FreeResources build.Resources
+
+	// Labels перечисляет метки воркера в формате key=value.
+	Labels []string
+
 	// JobResult сообщает координатору, какие джобы завершили исполнение на этом воркере
 	// на этой итерации цикла.
 	FinishedJob []JobResult
 
+	// JobOutput содержит вывод бегущих джобов, накопленный на этой итерации цикла.
+	//
+	// Вывод одного джоба приходит в порядке записи. Весь вывод, отправленный через JobOutput,
+	// приходит раньше FinishedJob этого джоба.
+	JobOutput []JobOutput
+
 	// AddedArtifacts говорит, какие артефакты появились в кеше на этой итерации цикла.
 	AddedArtifacts []build.ID
+
+	// RemovedArtifacts говорит, какие артефакты были вытеснены из кеша на этой итерации цикла.
+	RemovedArtifacts []build.ID
 }
 
 // JobSpec описывает джоб, который нужно запустить.
@@ -57,11 +126,44 @@ type JobSpec struct {
 	// Artifacts задаёт воркеров, с которых можно скачать артефакты необходимые этому джобу.
 	Artifacts map[build.ID]WorkerID
 
+	// Priority задаёт приоритет джоба в очередях шедулера. Воркер это поле игнорирует.
+	//
+	// Координатор вычисляет приоритет через scheduler.CriticalPath.
+	Priority time.Duration
+
+	// Verify просит воркера посчитать JobResult.Digest.
+	Verify bool
+
+	// ExcludeWorker запрещает шедулеру отдавать джоб этому воркеру. Координатор заполняет его при
+	// втором запуске джоба в режиме BuildRequest.Verify. Воркер это поле игнорирует.
+	ExcludeWorker WorkerID
+
 	build.Job
 }
 
 type HeartbeatResponse struct {
 	JobsToRun map[build.ID]JobSpec
+
+	// JobsToKill перечисляет джобы из RunningJobs, которые больше не нужны ни одной сборке.
+	//
+	// Воркер должен убить процессы этих джобов. Результат убитого джоба не нужно
+	// посылать координатору.
+	JobsToKill []build.ID
+
+	// Peers перечисляет живых воркеров кластера. Если кластер работает по mTLS, воркер отдаёт
+	// артефакты только воркерам из этого списка (auth.Peers).
+	Peers []WorkerID
+
+	// PausedOutput перечисляет бегущие джобы, вывод которых координатор сейчас не успевает переслать клиенту.
+	//
+	// Воркер не отправляет вывод этих джобов в следующем HeartbeatRequest и не забирает его из
+	// OutputBuffer. Буфер заполняется, и процесс джоба блокируется на записи, а heartbeat-ы
+	// продолжают ходить как обычно.
+	PausedOutput []build.ID
+
+	// Coordinator - идентификатор из сертификата координатора. Координатор скачивает артефакты с
+	// воркеров для клиента, поэтому на mTLS кластере воркер отдаёт их ему вне зависимости от Peers.
+	Coordinator string
 }
 
 type HeartbeatService interface {
@@ -4,6 +4,7 @@ package api
 
 import (
 	"context"
+	"net/http"
 
 	"go.uber.org/zap"
 )
@@ -15,6 +16,13 @@ func NewHeartbeatClient(l *zap.Logger, endpoint string) *HeartbeatClient {
 	panic("implement me")
 }
 
+// SetHTTPClient задаёт http.Client, через который ходит клиент. По умолчанию используется http.DefaultClient.
+//
+// Через него клиенту передаются настройки TLS и авторизации, см. пакет auth.
+func (c *HeartbeatClient) SetHTTPClient(client *http.Client) {
+	panic("implement me")
+}
+
 func (c *HeartbeatClient) Heartbeat(ctx context.Context, req *HeartbeatRequest) (*HeartbeatResponse, error) {
 	panic("implement me")
 }
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"time"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+// JobState описывает, на какой стадии находится джоб сборки.
+type JobState string
+
+const (
+	// JobStatePending - джоб ждёт зависимостей или стоит в очереди шедулера.
+	JobStatePending JobState = "pending"
+	// JobStateRunning - джоб отдан воркеру.
+	JobStateRunning JobState = "running"
+	// JobStateFinished - джоб успешно завершился или его результат взят из кеша.
+	JobStateFinished JobState = "finished"
+	// JobStateFailed - джоб завершился с ошибкой.
+	JobStateFailed JobState = "failed"
+)
+
+// BuildStatus описывает активную сборку координатора.
+type BuildStatus struct {
+	ID      build.ID
+	Started time.Time
+
+	// Jobs перечисляет джобы сборки в том же порядке, что и build.Graph.Jobs.
+	Jobs []JobStatus
+}
+
+// JobStatus описывает состояние одного джоба сборки.
+type JobStatus struct {
+	ID    build.ID
+	Name  string
+	State JobState
+
+	// WorkerID - воркер, которому координатор отдал последнюю попытку джоба.
+	// Пустой, пока джоб стоит в очереди, и у джобов, результат которых взят из кеша.
+	WorkerID WorkerID `json:",omitempty"`
+}
+
+// WorkerStatus описывает воркера, подключённого к координатору.
+type WorkerStatus struct {
+	ID WorkerID
+
+	// FreeSlots - число свободных слотов из последнего heartbeat-а.
+	FreeSlots int
+
+	// Artifacts - число артефактов в кеше воркера по данным координатора.
+	Artifacts int
+
+	LastHeartbeat time.Time
+}
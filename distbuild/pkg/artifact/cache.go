@@ -1,12 +1,15 @@
 package artifact
 
 import (
+	"container/list"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"time"
 
 	"gitlab.com/slon/shad-go/distbuild/pkg/build"
 )
@@ -25,6 +28,38 @@ type Cache struct {
 	mu          sync.Mutex
 	writeLocked map[build.ID]struct{}
 	readLocked  map[build.ID]int
+
+	// lru упорядочивает артефакты по времени последнего использования, начиная с самого свежего.
+	lru      *list.List
+	entries  map[build.ID]*list.Element
+	size     int64
+	maxSize  int64
+	evicted  []build.ID
+	evicting bool
+
+	stats Stats
+}
+
+// Stats содержит статистику кеша с момента его создания.
+type Stats struct {
+	// Hits и Misses считают успешные вызовы Get и вызовы Get, вернувшие ErrNotFound.
+	Hits, Misses int64
+
+	// Entries и Size описывают текущее содержимое кеша.
+	Entries int
+	Size    int64
+
+	// AddedBytes - суммарный размер закоммиченных артефактов.
+	AddedBytes int64
+
+	// Evictions и EvictedBytes считают артефакты, вытесненные из кеша из-за ограничения размера.
+	Evictions    int64
+	EvictedBytes int64
+}
+
+type entry struct {
+	id   build.ID
+	size int64
 }
 
 func NewCache(root string) (*Cache, error) {
@@ -49,12 +84,219 @@ func NewCache(root string) (*Cache, error) {
 		}
 	}
 
-	return &Cache{
+	c := &Cache{
 		tmpDir:      tmpDir,
 		cacheDir:    cacheDir,
 		writeLocked: make(map[build.ID]struct{}),
 		readLocked:  make(map[build.ID]int),
-	}, nil
+		lru:         list.New(),
+		entries:     make(map[build.ID]*list.Element),
+	}
+
+	if err := c.loadEntries(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// loadEntries восстанавливает размеры и порядок использования артефактов, уже лежащих на диске.
+//
+// Время последнего использования артефакта хранится в mtime его директории.
+func (c *Cache) loadEntries() error {
+	type loaded struct {
+		entry
+		mtime time.Time
+	}
+
+	var all []loaded
+	err := c.Range(func(id build.ID) error {
+		path := filepath.Join(c.cacheDir, id.Path())
+
+		st, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		size, err := dirSize(path)
+		if err != nil {
+			return err
+		}
+
+		all = append(all, loaded{entry: entry{id: id, size: size}, mtime: st.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].mtime.After(all[j].mtime)
+	})
+
+	for _, e := range all {
+		c.entries[e.id] = c.lru.PushBack(&e.entry)
+		c.size += e.size
+	}
+
+	return nil
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// SetMaxSize ограничивает суммарный размер артефактов в кеше.
+//
+// Если размер превышен, кеш удаляет артефакты, которые дольше всего не использовались.
+// Артефакты, на которые взят лок на чтение или запись, никогда не удаляются. Последний
+// добавленный или прочитанный артефакт тоже не удаляется, даже если он один больше maxSize.
+//
+// maxSize == 0 снимает ограничение.
+func (c *Cache) SetMaxSize(maxSize int64) {
+	c.mu.Lock()
+	c.maxSize = maxSize
+	c.mu.Unlock()
+
+	c.evict()
+}
+
+// Size возвращает суммарный размер артефактов в кеше.
+func (c *Cache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.size
+}
+
+// Stats возвращает статистику кеша.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	stats.Size = c.size
+	return stats
+}
+
+// TakeEvicted возвращает артефакты, вытесненные из кеша с прошлого вызова TakeEvicted.
+//
+// Артефакты, удалённые явным вызовом Remove, сюда не попадают.
+func (c *Cache) TakeEvicted() []build.ID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	evicted := c.evicted
+	c.evicted = nil
+	return evicted
+}
+
+// touch помечает артефакт как только что использованный. Вызывается под c.mu.
+//
+// Время использования на диске обновляет вызывающий код уже после c.mu.Unlock(), см. Get.
+func (c *Cache) touch(id build.ID) {
+	if e, ok := c.entries[id]; ok {
+		c.lru.MoveToFront(e)
+	}
+}
+
+// pickVictims выбирает артефакты для вытеснения, убирает их из учёта размера и берёт на них лок на запись.
+// Артефакты из skip не выбираются. Вызывается под c.mu.
+func (c *Cache) pickVictims(skip map[build.ID]struct{}) []*entry {
+	var victims []*entry
+
+	e := c.lru.Back()
+	for c.maxSize != 0 && c.size > c.maxSize && e != nil && e != c.lru.Front() {
+		prev := e.Prev()
+
+		victim := e.Value.(*entry)
+		_, writeLocked := c.writeLocked[victim.id]
+		_, skipped := skip[victim.id]
+		if !writeLocked && !skipped && c.readLocked[victim.id] == 0 {
+			c.lru.Remove(e)
+			delete(c.entries, victim.id)
+			c.size -= victim.size
+
+			c.writeLocked[victim.id] = struct{}{}
+			victims = append(victims, victim)
+		}
+
+		e = prev
+	}
+
+	return victims
+}
+
+func (c *Cache) evict() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.evicting {
+		return
+	}
+
+	c.evicting = true
+	defer func() { c.evicting = false }()
+
+	// failed содержит артефакты, которые не удалось удалить в этом проходе. Они остаются в учёте размера
+	// и снова станут кандидатами при следующем вытеснении.
+	failed := make(map[build.ID]struct{})
+
+	for {
+		victims := c.pickVictims(failed)
+		if len(victims) == 0 {
+			return
+		}
+
+		c.mu.Unlock()
+		remaining := make([]int64, len(victims))
+		removed := make([]bool, len(victims))
+		for i, victim := range victims {
+			path := filepath.Join(c.cacheDir, victim.id.Path())
+			if removed[i] = os.RemoveAll(path) == nil; !removed[i] {
+				// RemoveAll мог успеть удалить часть файлов, поэтому размер считаем заново.
+				remaining[i], _ = dirSize(path)
+			}
+		}
+		c.mu.Lock()
+
+		for i, victim := range victims {
+			delete(c.writeLocked, victim.id)
+
+			if removed[i] {
+				c.stats.Evictions++
+				c.stats.EvictedBytes += victim.size
+				c.evicted = append(c.evicted, victim.id)
+				continue
+			}
+
+			victim.size = remaining[i]
+			c.entries[victim.id] = c.lru.PushBack(victim)
+			c.size += victim.size
+			failed[victim.id] = struct{}{}
+		}
+	}
+}
+
+// forget убирает артефакт из учёта размера. Вызывается под c.mu.
+func (c *Cache) forget(id build.ID) {
+	if e, ok := c.entries[id]; ok {
+		c.lru.Remove(e)
+		delete(c.entries, id)
+		c.size -= e.Value.(*entry).size
+	}
 }
 
 func (c *Cache) readLock(id build.ID) error {
@@ -141,6 +383,10 @@ func (c *Cache) Remove(artifact build.ID) error {
 	}
 	defer c.writeUnlock(artifact)
 
+	c.mu.Lock()
+	c.forget(artifact)
+	c.mu.Unlock()
+
 	return os.RemoveAll(filepath.Join(c.cacheDir, artifact.Path()))
 }
 
@@ -161,8 +407,26 @@ func (c *Cache) Create(artifact build.ID) (path string, commit, abort func() err
 	}
 
 	commit = func() error {
-		defer c.writeUnlock(artifact)
-		return os.Rename(path, filepath.Join(c.cacheDir, artifact.Path()))
+		size, err := dirSize(path)
+		if err != nil {
+			c.writeUnlock(artifact)
+			return err
+		}
+
+		if err := os.Rename(path, filepath.Join(c.cacheDir, artifact.Path())); err != nil {
+			c.writeUnlock(artifact)
+			return err
+		}
+
+		c.mu.Lock()
+		c.entries[artifact] = c.lru.PushFront(&entry{id: artifact, size: size})
+		c.size += size
+		c.stats.AddedBytes += size
+		delete(c.writeLocked, artifact)
+		c.mu.Unlock()
+
+		c.evict()
+		return nil
 	}
 
 	return
@@ -179,10 +443,24 @@ func (c *Cache) Get(artifact build.ID) (path string, unlock func(), err error) {
 
 		if os.IsNotExist(err) {
 			err = ErrNotFound
+
+			c.mu.Lock()
+			c.stats.Misses++
+			c.mu.Unlock()
 		}
 		return
 	}
 
+	c.mu.Lock()
+	c.stats.Hits++
+	c.touch(artifact)
+	c.mu.Unlock()
+
+	// mtime переживает рестарт, по нему loadEntries восстанавливает порядок LRU. Лок на чтение
+	// не даёт удалить артефакт, поэтому диск трогаем уже без c.mu.
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
 	unlock = func() {
 		c.readUnlock(artifact)
 	}
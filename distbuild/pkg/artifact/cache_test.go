@@ -96,3 +96,127 @@ func TestArtifactExists(t *testing.T) {
 	_, _, _, err = c.Create(idA)
 	require.Truef(t, errors.Is(err, artifact.ErrExists), "%v", err)
 }
+
+func createArtifact(t *testing.T, c *testCache, id build.ID, size int) {
+	t.Helper()
+
+	path, commit, _, err := c.Create(id)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(path, "out"), make([]byte, size), 0666))
+	require.NoError(t, commit())
+}
+
+func TestCacheEviction(t *testing.T) {
+	c := newTestCache(t)
+	c.SetMaxSize(10)
+
+	idA, idB, idC := build.ID{'a'}, build.ID{'b'}, build.ID{'c'}
+
+	createArtifact(t, c, idA, 4)
+	createArtifact(t, c, idB, 4)
+	require.Empty(t, c.TakeEvicted())
+
+	_, unlock, err := c.Get(idA)
+	require.NoError(t, err)
+	unlock()
+
+	createArtifact(t, c, idC, 4)
+	require.Equal(t, []build.ID{idB}, c.TakeEvicted())
+	require.Equal(t, int64(8), c.Size())
+
+	_, _, err = c.Get(idB)
+	require.Truef(t, errors.Is(err, artifact.ErrNotFound), "%v", err)
+}
+
+func TestCacheEvictionSkipsLocked(t *testing.T) {
+	c := newTestCache(t)
+	c.SetMaxSize(10)
+
+	idA, idB, idC := build.ID{'a'}, build.ID{'b'}, build.ID{'c'}
+
+	createArtifact(t, c, idA, 4)
+
+	_, unlock, err := c.Get(idA)
+	require.NoError(t, err)
+	defer unlock()
+
+	createArtifact(t, c, idB, 4)
+	createArtifact(t, c, idC, 4)
+
+	require.Equal(t, []build.ID{idB}, c.TakeEvicted())
+
+	_, unlockA, err := c.Get(idA)
+	require.NoError(t, err)
+	unlockA()
+}
+
+func TestCacheEvictionRemoveFailed(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root ignores directory permissions")
+	}
+
+	c := newTestCache(t)
+	c.SetMaxSize(10)
+
+	idA, idB := build.ID{'a'}, build.ID{'b'}
+	createArtifact(t, c, idA, 4)
+
+	// Запрещаем удалять артефакт a вместе с его файлами.
+	dir := filepath.Join(c.tmpDir, "c", idA.Path())
+	require.NoError(t, os.Chmod(dir, 0555))
+	t.Cleanup(func() { _ = os.Chmod(dir, 0777) })
+
+	createArtifact(t, c, idB, 8)
+	require.Empty(t, c.TakeEvicted())
+	require.Equal(t, int64(12), c.Size())
+	require.Equal(t, 2, c.Stats().Entries)
+	require.Zero(t, c.Stats().Evictions)
+
+	require.NoError(t, os.Chmod(dir, 0777))
+	c.SetMaxSize(10)
+
+	require.Equal(t, []build.ID{idA}, c.TakeEvicted())
+	require.Equal(t, int64(8), c.Size())
+}
+
+func TestCacheSizeAfterRestart(t *testing.T) {
+	c := newTestCache(t)
+
+	createArtifact(t, c, build.ID{'a'}, 4)
+	createArtifact(t, c, build.ID{'b'}, 6)
+	require.NoError(t, c.Remove(build.ID{'b'}))
+	require.Equal(t, int64(4), c.Size())
+
+	reopened, err := artifact.NewCache(c.tmpDir)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), reopened.Size())
+}
+
+func TestCacheStats(t *testing.T) {
+	c := newTestCache(t)
+	c.SetMaxSize(10)
+
+	idA, idB, idC := build.ID{'a'}, build.ID{'b'}, build.ID{'c'}
+
+	createArtifact(t, c, idA, 4)
+	createArtifact(t, c, idB, 4)
+
+	_, unlock, err := c.Get(idA)
+	require.NoError(t, err)
+	unlock()
+
+	_, _, err = c.Get(idC)
+	require.Truef(t, errors.Is(err, artifact.ErrNotFound), "%v", err)
+
+	createArtifact(t, c, idC, 4)
+
+	require.Equal(t, artifact.Stats{
+		Hits:         1,
+		Misses:       1,
+		Entries:      2,
+		Size:         8,
+		AddedBytes:   12,
+		Evictions:    1,
+		EvictedBytes: 4,
+	}, c.Stats())
+}
@@ -4,11 +4,25 @@ package artifact
 
 import (
 	"context"
+	"net/http"
 
 	"gitlab.com/slon/shad-go/distbuild/pkg/build"
 )
 
 // Download artifact from remote cache into local cache.
+//
+// Download запрашивает сжатие gzip через Accept-Encoding и распаковывает ответ согласно Content-Encoding.
+// Если соединение оборвалось посреди передачи, Download перезапрашивает артефакт с параметром
+// after, равным последнему полностью полученному файлу (tarstream.ReceiveFrom), и докачивает остаток.
+// Ошибка tarstream.ErrChecksum не ретраится.
 func Download(ctx context.Context, endpoint string, c *Cache, artifactID build.ID) error {
 	panic("implement me")
 }
+
+// DownloadWithClient работает как Download, но ходит через client.
+//
+// Download эквивалентен DownloadWithClient с http.DefaultClient. Воркеры кластера, работающего по mTLS,
+// скачивают артефакты через клиент со своим сертификатом.
+func DownloadWithClient(ctx context.Context, client *http.Client, endpoint string, c *Cache, artifactID build.ID) error {
+	panic("implement me")
+}
@@ -1,6 +1,7 @@
 package artifact_test
 
 import (
+	"bytes"
 	"context"
 	"net/http"
 	"net/http/httptest"
@@ -13,6 +14,7 @@ import (
 
 	"gitlab.com/slon/shad-go/distbuild/pkg/artifact"
 	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/tarstream"
 )
 
 func TestArtifactTransfer(t *testing.T) {
@@ -49,3 +51,100 @@ func TestArtifactTransfer(t *testing.T) {
 	err = artifact.Download(ctx, server.URL, localCache.Cache, build.ID{0x02})
 	require.Error(t, err)
 }
+
+func TestArtifactCompression(t *testing.T) {
+	remoteCache := newTestCache(t)
+
+	id := build.ID{0x01}
+
+	dir, commit, _, err := remoteCache.Create(id)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), bytes.Repeat([]byte("foobar"), 1024), 0777))
+	require.NoError(t, commit())
+
+	h := artifact.NewHandler(zaptest.NewLogger(t), remoteCache.Cache)
+	mux := http.NewServeMux()
+	h.Register(mux)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for _, encoding := range []string{tarstream.EncodingGzip, tarstream.EncodingIdentity} {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/artifact?id="+id.String(), nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Encoding", encoding)
+
+		rsp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer rsp.Body.Close()
+
+		require.Equal(t, http.StatusOK, rsp.StatusCode)
+		if encoding == tarstream.EncodingGzip {
+			require.Equal(t, tarstream.EncodingGzip, rsp.Header.Get("Content-Encoding"))
+		} else {
+			require.Empty(t, rsp.Header.Get("Content-Encoding"))
+		}
+
+		body, err := tarstream.NewDecoder(rsp.Body, rsp.Header.Get("Content-Encoding"))
+		require.NoError(t, err)
+		require.NoError(t, tarstream.Receive(t.TempDir(), body))
+	}
+}
+
+// brokenWriter обрывает ответ после limit байт.
+type brokenWriter struct {
+	http.ResponseWriter
+	limit int
+}
+
+func (w *brokenWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		p = p[:w.limit]
+	}
+	w.limit -= len(p)
+
+	n, err := w.ResponseWriter.Write(p)
+	if err == nil && w.limit == 0 {
+		panic(http.ErrAbortHandler)
+	}
+	return n, err
+}
+
+func TestArtifactResume(t *testing.T) {
+	remoteCache := newTestCache(t)
+	localCache := newTestCache(t)
+
+	id := build.ID{0x01}
+
+	dir, commit, _, err := remoteCache.Create(id)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), bytes.Repeat([]byte("a"), 1<<16), 0777))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), bytes.Repeat([]byte("b"), 1<<16), 0777))
+	require.NoError(t, commit())
+
+	h := artifact.NewHandler(zaptest.NewLogger(t), remoteCache.Cache)
+	mux := http.NewServeMux()
+	h.Register(mux)
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Query().Get("after"))
+		if len(requests) == 1 {
+			r.Header.Del("Accept-Encoding")
+			w = &brokenWriter{ResponseWriter: w, limit: 1<<16 + 1<<15}
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	require.NoError(t, artifact.Download(context.Background(), server.URL, localCache.Cache, id))
+	require.Equal(t, []string{"", "a.txt"}, requests)
+
+	dir, unlock, err := localCache.Get(id)
+	require.NoError(t, err)
+	defer unlock()
+
+	content, err := os.ReadFile(filepath.Join(dir, "b.txt"))
+	require.NoError(t, err)
+	require.Equal(t, bytes.Repeat([]byte("b"), 1<<16), content)
+}
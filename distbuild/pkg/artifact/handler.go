@@ -15,6 +15,10 @@ func NewHandler(l *zap.Logger, c *Cache) *Handler {
 	panic("implement me")
 }
 
+// Register регистрирует обработчик GET /artifact.
+//
+// Хендлер выбирает сжатие ответа через tarstream.NegotiateEncoding и отправляет артефакт
+// через tarstream.SendFrom, начиная с записи из параметра after.
 func (h *Handler) Register(mux *http.ServeMux) {
 	panic("implement me")
 }
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"net/http"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+)
+
+// Peers хранит список воркеров, за которых поручился координатор.
+//
+// Воркер обновляет список из HeartbeatResponse.Peers и отдаёт артефакты только воркерам из списка
+// и самому координатору (HeartbeatResponse.Coordinator).
+// Все методы Peers concurrency safe.
+type Peers struct {
+	mu          sync.RWMutex
+	peers       map[string]struct{}
+	coordinator string
+}
+
+func NewPeers() *Peers {
+	return &Peers{peers: map[string]struct{}{}}
+}
+
+// Set заменяет список воркеров.
+func (p *Peers) Set(peers []api.WorkerID) {
+	m := make(map[string]struct{}, len(peers))
+	for _, id := range peers {
+		m[string(id)] = struct{}{}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.peers = m
+}
+
+// SetCoordinator задаёт идентификатор координатора. Пустой id сбрасывает его.
+func (p *Peers) SetCoordinator(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.coordinator = id
+}
+
+func (p *Peers) Contains(id string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if id != "" && id == p.coordinator {
+		return true
+	}
+
+	_, ok := p.peers[id]
+	return ok
+}
+
+// Middleware пропускает к next только запросы с сертификатом воркера из списка или координатора.
+// Остальные запросы получают 403 Forbidden.
+func (p *Peers) Middleware(l *zap.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := PeerIdentity(r)
+		if !ok || !p.Contains(id) {
+			l.Warn("request from unknown peer",
+				zap.String("path", r.URL.Path),
+				zap.String("peer", id),
+				zap.String("remote", r.RemoteAddr))
+
+			http.Error(w, "unknown peer", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
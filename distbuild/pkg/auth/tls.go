@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+var ErrNoIdentity = errors.New("auth: certificate has no URI identity")
+
+// LoadCertPool читает PEM файл с сертификатами CA кластера.
+func LoadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("auth: no certificates in %s", caFile)
+	}
+	return pool, nil
+}
+
+// ServerConfig возвращает tls.Config для HTTP сервера компонента.
+//
+// Сервер проверяет сертификаты клиентов по CA кластера, если клиент их предъявил. Клиенты сборки
+// ходят без сертификатов, а обязательность сертификата для отдельных путей проверяет RequireClientCert.
+func ServerConfig(cert tls.Certificate, ca *x509.CertPool) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    ca,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// ClientConfig возвращает tls.Config для исходящих запросов компонента.
+//
+// Сервер проверяется по CA кластера. Если cert != nil, клиент предъявляет его серверу.
+func ClientConfig(cert *tls.Certificate, ca *x509.CertPool) *tls.Config {
+	config := &tls.Config{
+		RootCAs:    ca,
+		MinVersion: tls.VersionTLS12,
+	}
+	if cert != nil {
+		config.Certificates = []tls.Certificate{*cert}
+	}
+	return config
+}
+
+// HTTPClient возвращает http.Client, который ходит с config.
+func HTTPClient(config *tls.Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	return &http.Client{Transport: transport}
+}
+
+// Identity возвращает идентификатор компонента из его сертификата.
+//
+// Идентификатор - это первый URI из SAN сертификата. Сертификат воркера содержит его api.WorkerID.
+func Identity(cert *x509.Certificate) (string, error) {
+	if len(cert.URIs) == 0 {
+		return "", ErrNoIdentity
+	}
+	return cert.URIs[0].String(), nil
+}
+
+// PeerIdentity возвращает идентификатор клиента из проверенного сертификата запроса.
+func PeerIdentity(r *http.Request) (string, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return "", false
+	}
+
+	id, err := Identity(r.TLS.VerifiedChains[0][0])
+	return id, err == nil
+}
+
+// RequireClientCert пропускает к next только запросы с сертификатом, подписанным CA кластера.
+// Остальные запросы получают 403 Forbidden.
+func RequireClientCert(l *zap.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := PeerIdentity(r); !ok {
+			l.Warn("request without client certificate",
+				zap.String("path", r.URL.Path),
+				zap.String("remote", r.RemoteAddr))
+
+			http.Error(w, "client certificate required", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,95 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/slon/shad-go/distbuild/disttest"
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/auth"
+)
+
+func newTLSServer(t *testing.T, ca *disttest.TestCA, handler http.Handler) *httptest.Server {
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = auth.ServerConfig(ca.Issue(t, ""), ca.Pool())
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func status(t *testing.T, client *http.Client, url string) int {
+	rsp, err := client.Get(url)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+	return rsp.StatusCode
+}
+
+func TestRequireClientCert(t *testing.T) {
+	ca := disttest.NewTestCA(t)
+	server := newTLSServer(t, ca, auth.RequireClientCert(zaptest.NewLogger(t), okHandler))
+
+	anonymous := auth.HTTPClient(auth.ClientConfig(nil, ca.Pool()))
+	require.Equal(t, http.StatusForbidden, status(t, anonymous, server.URL))
+
+	cert := ca.Issue(t, "https://127.0.0.1/worker/0")
+	worker := auth.HTTPClient(auth.ClientConfig(&cert, ca.Pool()))
+	require.Equal(t, http.StatusOK, status(t, worker, server.URL))
+
+	otherCA := disttest.NewTestCA(t)
+	foreignCert := otherCA.Issue(t, "https://127.0.0.1/worker/0")
+	foreign := auth.HTTPClient(auth.ClientConfig(&foreignCert, ca.Pool()))
+	_, err := foreign.Get(server.URL)
+	require.Error(t, err)
+
+	untrusting := auth.HTTPClient(auth.ClientConfig(&cert, otherCA.Pool()))
+	_, err = untrusting.Get(server.URL)
+	require.Error(t, err)
+}
+
+func TestPeers(t *testing.T) {
+	ca := disttest.NewTestCA(t)
+
+	peers := auth.NewPeers()
+	server := newTLSServer(t, ca, peers.Middleware(zaptest.NewLogger(t), okHandler))
+
+	client := func(identity string) *http.Client {
+		cert := ca.Issue(t, identity)
+		return auth.HTTPClient(auth.ClientConfig(&cert, ca.Pool()))
+	}
+
+	worker0 := client("https://127.0.0.1/worker/0")
+	worker1 := client("https://127.0.0.1/worker/1")
+	noIdentity := client("")
+
+	require.Equal(t, http.StatusForbidden, status(t, worker0, server.URL))
+
+	peers.Set([]api.WorkerID{"https://127.0.0.1/worker/0"})
+	require.Equal(t, http.StatusOK, status(t, worker0, server.URL))
+	require.Equal(t, http.StatusForbidden, status(t, worker1, server.URL))
+	require.Equal(t, http.StatusForbidden, status(t, noIdentity, server.URL))
+
+	peers.Set(nil)
+	require.Equal(t, http.StatusForbidden, status(t, worker0, server.URL))
+
+	coordinator := client("https://127.0.0.1/coordinator")
+	require.Equal(t, http.StatusForbidden, status(t, coordinator, server.URL))
+
+	peers.SetCoordinator("https://127.0.0.1/coordinator")
+	require.Equal(t, http.StatusOK, status(t, coordinator, server.URL))
+	require.Equal(t, http.StatusForbidden, status(t, noIdentity, server.URL))
+}
+
+func TestIdentity(t *testing.T) {
+	ca := disttest.NewTestCA(t)
+
+	id, err := auth.Identity(ca.Issue(t, "https://127.0.0.1/worker/0").Leaf)
+	require.NoError(t, err)
+	require.Equal(t, "https://127.0.0.1/worker/0", id)
+
+	_, err = auth.Identity(ca.Issue(t, "").Leaf)
+	require.ErrorIs(t, err, auth.ErrNoIdentity)
+}
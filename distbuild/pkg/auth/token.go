@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const bearerPrefix = "Bearer "
+
+// TokenMiddleware пропускает к next только запросы с заголовком Authorization: Bearer <token>,
+// где token входит в tokens. Остальные запросы получают 401 Unauthorized.
+func TokenMiddleware(l *zap.Logger, tokens []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ValidBearer(tokens, r.Header.Get("Authorization")) {
+			l.Warn("request without valid token",
+				zap.String("path", r.URL.Path),
+				zap.String("remote", r.RemoteAddr))
+
+			w.Header().Set("WWW-Authenticate", `Bearer realm="distbuild"`)
+			http.Error(w, "invalid or missing bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TokenCookie - cookie, в которой браузер хранит токен после входа через BrowserTokenMiddleware.
+const TokenCookie = "distbuild_token"
+
+// BrowserTokenMiddleware защищает страницы, которые открывают в браузере, например дашборд координатора.
+//
+// Браузер не умеет посылать заголовок Authorization, поэтому кроме него middleware принимает токен
+// из cookie TokenCookie. Чтобы получить cookie, достаточно один раз открыть страницу с параметром
+// ?token=<token>: middleware сохраняет токен в cookie и перенаправляет на тот же адрес без токена,
+// чтобы он не остался в истории браузера.
+func BrowserTokenMiddleware(l *zap.Logger, tokens []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("token"); token != "" && validToken(tokens, token) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     TokenCookie,
+				Value:    token,
+				Path:     "/",
+				Secure:   r.TLS != nil,
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+
+			u := *r.URL
+			q := u.Query()
+			q.Del("token")
+			u.RawQuery = q.Encode()
+			http.Redirect(w, r, u.RequestURI(), http.StatusSeeOther)
+			return
+		}
+
+		if ValidBearer(tokens, r.Header.Get("Authorization")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if cookie, err := r.Cookie(TokenCookie); err == nil && validToken(tokens, cookie.Value) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		l.Warn("request without valid token",
+			zap.String("path", r.URL.Path),
+			zap.String("remote", r.RemoteAddr))
+
+		w.Header().Set("WWW-Authenticate", `Bearer realm="distbuild"`)
+		http.Error(w, "invalid or missing token, open this page with ?token=<token>", http.StatusUnauthorized)
+	})
+}
+
+// Bearer возвращает значение заголовка Authorization для token.
+func Bearer(token string) string {
+	return bearerPrefix + token
+}
+
+// ValidBearer проверяет, что значение заголовка Authorization имеет вид Bearer <token> и token входит в tokens.
+func ValidBearer(tokens []string, header string) bool {
+	token, ok := strings.CutPrefix(header, bearerPrefix)
+	return ok && validToken(tokens, token)
+}
+
+func validToken(tokens []string, token string) bool {
+	valid := false
+	for _, t := range tokens {
+		// Сравниваем со всеми токенами за постоянное время, чтобы не выдавать совпавший префикс.
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			valid = true
+		}
+	}
+	return valid && token != ""
+}
+
+// TokenTransport добавляет заголовок Authorization: Bearer <Token> ко всем запросам.
+type TokenTransport struct {
+	Token string
+
+	// Base - транспорт, через который отправляются запросы. Если Base == nil, используется http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+func (t *TokenTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", Bearer(t.Token))
+	return base.RoundTrip(r)
+}
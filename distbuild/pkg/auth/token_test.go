@@ -0,0 +1,64 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/auth"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestTokenMiddleware(t *testing.T) {
+	server := httptest.NewServer(auth.TokenMiddleware(zaptest.NewLogger(t), []string{"secret", "other"}, okHandler))
+	defer server.Close()
+
+	get := func(client *http.Client) int {
+		rsp, err := client.Get(server.URL + "/build")
+		require.NoError(t, err)
+		defer rsp.Body.Close()
+		return rsp.StatusCode
+	}
+
+	require.Equal(t, http.StatusUnauthorized, get(http.DefaultClient))
+	require.Equal(t, http.StatusUnauthorized, get(&http.Client{Transport: &auth.TokenTransport{Token: "wrong"}}))
+	require.Equal(t, http.StatusUnauthorized, get(&http.Client{Transport: &auth.TokenTransport{}}))
+	require.Equal(t, http.StatusOK, get(&http.Client{Transport: &auth.TokenTransport{Token: "secret"}}))
+	require.Equal(t, http.StatusOK, get(&http.Client{Transport: &auth.TokenTransport{Token: "other"}}))
+}
+
+func TestBrowserTokenMiddleware(t *testing.T) {
+	server := httptest.NewServer(auth.BrowserTokenMiddleware(zaptest.NewLogger(t), []string{"secret"}, okHandler))
+	defer server.Close()
+
+	get := func(client *http.Client, path string) *http.Response {
+		rsp, err := client.Get(server.URL + path)
+		require.NoError(t, err)
+		defer rsp.Body.Close()
+		return rsp
+	}
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	browser := &http.Client{Jar: jar}
+
+	require.Equal(t, http.StatusUnauthorized, get(browser, "/dashboard").StatusCode)
+	require.Equal(t, http.StatusUnauthorized, get(browser, "/dashboard?token=wrong").StatusCode)
+
+	// После входа по ссылке с токеном браузер попадает на страницу без токена в адресе.
+	rsp := get(browser, "/dashboard?token=secret&x=1")
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+	require.Equal(t, "/dashboard?x=1", rsp.Request.URL.RequestURI())
+
+	require.Equal(t, http.StatusOK, get(browser, "/status/builds").StatusCode)
+
+	require.Equal(t, http.StatusOK, get(&http.Client{Transport: &auth.TokenTransport{Token: "secret"}}, "/dashboard").StatusCode)
+	require.Equal(t, http.StatusUnauthorized, get(http.DefaultClient, "/dashboard").StatusCode)
+}
@@ -1,7 +1,9 @@
 package build
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"text/template"
 )
@@ -12,7 +14,148 @@ type JobContext struct {
 	Deps      map[ID]string
 }
 
+// CmdKind - вид команды сборки.
+type CmdKind string
+
+const (
+	CmdExec    CmdKind = "exec"
+	CmdCat     CmdKind = "cat"
+	CmdCopy    CmdKind = "copy"
+	CmdSymlink CmdKind = "symlink"
+	CmdMkdir   CmdKind = "mkdir"
+	CmdEnvFile CmdKind = "envfile"
+)
+
+var (
+	ErrNoCmdKind        = errors.New("command kind is not set")
+	ErrAmbiguousCmdKind = errors.New("command sets fields of several kinds")
+)
+
+// Kind определяет вид команды по заполненным полям.
+//
+// Kind возвращает ErrNoCmdKind, если не заполнено ни одно поле, и ErrAmbiguousCmdKind, если
+// заполнены поля нескольких видов.
+func (c *Cmd) Kind() (CmdKind, error) {
+	var kinds []CmdKind
+	if len(c.Exec) != 0 {
+		kinds = append(kinds, CmdExec)
+	}
+	if c.CatOutput != "" || c.CatTemplate != "" {
+		kinds = append(kinds, CmdCat)
+	}
+	if c.CopySource != "" || c.CopyOutput != "" {
+		kinds = append(kinds, CmdCopy)
+	}
+	if c.SymlinkTarget != "" || c.SymlinkOutput != "" {
+		kinds = append(kinds, CmdSymlink)
+	}
+	if c.MkdirOutput != "" {
+		kinds = append(kinds, CmdMkdir)
+	}
+	if c.EnvFileOutput != "" {
+		kinds = append(kinds, CmdEnvFile)
+	}
+
+	switch len(kinds) {
+	case 0:
+		return "", ErrNoCmdKind
+	case 1:
+		return kinds[0], nil
+	default:
+		return "", fmt.Errorf("%w: %v", ErrAmbiguousCmdKind, kinds)
+	}
+}
+
+// PathError описывает путь команды, который выходит за пределы разрешённых директорий.
+type PathError struct {
+	Field  string
+	Path   string
+	Reason string
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("%s %q %s", e.Field, e.Path, e.Reason)
+}
+
+// within проверяет, что path лексически лежит внутри dir или совпадает с ней.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && (rel == "." || filepath.IsLocal(rel))
+}
+
+// checkOutput проверяет, что path - абсолютный путь внутри OutputDir.
+func checkOutput(ctx JobContext, field, path string) error {
+	if !filepath.IsAbs(path) {
+		return &PathError{Field: field, Path: path, Reason: "must be absolute"}
+	}
+
+	if !within(ctx.OutputDir, path) {
+		return &PathError{Field: field, Path: path, Reason: "escapes output directory"}
+	}
+	return nil
+}
+
+// checkPaths проверяет пути отрендеренной команды, которую воркер выполняет сам.
+//
+// Проверяются только пути команд copy, symlink, mkdir и envfile. Пути exec изолирует песочница воркера,
+// а CatOutput, как и раньше, может указывать куда угодно.
+func (c *Cmd) checkPaths(ctx JobContext, kind CmdKind) error {
+	switch kind {
+	case CmdCopy:
+		if !filepath.IsAbs(c.CopySource) {
+			return &PathError{Field: "CopySource", Path: c.CopySource, Reason: "must be absolute"}
+		}
+
+		allowed := within(ctx.SourceDir, c.CopySource) || within(ctx.OutputDir, c.CopySource)
+		for _, dep := range ctx.Deps {
+			allowed = allowed || within(dep, c.CopySource)
+		}
+		if !allowed {
+			return &PathError{Field: "CopySource", Path: c.CopySource, Reason: "is outside of source, dependency and output directories"}
+		}
+
+		if within(c.CopySource, c.CopyOutput) {
+			return &PathError{Field: "CopyOutput", Path: c.CopyOutput, Reason: "is inside of CopySource"}
+		}
+
+		return checkOutput(ctx, "CopyOutput", c.CopyOutput)
+
+	case CmdSymlink:
+		if c.SymlinkTarget == "" || filepath.IsAbs(c.SymlinkTarget) {
+			return &PathError{Field: "SymlinkTarget", Path: c.SymlinkTarget, Reason: "must be relative"}
+		}
+
+		if err := checkOutput(ctx, "SymlinkOutput", c.SymlinkOutput); err != nil {
+			return err
+		}
+
+		target := filepath.Join(filepath.Dir(c.SymlinkOutput), c.SymlinkTarget)
+		if !within(ctx.OutputDir, target) {
+			return &PathError{Field: "SymlinkTarget", Path: c.SymlinkTarget, Reason: "escapes output directory"}
+		}
+		return nil
+
+	case CmdMkdir:
+		return checkOutput(ctx, "MkdirOutput", c.MkdirOutput)
+
+	case CmdEnvFile:
+		for _, kv := range c.Environ {
+			key, _, ok := strings.Cut(kv, "=")
+			if !ok || key == "" || strings.ContainsAny(kv, "\n\x00") {
+				return fmt.Errorf("invalid environment variable %q", kv)
+			}
+		}
+
+		return checkOutput(ctx, "EnvFileOutput", c.EnvFileOutput)
+	}
+
+	return nil
+}
+
 // Render replaces variable references with their real value.
+//
+// Paths of in-process commands (copy, symlink, mkdir and envfile) are checked after rendering:
+// they must not escape OutputDir, see *PathError.
 func (c *Cmd) Render(ctx JobContext) (*Cmd, error) {
 	var errs []error
 
@@ -60,10 +203,22 @@ func (c *Cmd) Render(ctx JobContext) (*Cmd, error) {
 	rendered.WorkingDirectory = render(c.WorkingDirectory)
 	rendered.Exec = renderList(c.Exec)
 	rendered.Environ = renderList(c.Environ)
+	rendered.CopySource = render(c.CopySource)
+	rendered.CopyOutput = render(c.CopyOutput)
+	rendered.SymlinkTarget = render(c.SymlinkTarget)
+	rendered.SymlinkOutput = render(c.SymlinkOutput)
+	rendered.MkdirOutput = render(c.MkdirOutput)
+	rendered.EnvFileOutput = render(c.EnvFileOutput)
 
 	if len(errs) != 0 {
 		return nil, fmt.Errorf("error rendering cmd: %w", errs[0])
 	}
 
+	if kind, err := rendered.Kind(); err == nil {
+		if err := rendered.checkPaths(ctx, kind); err != nil {
+			return nil, fmt.Errorf("error rendering cmd: %w", err)
+		}
+	}
+
 	return &rendered, nil
 }
@@ -29,3 +29,87 @@ func TestCmdRender(t *testing.T) {
 
 	require.Equal(t, expected, result)
 }
+
+func TestCmdKind(t *testing.T) {
+	for _, tc := range []struct {
+		cmd  Cmd
+		kind CmdKind
+		err  error
+	}{
+		{cmd: Cmd{Exec: []string{"true"}, Environ: []string{"A=B"}}, kind: CmdExec},
+		{cmd: Cmd{CatTemplate: "", CatOutput: "/out"}, kind: CmdCat},
+		{cmd: Cmd{CopySource: "/a", CopyOutput: "/b"}, kind: CmdCopy},
+		{cmd: Cmd{SymlinkTarget: "a", SymlinkOutput: "/b"}, kind: CmdSymlink},
+		{cmd: Cmd{MkdirOutput: "/a"}, kind: CmdMkdir},
+		{cmd: Cmd{EnvFileOutput: "/env", Environ: []string{"A=B"}}, kind: CmdEnvFile},
+		{cmd: Cmd{}, err: ErrNoCmdKind},
+		{cmd: Cmd{Environ: []string{"A=B"}}, err: ErrNoCmdKind},
+		{cmd: Cmd{Exec: []string{"true"}, MkdirOutput: "/a"}, err: ErrAmbiguousCmdKind},
+	} {
+		kind, err := tc.cmd.Kind()
+		if tc.err != nil {
+			require.ErrorIs(t, err, tc.err)
+			continue
+		}
+
+		require.NoError(t, err)
+		require.Equal(t, tc.kind, kind)
+	}
+}
+
+func TestCmdRenderInProcess(t *testing.T) {
+	ctx := JobContext{
+		SourceDir: "/distbuild/src",
+		OutputDir: "/distbuild/jobs/b",
+		Deps: map[ID]string{
+			{'a'}: "/distbuild/jobs/a",
+		},
+	}
+
+	tmpl := Cmd{
+		CopySource: `{{index .Deps "6100000000000000000000000000000000000000"}}/lib`,
+		CopyOutput: "{{.OutputDir}}/lib",
+	}
+
+	result, err := tmpl.Render(ctx)
+	require.NoError(t, err)
+	require.Equal(t, &Cmd{CopySource: "/distbuild/jobs/a/lib", CopyOutput: "/distbuild/jobs/b/lib"}, result)
+
+	tmpl = Cmd{
+		EnvFileOutput: "{{.OutputDir}}/env",
+		Environ:       []string{"SRC={{.SourceDir}}"},
+	}
+
+	result, err = tmpl.Render(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"SRC=/distbuild/src"}, result.Environ)
+
+	for _, tc := range []struct {
+		name  string
+		cmd   Cmd
+		field string
+	}{
+		{name: "copy from outside", cmd: Cmd{CopySource: "/etc/passwd", CopyOutput: "{{.OutputDir}}/passwd"}, field: "CopySource"},
+		{name: "copy from sibling job", cmd: Cmd{CopySource: "{{.OutputDir}}/../c", CopyOutput: "{{.OutputDir}}/c"}, field: "CopySource"},
+		{name: "copy to source", cmd: Cmd{CopySource: "{{.SourceDir}}/a", CopyOutput: "{{.SourceDir}}/b"}, field: "CopyOutput"},
+		{name: "copy into itself", cmd: Cmd{CopySource: "{{.OutputDir}}/a", CopyOutput: "{{.OutputDir}}/a/b"}, field: "CopyOutput"},
+		{name: "relative output", cmd: Cmd{MkdirOutput: "bin"}, field: "MkdirOutput"},
+		{name: "mkdir outside", cmd: Cmd{MkdirOutput: "{{.OutputDir}}/../../tmp"}, field: "MkdirOutput"},
+		{name: "absolute symlink", cmd: Cmd{SymlinkTarget: "/bin/sh", SymlinkOutput: "{{.OutputDir}}/sh"}, field: "SymlinkTarget"},
+		{name: "symlink outside", cmd: Cmd{SymlinkTarget: "../../a/lib", SymlinkOutput: "{{.OutputDir}}/bin/lib"}, field: "SymlinkTarget"},
+		{name: "env file outside", cmd: Cmd{EnvFileOutput: "{{.SourceDir}}/env"}, field: "EnvFileOutput"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.cmd.Render(ctx)
+			require.Error(t, err)
+
+			var pathErr *PathError
+			require.ErrorAs(t, err, &pathErr)
+			require.Equal(t, tc.field, pathErr.Field)
+		})
+	}
+
+	tmpl = Cmd{EnvFileOutput: "{{.OutputDir}}/env", Environ: []string{"NOVALUE"}}
+	_, err = tmpl.Render(ctx)
+	require.Error(t, err)
+}
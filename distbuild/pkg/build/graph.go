@@ -29,6 +29,25 @@ type Job struct {
 
 	// Cmds описывает список команд, которые нужно выполнить в рамках этого джоба.
 	Cmds []Cmd
+
+	// Requirements описывает ресурсы и метки воркера, необходимые для запуска джоба.
+	Requirements Requirements
+
+	// NoCache запрещает брать результат джоба из кеша. Такой джоб выполняется при каждой сборке.
+	//
+	// Нужен, например, для тестов, которые должны запускаться всегда.
+	NoCache bool
+
+	// Retry описывает, когда джоб можно перезапустить после падения. По умолчанию джоб не перезапускается.
+	Retry RetryPolicy
+
+	// Output помечает джоб как результат сборки. После успешной сборки клиент скачивает
+	// выходную директорию джоба и кладёт её в Output внутри своей выходной директории.
+	//
+	// Output - относительный путь, например "bin/server". Он не может совпадать с самой выходной
+	// директорией клиента, то есть быть ".". Пустой Output означает, что результат джоба нужен
+	// только другим джобам.
+	Output string
 }
 
 // Cmd описывает одну команду сборки.
@@ -36,8 +55,15 @@ type Job struct {
 // Есть несколько видов команд. Все виды команд описываются одной структурой.
 // Реальный тип определяется тем, какие поля структуры заполнены.
 //
-//	exec - выполняет произвольную команду
-//	cat  - записывает строку в файл
+//	exec    - выполняет произвольную команду
+//	cat     - записывает строку в файл
+//	copy    - копирует файл или директорию в выходную директорию
+//	symlink - создаёт симлинк внутри выходной директории
+//	mkdir   - создаёт директорию внутри выходной директории
+//	envfile - записывает переменные окружения в файл
+//
+// Все виды, кроме exec, выполняются внутри воркера без запуска процессов (Cmd.RunInProcess).
+// Вид команды возвращает Cmd.Kind.
 //
 // Все строки в описании команды могут содержать в себе ссылки на контекстные переменные. Перед выполнением
 // реальной команды, переменные заменяются на их реальные значения.
@@ -51,6 +77,8 @@ type Cmd struct {
 	Exec []string
 
 	// Environ описывает переменные окружения, которые необходимы для работы команды из Exec.
+	//
+	// Для команды типа envfile Environ задаёт переменные в формате KEY=VALUE, которые нужно записать в файл.
 	Environ []string
 
 	// WorkingDirectory задаёт рабочую директорию для команды из Exec.
@@ -61,6 +89,27 @@ type Cmd struct {
 
 	// CatOutput задаёт выходной файл для команды типа cat.
 	CatOutput string
+
+	// CopySource задаёт файл или директорию, которые копирует команда типа copy. Путь должен лежать
+	// внутри {{.SourceDir}}, внутри выходной директории одной из зависимостей или внутри {{.OutputDir}}.
+	CopySource string
+
+	// CopyOutput задаёт путь внутри {{.OutputDir}}, по которому копируется CopySource. Путь не должен существовать.
+	CopyOutput string
+
+	// SymlinkTarget задаёт, куда указывает симлинк. Путь должен быть относительным и указывать внутрь {{.OutputDir}},
+	// иначе симлинк сломается, когда артефакт скачают на другой воркер.
+	SymlinkTarget string
+
+	// SymlinkOutput задаёт путь внутри {{.OutputDir}}, по которому создаётся симлинк.
+	SymlinkOutput string
+
+	// MkdirOutput задаёт директорию внутри {{.OutputDir}}, которую создаёт команда типа mkdir вместе с родителями.
+	MkdirOutput string
+
+	// EnvFileOutput задаёт файл внутри {{.OutputDir}}, в который команда типа envfile записывает
+	// переменные из Environ, по одной на строку.
+	EnvFileOutput string
 }
 
 type Graph struct {
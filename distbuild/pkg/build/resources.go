@@ -0,0 +1,78 @@
+package build
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Resources описывает вычислительные ресурсы.
+type Resources struct {
+	// CPU задаёт число ядер.
+	CPU int
+
+	// Memory задаёт объём памяти в байтах.
+	Memory int64
+}
+
+// Fits проверяет, что r помещается в available.
+func (r Resources) Fits(available Resources) bool {
+	return r.CPU <= available.CPU && r.Memory <= available.Memory
+}
+
+func (r Resources) Add(other Resources) Resources {
+	return Resources{CPU: r.CPU + other.CPU, Memory: r.Memory + other.Memory}
+}
+
+func (r Resources) Sub(other Resources) Resources {
+	return Resources{CPU: r.CPU - other.CPU, Memory: r.Memory - other.Memory}
+}
+
+// Requirements описывает требования джоба к воркеру, на котором он будет запущен.
+//
+// Нулевое значение означает, что джобу подходит любой воркер.
+type Requirements struct {
+	Resources
+
+	// Labels перечисляет метки, которые должны быть у воркера.
+	//
+	// Метка имеет вид key=value. Например:
+	//   os=linux
+	//   has=postgres
+	Labels []string
+}
+
+// SatisfiedBy проверяет, что воркер со свободными ресурсами available и метками labels подходит джобу.
+func (r *Requirements) SatisfiedBy(available Resources, labels []string) bool {
+	if !r.Fits(available) {
+		return false
+	}
+
+	for _, required := range r.Labels {
+		found := false
+		for _, l := range labels {
+			if l == required {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r Requirements) String() string {
+	var parts []string
+	if r.CPU != 0 {
+		parts = append(parts, fmt.Sprintf("cpu=%d", r.CPU))
+	}
+	if r.Memory != 0 {
+		parts = append(parts, fmt.Sprintf("memory=%d", r.Memory))
+	}
+	parts = append(parts, r.Labels...)
+
+	return "{" + strings.Join(parts, ", ") + "}"
+}
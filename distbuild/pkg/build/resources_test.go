@@ -0,0 +1,26 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequirements(t *testing.T) {
+	worker := Resources{CPU: 4, Memory: 8 << 30}
+	labels := []string{"os=linux", "has=postgres"}
+
+	var none Requirements
+	require.True(t, none.SatisfiedBy(Resources{}, nil))
+
+	link := Requirements{Resources: Resources{CPU: 1, Memory: 8 << 30}}
+	require.True(t, link.SatisfiedBy(worker, labels))
+	require.False(t, link.SatisfiedBy(worker.Sub(Resources{Memory: 1}), labels))
+
+	test := Requirements{Labels: []string{"os=linux", "has=postgres"}}
+	require.True(t, test.SatisfiedBy(worker, labels))
+	require.False(t, test.SatisfiedBy(worker, labels[:1]))
+
+	require.Equal(t, "{cpu=1, memory=8589934592}", link.String())
+	require.Equal(t, "{os=linux, has=postgres}", test.String())
+}
@@ -0,0 +1,50 @@
+package build
+
+import (
+	"slices"
+	"strings"
+)
+
+// RetryPolicy описывает, когда упавший джоб нужно перезапустить.
+//
+// Нулевое значение означает, что джоб не перезапускается.
+type RetryPolicy struct {
+	// MaxAttempts задаёт максимальное число запусков джоба, включая первый.
+	MaxAttempts int
+
+	// ExitCodes перечисляет коды выхода, при которых джоб можно перезапустить.
+	ExitCodes []int
+
+	// Errors перечисляет подстроки JobResult.Error, при которых джоб можно перезапустить.
+	// Например, "memory limit exceeded" перезапускает джобы, упёршиеся в ограничение песочницы.
+	Errors []string
+}
+
+// Retryable решает, нужно ли перезапустить джоб, который упал на попытке attempt (начиная с 1)
+// с кодом exitCode и ошибкой errorMessage.
+//
+// Если ExitCodes и Errors пустые, перезапускается любое падение. Иначе падение должно подходить
+// под один из кодов выхода или под одну из подстрок ошибки.
+func (p RetryPolicy) Retryable(attempt int, exitCode int, errorMessage *string) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+
+	if len(p.ExitCodes) == 0 && len(p.Errors) == 0 {
+		return true
+	}
+
+	if errorMessage == nil && exitCode != 0 && slices.Contains(p.ExitCodes, exitCode) {
+		return true
+	}
+
+	if errorMessage != nil {
+		for _, e := range p.Errors {
+			if strings.Contains(*errorMessage, e) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
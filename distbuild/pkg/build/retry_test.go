@@ -0,0 +1,27 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryable(t *testing.T) {
+	oom := "sandbox: memory limit exceeded"
+	other := "exec: \"gcc\": executable file not found in $PATH"
+
+	var none RetryPolicy
+	require.False(t, none.Retryable(1, 1, nil))
+
+	always := RetryPolicy{MaxAttempts: 3}
+	require.True(t, always.Retryable(1, 1, nil))
+	require.True(t, always.Retryable(2, 0, &other))
+	require.False(t, always.Retryable(3, 1, nil))
+
+	specific := RetryPolicy{MaxAttempts: 2, ExitCodes: []int{2}, Errors: []string{"memory limit exceeded"}}
+	require.True(t, specific.Retryable(1, 2, nil))
+	require.False(t, specific.Retryable(1, 1, nil))
+	require.True(t, specific.Retryable(1, 0, &oom))
+	require.False(t, specific.Retryable(1, 0, &other))
+	require.False(t, specific.Retryable(2, 2, nil))
+}
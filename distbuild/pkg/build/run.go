@@ -0,0 +1,161 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrNotInProcess = errors.New("exec command must be run as a process")
+
+// RunInProcess выполняет отрендеренную команду любого вида, кроме exec, внутри текущего процесса.
+// Для exec RunInProcess возвращает ErrNotInProcess.
+//
+// ctx должен совпадать с контекстом, с которым команда рендерилась. Кроме лексической проверки
+// путей в Render, RunInProcess следит, чтобы запись не вышла за пределы OutputDir через симлинки,
+// созданные предыдущими командами джоба. Выходные пути команд copy, symlink и envfile не должны существовать.
+func (c *Cmd) RunInProcess(ctx JobContext) error {
+	kind, err := c.Kind()
+	if err != nil {
+		return err
+	}
+
+	if err := c.checkPaths(ctx, kind); err != nil {
+		return err
+	}
+
+	switch kind {
+	case CmdCat:
+		return os.WriteFile(c.CatOutput, []byte(c.CatTemplate), 0666)
+
+	case CmdCopy:
+		if err := prepareDir(ctx, filepath.Dir(c.CopyOutput)); err != nil {
+			return err
+		}
+		return copyPath(ctx, c.CopySource, c.CopyOutput)
+
+	case CmdSymlink:
+		if err := prepareDir(ctx, filepath.Dir(c.SymlinkOutput)); err != nil {
+			return err
+		}
+		return os.Symlink(c.SymlinkTarget, c.SymlinkOutput)
+
+	case CmdMkdir:
+		return prepareDir(ctx, c.MkdirOutput)
+
+	case CmdEnvFile:
+		if err := prepareDir(ctx, filepath.Dir(c.EnvFileOutput)); err != nil {
+			return err
+		}
+
+		var content strings.Builder
+		for _, kv := range c.Environ {
+			content.WriteString(kv)
+			content.WriteByte('\n')
+		}
+		return writeNew(c.EnvFileOutput, strings.NewReader(content.String()), 0666)
+
+	default:
+		return ErrNotInProcess
+	}
+}
+
+// prepareDir создаёт директорию dir, предварительно проверив, что её ближайший существующий
+// предок после раскрытия симлинков лежит внутри OutputDir.
+func prepareDir(ctx JobContext, dir string) error {
+	root, err := filepath.EvalSymlinks(ctx.OutputDir)
+	if err != nil {
+		return err
+	}
+
+	existing := dir
+	for {
+		_, err := os.Lstat(existing)
+		if err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		existing = filepath.Dir(existing)
+	}
+
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return err
+	}
+
+	if !within(root, resolved) {
+		return &PathError{Field: "output", Path: dir, Reason: "escapes output directory through a symlink"}
+	}
+
+	return os.MkdirAll(dir, 0777)
+}
+
+// copyPath рекурсивно копирует src в dst. Симлинки копируются как симлинки и, как и SymlinkTarget,
+// должны быть относительными и указывать внутрь OutputDir.
+func copyPath(ctx JobContext, src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+
+		if filepath.IsAbs(target) || !within(ctx.OutputDir, filepath.Join(filepath.Dir(dst), target)) {
+			return &PathError{Field: "CopySource", Path: src, Reason: fmt.Sprintf("contains symlink to %q outside of output directory", target)}
+		}
+		return os.Symlink(target, dst)
+
+	case info.IsDir():
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+
+		if err := os.Mkdir(dst, 0777); err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			if err := copyPath(ctx, filepath.Join(src, e.Name()), filepath.Join(dst, e.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case info.Mode().IsRegular():
+		f, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		return writeNew(dst, f, info.Mode().Perm())
+
+	default:
+		return fmt.Errorf("cannot copy %q: unsupported file type %s", src, info.Mode().Type())
+	}
+}
+
+// writeNew создаёт файл path, которого ещё не существует, и записывает в него содержимое r.
+func writeNew(path string, r io.Reader, perm fs.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
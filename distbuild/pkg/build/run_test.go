@@ -0,0 +1,144 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newJobContext создаёт директории джоба b, зависящего от джоба a.
+func newJobContext(t *testing.T) JobContext {
+	root := t.TempDir()
+
+	ctx := JobContext{
+		SourceDir: filepath.Join(root, "src"),
+		OutputDir: filepath.Join(root, "jobs", "b"),
+		Deps: map[ID]string{
+			{'a'}: filepath.Join(root, "jobs", "a"),
+		},
+	}
+
+	for _, dir := range []string{ctx.SourceDir, ctx.OutputDir, ctx.Deps[ID{'a'}]} {
+		require.NoError(t, os.MkdirAll(dir, 0777))
+	}
+	return ctx
+}
+
+func run(t *testing.T, ctx JobContext, tmpl Cmd) error {
+	t.Helper()
+
+	cmd, err := tmpl.Render(ctx)
+	require.NoError(t, err)
+	return cmd.RunInProcess(ctx)
+}
+
+func TestRunCopy(t *testing.T) {
+	ctx := newJobContext(t)
+
+	lib := filepath.Join(ctx.Deps[ID{'a'}], "lib")
+	require.NoError(t, os.MkdirAll(filepath.Join(lib, "pkg"), 0777))
+	require.NoError(t, os.WriteFile(filepath.Join(lib, "pkg", "a.a"), []byte("archive"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(lib, "tool"), []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, os.Symlink("pkg/a.a", filepath.Join(lib, "current")))
+	require.NoError(t, os.WriteFile(filepath.Join(ctx.SourceDir, "main.go"), []byte("package main"), 0644))
+
+	require.NoError(t, run(t, ctx, Cmd{
+		CopySource: `{{index .Deps "6100000000000000000000000000000000000000"}}/lib`,
+		CopyOutput: "{{.OutputDir}}/vendor/lib",
+	}))
+	require.NoError(t, run(t, ctx, Cmd{
+		CopySource: "{{.SourceDir}}/main.go",
+		CopyOutput: "{{.OutputDir}}/main.go",
+	}))
+
+	out := filepath.Join(ctx.OutputDir, "vendor", "lib")
+
+	content, err := os.ReadFile(filepath.Join(out, "pkg", "a.a"))
+	require.NoError(t, err)
+	require.Equal(t, "archive", string(content))
+
+	info, err := os.Stat(filepath.Join(out, "tool"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0100), info.Mode().Perm()&0100)
+
+	target, err := os.Readlink(filepath.Join(out, "current"))
+	require.NoError(t, err)
+	require.Equal(t, "pkg/a.a", target)
+
+	content, err = os.ReadFile(filepath.Join(ctx.OutputDir, "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package main", string(content))
+
+	// Выходной путь не должен существовать.
+	require.Error(t, run(t, ctx, Cmd{
+		CopySource: "{{.SourceDir}}/main.go",
+		CopyOutput: "{{.OutputDir}}/main.go",
+	}))
+}
+
+func TestRunCopyRejectsEscapingSymlink(t *testing.T) {
+	ctx := newJobContext(t)
+
+	require.NoError(t, os.Mkdir(filepath.Join(ctx.SourceDir, "dir"), 0777))
+	require.NoError(t, os.Symlink("/etc/passwd", filepath.Join(ctx.SourceDir, "dir", "passwd")))
+
+	err := run(t, ctx, Cmd{
+		CopySource: "{{.SourceDir}}/dir",
+		CopyOutput: "{{.OutputDir}}/dir",
+	})
+
+	var pathErr *PathError
+	require.ErrorAs(t, err, &pathErr)
+	require.NoFileExists(t, filepath.Join(ctx.OutputDir, "dir", "passwd"))
+}
+
+func TestRunSymlinkAndMkdir(t *testing.T) {
+	ctx := newJobContext(t)
+
+	require.NoError(t, run(t, ctx, Cmd{MkdirOutput: "{{.OutputDir}}/lib/go"}))
+	require.NoError(t, run(t, ctx, Cmd{MkdirOutput: "{{.OutputDir}}/lib/go"}))
+	require.DirExists(t, filepath.Join(ctx.OutputDir, "lib", "go"))
+
+	require.NoError(t, run(t, ctx, Cmd{SymlinkTarget: "../lib/go", SymlinkOutput: "{{.OutputDir}}/bin/go"}))
+
+	target, err := os.Readlink(filepath.Join(ctx.OutputDir, "bin", "go"))
+	require.NoError(t, err)
+	require.Equal(t, "../lib/go", target)
+}
+
+func TestRunEnvFile(t *testing.T) {
+	ctx := newJobContext(t)
+
+	require.NoError(t, run(t, ctx, Cmd{
+		EnvFileOutput: "{{.OutputDir}}/etc/env",
+		Environ:       []string{"GOOS=linux", "SRC={{.SourceDir}}"},
+	}))
+
+	content, err := os.ReadFile(filepath.Join(ctx.OutputDir, "etc", "env"))
+	require.NoError(t, err)
+	require.Equal(t, "GOOS=linux\nSRC="+ctx.SourceDir+"\n", string(content))
+}
+
+func TestRunRejectsSymlinkedOutput(t *testing.T) {
+	ctx := newJobContext(t)
+
+	// Симлинк, созданный командой exec, указывает за пределы OutputDir.
+	require.NoError(t, os.Symlink(ctx.SourceDir, filepath.Join(ctx.OutputDir, "src")))
+
+	err := run(t, ctx, Cmd{EnvFileOutput: "{{.OutputDir}}/src/env", Environ: []string{"A=B"}})
+
+	var pathErr *PathError
+	require.ErrorAs(t, err, &pathErr)
+	require.NoFileExists(t, filepath.Join(ctx.SourceDir, "env"))
+
+	err = run(t, ctx, Cmd{MkdirOutput: "{{.OutputDir}}/src/sub"})
+	require.ErrorAs(t, err, &pathErr)
+	require.NoDirExists(t, filepath.Join(ctx.SourceDir, "sub"))
+}
+
+func TestRunExec(t *testing.T) {
+	cmd := &Cmd{Exec: []string{"true"}}
+	require.ErrorIs(t, cmd.RunInProcess(newJobContext(t)), ErrNotInProcess)
+}
@@ -27,3 +27,22 @@ func TestTopSort(t *testing.T) {
 	require.Equal(t, ID{'b'}, sorted[1].ID)
 	require.Equal(t, ID{'a'}, sorted[2].ID)
 }
+
+func TestTopSortUnknownDeps(t *testing.T) {
+	jobs := []Job{
+		{
+			ID: ID{'a'},
+		},
+		{
+			ID:   ID{'b'},
+			Deps: []ID{{'x'}, {'c'}},
+		},
+		{
+			ID: ID{'c'},
+		},
+	}
+
+	sorted := TopSort(jobs)
+	require.Equal(t, []ID{{'a'}, {'c'}, {'b'}}, []ID{sorted[0].ID, sorted[1].ID, sorted[2].ID})
+	require.Len(t, TopSort([]Job{{ID: ID{'b'}, Deps: []ID{{'x'}}}}), 1)
+}
@@ -0,0 +1,290 @@
+package build
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// JobRef указывает на джоб в сообщениях об ошибках.
+type JobRef struct {
+	ID   ID
+	Name string
+}
+
+func (j JobRef) String() string {
+	return fmt.Sprintf("%q (%s)", j.Name, j.ID.String()[:8])
+}
+
+func ref(job *Job) JobRef {
+	return JobRef{ID: job.ID, Name: job.Name}
+}
+
+// DuplicateJobError описывает несколько джобов с одинаковым ID.
+type DuplicateJobError struct {
+	Jobs []JobRef
+}
+
+func (e *DuplicateJobError) Error() string {
+	names := make([]string, len(e.Jobs))
+	for i, j := range e.Jobs {
+		names[i] = fmt.Sprintf("%q", j.Name)
+	}
+	return fmt.Sprintf("jobs %s have the same id %s", strings.Join(names, ", "), e.Jobs[0].ID)
+}
+
+// MissingDepError описывает зависимость, которой нет в графе.
+type MissingDepError struct {
+	Job JobRef
+	Dep ID
+}
+
+func (e *MissingDepError) Error() string {
+	return fmt.Sprintf("job %s depends on unknown job %s", e.Job, e.Dep)
+}
+
+// MissingInputError описывает входной файл джоба, которого нет в Graph.SourceFiles.
+type MissingInputError struct {
+	Job   JobRef
+	Input string
+}
+
+func (e *MissingInputError) Error() string {
+	return fmt.Sprintf("job %s input %q is missing from source files", e.Job, e.Input)
+}
+
+// InvalidCmdError описывает команду джоба, вид которой нельзя определить (см. Cmd.Kind).
+type InvalidCmdError struct {
+	Job JobRef
+	Cmd int
+	Err error
+}
+
+func (e *InvalidCmdError) Error() string {
+	return fmt.Sprintf("job %s cmd %d: %v", e.Job, e.Cmd, e.Err)
+}
+
+func (e *InvalidCmdError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidOutputError описывает Output джоба, который выходит за пределы выходной директории
+// или совпадает с ней самой.
+type InvalidOutputError struct {
+	Job    JobRef
+	Output string
+}
+
+func (e *InvalidOutputError) Error() string {
+	return fmt.Sprintf("job %s output %q must be a relative path inside the output directory", e.Job, e.Output)
+}
+
+// OutputConflictError описывает два джоба, Output одного из которых совпадает с Output другого
+// или лежит внутри него.
+type OutputConflictError struct {
+	Job, Other          JobRef
+	Output, OtherOutput string
+}
+
+func (e *OutputConflictError) Error() string {
+	return fmt.Sprintf("job %s output %q conflicts with job %s output %q", e.Job, e.Output, e.Other, e.OtherOutput)
+}
+
+// CycleError описывает цикл в зависимостях джобов.
+//
+// Cycle перечисляет джобы цикла в порядке зависимостей: каждый джоб зависит от следующего,
+// а последний - от первого.
+type CycleError struct {
+	Cycle []JobRef
+}
+
+func (e *CycleError) Error() string {
+	path := make([]string, 0, len(e.Cycle)+1)
+	for _, j := range e.Cycle {
+		path = append(path, j.String())
+	}
+	path = append(path, e.Cycle[0].String())
+	return "dependency cycle: " + strings.Join(path, " -> ")
+}
+
+// ValidationError содержит все проблемы, найденные Validate.
+//
+// Отдельные проблемы достаются через errors.As: *DuplicateJobError, *MissingDepError,
+// *MissingInputError, *InvalidCmdError, *InvalidOutputError, *OutputConflictError и *CycleError.
+type ValidationError struct {
+	Errors []error
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "invalid build graph: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	return e.Errors
+}
+
+// Validate проверяет, что граф можно собрать: ID джобов уникальны, все Deps ссылаются на джобы графа,
+// все Inputs перечислены в SourceFiles, у каждой команды определён вид, Output джобов не пересекаются,
+// а зависимости не содержат циклов.
+//
+// Validate возвращает nil или *ValidationError со всеми найденными проблемами.
+func Validate(graph *Graph) error {
+	var errs []error
+
+	sources := make(map[string]struct{}, len(graph.SourceFiles))
+	for _, path := range graph.SourceFiles {
+		sources[path] = struct{}{}
+	}
+
+	index := make(map[ID]int, len(graph.Jobs))
+	duplicates := map[ID]*DuplicateJobError{}
+	for i := range graph.Jobs {
+		job := &graph.Jobs[i]
+
+		first, ok := index[job.ID]
+		if !ok {
+			index[job.ID] = i
+			continue
+		}
+
+		dup, ok := duplicates[job.ID]
+		if !ok {
+			dup = &DuplicateJobError{Jobs: []JobRef{ref(&graph.Jobs[first])}}
+			duplicates[job.ID] = dup
+			errs = append(errs, dup)
+		}
+		dup.Jobs = append(dup.Jobs, ref(job))
+	}
+
+	for i := range graph.Jobs {
+		job := &graph.Jobs[i]
+
+		for _, dep := range job.Deps {
+			if _, ok := index[dep]; !ok {
+				errs = append(errs, &MissingDepError{Job: ref(job), Dep: dep})
+			}
+		}
+
+		for _, input := range job.Inputs {
+			if _, ok := sources[input]; !ok {
+				errs = append(errs, &MissingInputError{Job: ref(job), Input: input})
+			}
+		}
+
+		for j := range job.Cmds {
+			if _, err := job.Cmds[j].Kind(); err != nil {
+				errs = append(errs, &InvalidCmdError{Job: ref(job), Cmd: j, Err: err})
+			}
+		}
+	}
+
+	errs = append(errs, checkOutputs(graph.Jobs)...)
+	errs = append(errs, findCycles(graph.Jobs, index)...)
+
+	if len(errs) != 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// checkOutputs проверяет, что каждый Output лежит внутри выходной директории и что клиент не
+// запишет результат одного джоба поверх результата другого.
+func checkOutputs(jobs []Job) []error {
+	var errs []error
+
+	owners := map[string]*Job{}
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Output == "" {
+			continue
+		}
+
+		// Output "." заменил бы всю выходную директорию клиента целиком.
+		if !filepath.IsLocal(job.Output) || filepath.Clean(job.Output) == "." {
+			errs = append(errs, &InvalidOutputError{Job: ref(job), Output: job.Output})
+			continue
+		}
+
+		output := filepath.Clean(job.Output)
+		if other, ok := owners[output]; ok {
+			errs = append(errs, &OutputConflictError{Job: ref(job), Other: ref(other), Output: job.Output, OtherOutput: other.Output})
+			continue
+		}
+		owners[output] = job
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		output := filepath.Clean(job.Output)
+		if owners[output] != job {
+			continue
+		}
+
+		for dir := filepath.Dir(output); dir != "."; dir = filepath.Dir(dir) {
+			if other, ok := owners[dir]; ok {
+				errs = append(errs, &OutputConflictError{Job: ref(job), Other: ref(other), Output: job.Output, OtherOutput: other.Output})
+			}
+		}
+	}
+
+	return errs
+}
+
+// findCycles обходит граф в глубину и возвращает по CycleError на каждое обратное ребро.
+func findCycles(jobs []Job, index map[ID]int) []error {
+	const (
+		white = iota
+		gray
+		black
+	)
+
+	var (
+		errs  []error
+		color = make([]int, len(jobs))
+		stack []int
+	)
+
+	var visit func(i int)
+	visit = func(i int) {
+		color[i] = gray
+		stack = append(stack, i)
+
+		for _, dep := range jobs[i].Deps {
+			j, ok := index[dep]
+			if !ok {
+				continue
+			}
+
+			switch color[j] {
+			case white:
+				visit(j)
+			case gray:
+				var cycle []JobRef
+				for k := len(stack) - 1; k >= 0; k-- {
+					if stack[k] == j {
+						for _, s := range stack[k:] {
+							cycle = append(cycle, ref(&jobs[s]))
+						}
+						break
+					}
+				}
+				errs = append(errs, &CycleError{Cycle: cycle})
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		color[i] = black
+	}
+
+	for i := range jobs {
+		if color[i] == white && index[jobs[i].ID] == i {
+			visit(i)
+		}
+	}
+
+	return errs
+}
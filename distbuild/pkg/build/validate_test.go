@@ -0,0 +1,159 @@
+package build
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	graph := &Graph{
+		SourceFiles: map[ID]string{{'f'}: "a.go"},
+		Jobs: []Job{
+			{ID: ID{'a'}, Name: "a", Inputs: []string{"a.go"}, Deps: []ID{{'b'}}},
+			{ID: ID{'b'}, Name: "b"},
+		},
+	}
+
+	require.NoError(t, Validate(graph))
+	require.NoError(t, Validate(&Graph{}))
+}
+
+func TestValidateCycle(t *testing.T) {
+	graph := &Graph{
+		Jobs: []Job{
+			{ID: ID{'a'}, Name: "a", Deps: []ID{{'b'}}},
+			{ID: ID{'b'}, Name: "b", Deps: []ID{{'c'}}},
+			{ID: ID{'c'}, Name: "c", Deps: []ID{{'a'}}},
+			{ID: ID{'d'}, Name: "d", Deps: []ID{{'d'}}},
+			{ID: ID{'e'}, Name: "e", Deps: []ID{{'a'}}},
+		},
+	}
+
+	err := Validate(graph)
+	require.Error(t, err)
+
+	var validation *ValidationError
+	require.True(t, errors.As(err, &validation))
+	require.Len(t, validation.Errors, 2)
+
+	var cycle *CycleError
+	require.True(t, errors.As(validation.Errors[0], &cycle))
+	require.Equal(t, []JobRef{
+		{ID: ID{'a'}, Name: "a"},
+		{ID: ID{'b'}, Name: "b"},
+		{ID: ID{'c'}, Name: "c"},
+	}, cycle.Cycle)
+	require.Contains(t, err.Error(), `dependency cycle: "a" (61000000) -> "b" (62000000) -> "c" (63000000) -> "a" (61000000)`)
+
+	require.True(t, errors.As(validation.Errors[1], &cycle))
+	require.Equal(t, []JobRef{{ID: ID{'d'}, Name: "d"}}, cycle.Cycle)
+}
+
+func TestValidateProblems(t *testing.T) {
+	graph := &Graph{
+		SourceFiles: map[ID]string{{'f'}: "a.go"},
+		Jobs: []Job{
+			{ID: ID{'a'}, Name: "compile", Inputs: []string{"a.go", "b.go"}},
+			{ID: ID{'a'}, Name: "link"},
+			{ID: ID{'c'}, Name: "test", Deps: []ID{{'x'}}},
+		},
+	}
+
+	err := Validate(graph)
+	require.Error(t, err)
+
+	var duplicate *DuplicateJobError
+	require.True(t, errors.As(err, &duplicate))
+	require.Equal(t, []JobRef{{ID: ID{'a'}, Name: "compile"}, {ID: ID{'a'}, Name: "link"}}, duplicate.Jobs)
+
+	var input *MissingInputError
+	require.True(t, errors.As(err, &input))
+	require.Equal(t, JobRef{ID: ID{'a'}, Name: "compile"}, input.Job)
+	require.Equal(t, "b.go", input.Input)
+
+	var dep *MissingDepError
+	require.True(t, errors.As(err, &dep))
+	require.Equal(t, JobRef{ID: ID{'c'}, Name: "test"}, dep.Job)
+	require.Equal(t, ID{'x'}, dep.Dep)
+
+	var cycle *CycleError
+	require.False(t, errors.As(err, &cycle))
+}
+
+func TestValidateOutputs(t *testing.T) {
+	graph := &Graph{
+		Jobs: []Job{
+			{ID: ID{'a'}, Name: "server", Output: "bin/server"},
+			{ID: ID{'b'}, Name: "client", Output: "bin/client/"},
+			{ID: ID{'c'}, Name: "lib"},
+		},
+	}
+	require.NoError(t, Validate(graph))
+
+	graph = &Graph{
+		Jobs: []Job{
+			{ID: ID{'a'}, Name: "server", Output: "bin/server"},
+			{ID: ID{'b'}, Name: "escape", Output: "../server"},
+			{ID: ID{'c'}, Name: "absolute", Output: "/usr/bin/server"},
+			{ID: ID{'f'}, Name: "root", Output: "bin/.."},
+			{ID: ID{'d'}, Name: "copy", Output: "bin/./server"},
+			{ID: ID{'e'}, Name: "bin", Output: "bin"},
+		},
+	}
+
+	err := Validate(graph)
+	require.Error(t, err)
+
+	var validation *ValidationError
+	require.True(t, errors.As(err, &validation))
+	require.Len(t, validation.Errors, 5)
+
+	var invalid *InvalidOutputError
+	require.True(t, errors.As(validation.Errors[0], &invalid))
+	require.Equal(t, "../server", invalid.Output)
+	require.True(t, errors.As(validation.Errors[1], &invalid))
+	require.Equal(t, "/usr/bin/server", invalid.Output)
+	require.True(t, errors.As(validation.Errors[2], &invalid))
+	require.Equal(t, "bin/..", invalid.Output)
+
+	var conflict *OutputConflictError
+	require.True(t, errors.As(validation.Errors[3], &conflict))
+	require.Equal(t, "copy", conflict.Job.Name)
+	require.Equal(t, "server", conflict.Other.Name)
+
+	require.True(t, errors.As(validation.Errors[4], &conflict))
+	require.Equal(t, "server", conflict.Job.Name)
+	require.Equal(t, "bin", conflict.Other.Name)
+	require.Contains(t, err.Error(), `job "server" (61000000) output "bin/server" conflicts with job "bin" (65000000) output "bin"`)
+}
+
+func TestValidateCmds(t *testing.T) {
+	graph := &Graph{
+		Jobs: []Job{
+			{ID: ID{'a'}, Name: "a", Cmds: []Cmd{
+				{Exec: []string{"true"}},
+				{},
+				{MkdirOutput: "{{.OutputDir}}/bin", SymlinkOutput: "{{.OutputDir}}/bin/a"},
+			}},
+		},
+	}
+
+	err := Validate(graph)
+	require.Error(t, err)
+
+	var validation *ValidationError
+	require.True(t, errors.As(err, &validation))
+	require.Len(t, validation.Errors, 2)
+
+	var invalid *InvalidCmdError
+	require.True(t, errors.As(validation.Errors[0], &invalid))
+	require.Equal(t, 1, invalid.Cmd)
+	require.ErrorIs(t, invalid, ErrNoCmdKind)
+
+	require.True(t, errors.As(validation.Errors[1], &invalid))
+	require.Equal(t, 2, invalid.Cmd)
+	require.ErrorIs(t, invalid, ErrAmbiguousCmdKind)
+	require.Contains(t, err.Error(), `job "a" (61000000) cmd 2: command sets fields of several kinds: [symlink mkdir]`)
+}
@@ -0,0 +1,158 @@
+package chunker
+
+import (
+	"crypto/sha1"
+	"errors"
+	"io"
+	"math/bits"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+// Config задаёт ограничения на размер чанков.
+//
+// Avg должен быть степенью двойки, Min <= Avg <= Max.
+type Config struct {
+	Min, Avg, Max int
+}
+
+// DefaultConfig подобран для файлов от сотен килобайт до сотен мегабайт.
+var DefaultConfig = Config{
+	Min: 256 << 10,
+	Avg: 1 << 20,
+	Max: 4 << 20,
+}
+
+var ErrInvalidConfig = errors.New("chunker: invalid config")
+
+func (c Config) validate() error {
+	if c.Min <= 0 || c.Min > c.Avg || c.Avg > c.Max || bits.OnesCount(uint(c.Avg)) != 1 {
+		return ErrInvalidConfig
+	}
+	return nil
+}
+
+// Chunk описывает очередной кусок файла.
+type Chunk struct {
+	// ID - sha1 содержимого чанка.
+	ID build.ID
+
+	// Offset - смещение чанка от начала файла.
+	Offset int64
+
+	// Data содержит байты чанка. Слайс валиден только до следующего вызова Next.
+	Data []byte
+}
+
+// Chunker режет поток на чанки по содержимому (content-defined chunking).
+//
+// Граница чанка ставится там, где rolling hash последнего окна байт (gear hash) принимает
+// определённое значение. Поэтому вставка или удаление байт в середине файла меняет только
+// соседние с правкой чанки, а остальные чанки и их ID остаются прежними.
+type Chunker struct {
+	r      io.Reader
+	config Config
+	mask   uint64
+
+	buf    []byte
+	start  int
+	end    int
+	offset int64
+	eof    bool
+}
+
+func New(r io.Reader, config Config) (*Chunker, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	return &Chunker{
+		r:      r,
+		config: config,
+		mask:   uint64(config.Avg-1) << (64 - bits.TrailingZeros(uint(config.Avg))),
+		buf:    make([]byte, 2*config.Max),
+	}, nil
+}
+
+// fill дочитывает буфер так, чтобы в нём было хотя бы Max байт или весь остаток потока.
+func (c *Chunker) fill() error {
+	if c.end-c.start >= c.config.Max || c.eof {
+		return nil
+	}
+
+	c.end = copy(c.buf, c.buf[c.start:c.end])
+	c.start = 0
+
+	for c.end < len(c.buf) && !c.eof {
+		n, err := c.r.Read(c.buf[c.end:])
+		c.end += n
+
+		if err == io.EOF {
+			c.eof = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cut возвращает длину следующего чанка в data.
+//
+// Старшие биты gear hash зависят от последних 64 байт, поэтому граница проверяется по ним.
+func (c *Chunker) cut(data []byte) int {
+	if len(data) <= c.config.Min {
+		return len(data)
+	}
+
+	if len(data) > c.config.Max {
+		data = data[:c.config.Max]
+	}
+
+	var hash uint64
+	for i := c.config.Min; i < len(data); i++ {
+		hash = (hash << 1) + gear[data[i]]
+		if hash&c.mask == 0 {
+			return i + 1
+		}
+	}
+
+	return len(data)
+}
+
+// Next возвращает следующий чанк. После последнего чанка Next возвращает io.EOF.
+func (c *Chunker) Next() (Chunk, error) {
+	if err := c.fill(); err != nil {
+		return Chunk{}, err
+	}
+
+	if c.start == c.end {
+		return Chunk{}, io.EOF
+	}
+
+	n := c.cut(c.buf[c.start:c.end])
+	chunk := Chunk{
+		ID:     sha1.Sum(c.buf[c.start : c.start+n]),
+		Offset: c.offset,
+		Data:   c.buf[c.start : c.start+n],
+	}
+
+	c.start += n
+	c.offset += int64(n)
+	return chunk, nil
+}
+
+// gear - таблица случайных чисел для gear hash. Таблица фиксирована, иначе границы
+// чанков у разных версий клиента не совпадали бы.
+var gear = func() (table [256]uint64) {
+	// splitmix64
+	state := uint64(0x6469737462756c64)
+	for i := range table {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return
+}()
@@ -0,0 +1,100 @@
+package chunker_test
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/chunker"
+)
+
+var testConfig = chunker.Config{Min: 1 << 10, Avg: 4 << 10, Max: 16 << 10}
+
+func split(t *testing.T, data []byte) (ids []build.ID, sizes []int) {
+	t.Helper()
+
+	c, err := chunker.New(bytes.NewReader(data), testConfig)
+	require.NoError(t, err)
+
+	var joined []byte
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		require.Equal(t, int64(len(joined)), chunk.Offset)
+		joined = append(joined, chunk.Data...)
+
+		ids = append(ids, chunk.ID)
+		sizes = append(sizes, len(chunk.Data))
+	}
+
+	require.Equal(t, data, joined)
+	return
+}
+
+func randomData(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(42)).Read(data)
+	return data
+}
+
+func TestChunkSizes(t *testing.T) {
+	_, sizes := split(t, randomData(1<<20))
+
+	require.Greater(t, len(sizes), 1<<20/testConfig.Max)
+	for i, size := range sizes {
+		require.LessOrEqual(t, size, testConfig.Max)
+		if i != len(sizes)-1 {
+			require.GreaterOrEqual(t, size, testConfig.Min)
+		}
+	}
+}
+
+func TestSmallInput(t *testing.T) {
+	ids, _ := split(t, nil)
+	require.Empty(t, ids)
+
+	ids, sizes := split(t, []byte("foo"))
+	require.Len(t, ids, 1)
+	require.Equal(t, []int{3}, sizes)
+}
+
+func TestEditKeepsChunks(t *testing.T) {
+	data := randomData(1 << 20)
+
+	edited := append([]byte{}, data[:len(data)/2]...)
+	edited = append(edited, []byte("one more line\n")...)
+	edited = append(edited, data[len(data)/2:]...)
+
+	before, _ := split(t, data)
+	after, _ := split(t, edited)
+
+	known := map[build.ID]bool{}
+	for _, id := range before {
+		known[id] = true
+	}
+
+	var changed int
+	for _, id := range after {
+		if !known[id] {
+			changed++
+		}
+	}
+
+	require.LessOrEqual(t, changed, 2)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	_, err := chunker.New(nil, chunker.Config{Min: 1, Avg: 3, Max: 4})
+	require.ErrorIs(t, err, chunker.ErrInvalidConfig)
+
+	_, err = chunker.New(nil, chunker.Config{Min: 8, Avg: 4, Max: 16})
+	require.ErrorIs(t, err, chunker.ErrInvalidConfig)
+}
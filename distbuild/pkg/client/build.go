@@ -4,9 +4,11 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 
 	"go.uber.org/zap"
 
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
 	"gitlab.com/slon/shad-go/distbuild/pkg/build"
 )
 
@@ -21,6 +23,49 @@ func NewClient(
 	panic("implement me")
 }
 
+// SetBuildService задаёт транспорт, через который клиент запускает сборку.
+//
+// Должен вызываться до Build. По умолчанию клиент ходит по HTTP через api.BuildClient на apiEndpoint,
+// а с gRPC транспортом сюда передаётся grpcapi.BuildClient. Файлы всегда заливаются по HTTP на apiEndpoint.
+func (c *Client) SetBuildService(s api.BuildServiceClient) {
+	panic("implement me")
+}
+
+// SetAuth задаёт токен, с которым клиент ходит к координатору, и настройки TLS.
+//
+// Должен вызываться до Build. Токен передаётся в заголовке Authorization (auth.TokenTransport).
+// Если config == nil, клиент ходит по TLS с настройками по умолчанию.
+func (c *Client) SetAuth(token string, config *tls.Config) {
+	panic("implement me")
+}
+
+// SetTraceOutput включает запись таймлайна сборки в файл path.
+//
+// Должен вызываться до Build. После завершения сборки (в том числе неуспешного) клиент записывает
+// в path трейс в формате Chrome trace event через trace.Trace: фазу заливки файлов и JobResult.Trace
+// каждого завершённого джоба. По умолчанию трейс не пишется.
+func (c *Client) SetTraceOutput(path string) {
+	panic("implement me")
+}
+
+// SetOutputDir задаёт директорию, в которую клиент кладёт результаты сборки.
+//
+// Должен вызываться до Build. После BuildFinished клиент для каждого успешно завершённого джоба
+// с непустым build.Job.Output скачивает его артефакт через координатора (artifact.DownloadWithClient
+// на apiEndpoint) во временную директорию outdir.TempDir и подменяет им dir/Output через outdir.Replace.
+// Если скачать хотя бы один результат не удалось, Build возвращает ошибку с именем джоба.
+// По умолчанию результаты не скачиваются.
+func (c *Client) SetOutputDir(dir string) {
+	panic("implement me")
+}
+
+// SetVerify включает режим проверки воспроизводимости (api.BuildRequest.Verify).
+//
+// Должен вызываться до Build.
+func (c *Client) SetVerify(verify bool) {
+	panic("implement me")
+}
+
 type BuildListener interface {
 	OnJobStdout(jobID build.ID, stdout []byte) error
 	OnJobStderr(jobID build.ID, stderr []byte) error
@@ -29,6 +74,27 @@ type BuildListener interface {
 	OnJobFailed(jobID build.ID, code int, error string) error
 }
 
+// FlakyListener - необязательное расширение BuildListener.
+//
+// Если listener, переданный в Build, реализует FlakyListener, клиент вызывает OnJobFlaky на каждый
+// StatusUpdate.JobFlaky, то есть для джобов, которые упали и прошли после перезапуска.
+// OnJobFlaky вызывается перед OnJobFinished этого джоба.
+type FlakyListener interface {
+	OnJobFlaky(jobID build.ID, failures []api.JobResult) error
+}
+
+// VerifyListener - необязательное расширение BuildListener.
+//
+// Если listener, переданный в Build, реализует VerifyListener, клиент вызывает OnJobMismatch на каждый
+// StatusUpdate.JobMismatch. OnJobMismatch вызывается перед OnJobFinished этого джоба.
+type VerifyListener interface {
+	OnJobMismatch(jobID build.ID, mismatch *api.JobMismatch) error
+}
+
+// Build запускает сборку графа и дожидается её завершения.
+//
+// Если ctx отменили посреди сборки, Build посылает координатору сигнал Cancel
+// и возвращает ошибку контекста.
 func (c *Client) Build(ctx context.Context, graph build.Graph, lsn BuildListener) error {
 	panic("implement me")
 }
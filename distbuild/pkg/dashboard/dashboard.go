@@ -0,0 +1,159 @@
+package dashboard
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+// Provider отдаёт текущее состояние координатора.
+//
+// Методы вызываются на каждый запрос и должны возвращать копию состояния, которую
+// обработчик может читать без блокировок.
+type Provider interface {
+	Builds() []api.BuildStatus
+	Workers() []api.WorkerStatus
+}
+
+// Handler обслуживает read-only эндпоинты со статусом координатора.
+type Handler struct {
+	l        *zap.Logger
+	provider Provider
+}
+
+func NewHandler(l *zap.Logger, provider Provider) *Handler {
+	return &Handler{l: l, provider: provider}
+}
+
+// Register регистрирует эндпоинты:
+//   - GET /status/builds - JSON список активных сборок с их джобами;
+//   - GET /status/builds/{id} - JSON одной сборки;
+//   - GET /status/workers - JSON список подключённых воркеров;
+//   - GET /dashboard - HTML страница поверх тех же данных.
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /status/builds", h.builds)
+	mux.HandleFunc("GET /status/builds/{id}", h.build)
+	mux.HandleFunc("GET /status/workers", h.workers)
+	mux.HandleFunc("GET /dashboard", h.dashboard)
+}
+
+func (h *Handler) sortedBuilds() []api.BuildStatus {
+	builds := h.provider.Builds()
+	slices.SortStableFunc(builds, func(a, b api.BuildStatus) int {
+		return a.Started.Compare(b.Started)
+	})
+	return builds
+}
+
+func (h *Handler) sortedWorkers() []api.WorkerStatus {
+	workers := h.provider.Workers()
+	slices.SortFunc(workers, func(a, b api.WorkerStatus) int {
+		return strings.Compare(string(a.ID), string(b.ID))
+	})
+	return workers
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.l.Warn("failed to write status", zap.Error(err))
+	}
+}
+
+func (h *Handler) builds(w http.ResponseWriter, r *http.Request) {
+	builds := h.sortedBuilds()
+	if builds == nil {
+		builds = []api.BuildStatus{}
+	}
+	h.writeJSON(w, builds)
+}
+
+func (h *Handler) build(w http.ResponseWriter, r *http.Request) {
+	var id build.ID
+	if err := id.UnmarshalText([]byte(r.PathValue("id"))); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, b := range h.provider.Builds() {
+		if b.ID == id {
+			h.writeJSON(w, b)
+			return
+		}
+	}
+
+	http.Error(w, "build "+id.String()+" not found", http.StatusNotFound)
+}
+
+func (h *Handler) workers(w http.ResponseWriter, r *http.Request) {
+	workers := h.sortedWorkers()
+	if workers == nil {
+		workers = []api.WorkerStatus{}
+	}
+	h.writeJSON(w, workers)
+}
+
+//go:embed dashboard.html
+var dashboardHTML string
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"short": func(id build.ID) string { return id.String()[:8] },
+	"since": func(now, t time.Time) string { return now.Sub(t).Round(time.Second).String() },
+}).Parse(dashboardHTML))
+
+// buildView - сборка вместе с числом джобов в каждом состоянии.
+type buildView struct {
+	api.BuildStatus
+	Pending, Running, Finished, Failed int
+}
+
+type dashboardView struct {
+	Now     time.Time
+	Builds  []buildView
+	Workers []api.WorkerStatus
+}
+
+func (h *Handler) dashboard(w http.ResponseWriter, r *http.Request) {
+	view := dashboardView{
+		Now:     time.Now(),
+		Workers: h.sortedWorkers(),
+	}
+
+	for _, b := range h.sortedBuilds() {
+		v := buildView{BuildStatus: b}
+		for _, job := range b.Jobs {
+			switch job.State {
+			case api.JobStatePending:
+				v.Pending++
+			case api.JobStateRunning:
+				v.Running++
+			case api.JobStateFinished:
+				v.Finished++
+			case api.JobStateFailed:
+				v.Failed++
+			}
+		}
+		view.Builds = append(view.Builds, v)
+	}
+
+	// Шаблон рендерится в буфер, чтобы ошибка шаблона не оставила клиенту половину страницы.
+	var buf bytes.Buffer
+	if err := dashboardTemplate.Execute(&buf, view); err != nil {
+		h.l.Error("failed to render dashboard", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,169 @@
+package dashboard_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/dashboard"
+)
+
+type fakeProvider struct {
+	builds  []api.BuildStatus
+	workers []api.WorkerStatus
+}
+
+func (p *fakeProvider) Builds() []api.BuildStatus {
+	return append([]api.BuildStatus(nil), p.builds...)
+}
+
+func (p *fakeProvider) Workers() []api.WorkerStatus {
+	return append([]api.WorkerStatus(nil), p.workers...)
+}
+
+var (
+	now = time.Now()
+
+	fake = &fakeProvider{
+		builds: []api.BuildStatus{
+			{
+				ID:      build.ID{'b'},
+				Started: now.Add(-time.Second),
+				Jobs: []api.JobStatus{
+					{ID: build.ID{'c'}, Name: "compile <main>", State: api.JobStateRunning, WorkerID: "w0"},
+					{ID: build.ID{'d'}, Name: "link", State: api.JobStatePending},
+				},
+			},
+			{
+				ID:      build.ID{'a'},
+				Started: now.Add(-time.Minute),
+				Jobs: []api.JobStatus{
+					{ID: build.ID{'e'}, Name: "test", State: api.JobStateFailed, WorkerID: "w1"},
+				},
+			},
+		},
+		workers: []api.WorkerStatus{
+			{ID: "w1", FreeSlots: 2, Artifacts: 10, LastHeartbeat: now},
+			{ID: "w0", FreeSlots: 0, Artifacts: 3, LastHeartbeat: now},
+		},
+	}
+)
+
+func newServer(t *testing.T, provider dashboard.Provider) *httptest.Server {
+	mux := http.NewServeMux()
+	dashboard.NewHandler(zaptest.NewLogger(t), provider).Register(mux)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, url string) (*http.Response, string) {
+	t.Helper()
+
+	rsp, err := http.Get(url)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	return rsp, string(body)
+}
+
+func TestBuilds(t *testing.T) {
+	server := newServer(t, fake)
+
+	rsp, body := get(t, server.URL+"/status/builds")
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+	require.Equal(t, "application/json", rsp.Header.Get("Content-Type"))
+
+	var builds []api.BuildStatus
+	require.NoError(t, json.Unmarshal([]byte(body), &builds))
+	require.Len(t, builds, 2)
+
+	// Сборки отсортированы по времени старта.
+	require.Equal(t, build.ID{'a'}, builds[0].ID)
+	require.Equal(t, build.ID{'b'}, builds[1].ID)
+	require.Equal(t, fake.builds[0].Jobs, builds[1].Jobs)
+}
+
+func TestBuild(t *testing.T) {
+	server := newServer(t, fake)
+
+	rsp, body := get(t, server.URL+"/status/builds/"+build.ID{'a'}.String())
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+
+	var status api.BuildStatus
+	require.NoError(t, json.Unmarshal([]byte(body), &status))
+	require.Equal(t, fake.builds[1].Jobs, status.Jobs)
+
+	rsp, _ = get(t, server.URL+"/status/builds/"+build.ID{'z'}.String())
+	require.Equal(t, http.StatusNotFound, rsp.StatusCode)
+
+	rsp, _ = get(t, server.URL+"/status/builds/xyz")
+	require.Equal(t, http.StatusBadRequest, rsp.StatusCode)
+}
+
+func TestWorkers(t *testing.T) {
+	server := newServer(t, fake)
+
+	rsp, body := get(t, server.URL+"/status/workers")
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+
+	var workers []api.WorkerStatus
+	require.NoError(t, json.Unmarshal([]byte(body), &workers))
+	require.Len(t, workers, 2)
+	require.Equal(t, api.WorkerID("w0"), workers[0].ID)
+	require.Equal(t, 3, workers[0].Artifacts)
+	require.Equal(t, 2, workers[1].FreeSlots)
+}
+
+func TestEmpty(t *testing.T) {
+	server := newServer(t, &fakeProvider{})
+
+	_, body := get(t, server.URL+"/status/builds")
+	require.JSONEq(t, "[]", body)
+
+	_, body = get(t, server.URL+"/status/workers")
+	require.JSONEq(t, "[]", body)
+
+	rsp, body := get(t, server.URL+"/dashboard")
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+	require.Contains(t, body, "No workers connected.")
+	require.Contains(t, body, "No active builds.")
+}
+
+func TestDashboard(t *testing.T) {
+	server := newServer(t, fake)
+
+	rsp, body := get(t, server.URL+"/dashboard")
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+	require.Equal(t, "text/html; charset=utf-8", rsp.Header.Get("Content-Type"))
+
+	require.Contains(t, body, "Workers (2)")
+	require.Contains(t, body, "<td>w1</td><td>2</td><td>10</td>")
+	require.Contains(t, body, "Build "+build.ID{'b'}.String()[:8])
+	require.Contains(t, body, "1 pending,\n1 running,\n0 finished,\n0 failed.")
+	require.Contains(t, body, `<td class="failed">failed</td><td>w1</td>`)
+
+	// Имена джобов экранируются.
+	require.Contains(t, body, "compile &lt;main&gt;")
+	require.NotContains(t, body, "<main>")
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	server := newServer(t, fake)
+
+	rsp, err := http.Post(server.URL+"/status/builds", "application/json", nil)
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	require.Equal(t, http.StatusMethodNotAllowed, rsp.StatusCode)
+}
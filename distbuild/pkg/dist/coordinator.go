@@ -3,32 +3,133 @@
 package dist
 
 import (
+	"crypto/tls"
 	"net/http"
 	"time"
 
 	"go.uber.org/zap"
 
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/dashboard"
 	"gitlab.com/slon/shad-go/distbuild/pkg/filecache"
+	"gitlab.com/slon/shad-go/distbuild/pkg/journal"
 	"gitlab.com/slon/shad-go/distbuild/pkg/scheduler"
 )
 
 type Coordinator struct {
 }
 
+var _ dashboard.Provider = (*Coordinator)(nil)
+
 var defaultConfig = scheduler.Config{
 	CacheTimeout: time.Millisecond * 10,
 	DepsTimeout:  time.Millisecond * 100,
 }
 
+// workersTimeout - сколько сборка ждёт подходящих воркеров, прежде чем завершиться с BuildFailed.
+//
+// Воркеры регистрируются с первым heartbeat-ом, поэтому сразу после старта кластера координатор знает
+// не про всех. Столько времени координатор ждёт второго живого воркера для сборки с BuildRequest.Verify
+// и воркера, для которого scheduler.CanSatisfy вернёт true.
+var workersTimeout = time.Second * 5
+
+var defaultLivenessConfig = LivenessConfig{
+	HeartbeatInterval: time.Millisecond * 100,
+	MissedHeartbeats:  5,
+}
+
+// NewCoordinator создаёт координатора.
+//
+// Если journal != nil, координатор восстанавливает из него незавершённые сборки и
+// записывает в него все последующие события. Если journal == nil, состояние
+// координатора хранится только в памяти.
 func NewCoordinator(
 	log *zap.Logger,
 	fileCache *filecache.Cache,
+	journal *journal.Journal,
 ) *Coordinator {
 	panic("implement me")
 }
 
+// BuildService возвращает реализацию api.Service координатора.
+//
+// Через неё API сборки обслуживается другими транспортами, например grpcapi.RegisterBuildService.
+func (c *Coordinator) BuildService() api.Service {
+	panic("implement me")
+}
+
+// HeartbeatService возвращает реализацию api.HeartbeatService координатора.
+func (c *Coordinator) HeartbeatService() api.HeartbeatService {
+	panic("implement me")
+}
+
+// SetLiveness задаёт, как быстро координатор считает мёртвыми воркеров, переставших присылать heartbeat-ы.
+//
+// Должен вызываться до начала обслуживания запросов. По умолчанию используется defaultLivenessConfig,
+// рассчитанный на тесты: в настоящем кластере сеть и GC могут задержать heartbeat дольше, чем на полсекунды.
+func (c *Coordinator) SetLiveness(config LivenessConfig) {
+	panic("implement me")
+}
+
+// SetAuth включает авторизацию запросов к координатору.
+//
+// Должен вызываться до начала обслуживания запросов. Всё, чем пользуется клиент сборки, принимает только
+// запросы с токеном из tokens (auth.TokenMiddleware): API сборки (api.BuildHandler) и заливка файлов
+// (PUT /file, /file/chunks/missing, /file/chunk, /file/assemble). У клиента нет сертификата кластера,
+// поэтому эти эндпоинты сертификат не требуют. Эндпоинты dashboard.Handler тоже требуют токен, но
+// через auth.BrowserTokenMiddleware, которая принимает его ещё и из cookie, чтобы дашборд открывался в браузере.
+//
+// Heartbeat-ы и скачивание файлов (GET /file) принимаются только от компонентов с сертификатом
+// CA кластера (auth.RequireClientCert), причём WorkerID в HeartbeatRequest должен совпадать с
+// идентификатором из сертификата воркера. Чтобы обернуть методы /file в разные middleware,
+// координатор регистрирует filecache.Handler на отдельном http.ServeMux и маршрутизирует в него
+// запросы по методу.
+//
+// Сам TLS настраивает HTTP сервер, в котором работает координатор (auth.ServerConfig).
+func (c *Coordinator) SetAuth(tokens []string) {
+	panic("implement me")
+}
+
+// SetTLS задаёт клиентскую конфигурацию, с которой координатор ходит к воркерам на mTLS кластере.
+//
+// Должен вызываться до начала обслуживания запросов. config содержит сертификат координатора
+// (auth.ClientConfig), через него координатор скачивает артефакты для GET /artifact и артефакты
+// несовпавших запусков в режиме BuildRequest.Verify (artifact.DownloadWithClient). Идентификатор из
+// сертификата (auth.Identity) координатор отправляет воркерам в HeartbeatResponse.Coordinator, и воркеры
+// отдают ему артефакты, хотя его нет в HeartbeatResponse.Peers.
+func (c *Coordinator) SetTLS(config *tls.Config) {
+	panic("implement me")
+}
+
+// Builds возвращает состояние активных сборок для dashboard.Handler.
+//
+// Сборка считается активной, пока клиент не получил BuildFinished или BuildFailed. Джоб сборки
+// переходит в JobStateRunning только после того, как сборка подписалась на него через
+// scheduler.ScheduleJob, в том числе если джоб уже выполняется для другой сборки.
+func (c *Coordinator) Builds() []api.BuildStatus {
+	panic("implement me")
+}
+
+// Workers возвращает состояние живых воркеров для dashboard.Handler.
+//
+// FreeSlots берётся из последнего heartbeat-а воркера, Artifacts - из числа артефактов,
+// которые шедулер знает на этом воркере.
+func (c *Coordinator) Workers() []api.WorkerStatus {
+	panic("implement me")
+}
+
+// Stop останавливает координатора и прерывает все активные запросы.
 func (c *Coordinator) Stop() {}
 
+// ServeHTTP обслуживает API координатора.
+//
+// Кроме того, по пути metrics.Path координатор отдаёт свои метрики, метрики шедулера и статистику файлового кеша,
+// а эндпоинты dashboard.Handler показывают состояние сборок и воркеров.
+//
+// GET /artifact?id=1234 отдаёт артефакт клиенту: координатор находит воркера с артефактом через
+// scheduler.LocateArtifact и проксирует к нему запрос вместе с параметром after и заголовком Accept-Encoding.
+// После SetTLS запрос к воркеру идёт с сертификатом координатора.
+// Если артефакта нет ни на одном воркере, координатор отвечает 404.
 func (c *Coordinator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	panic("implement me")
 }
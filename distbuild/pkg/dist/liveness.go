@@ -0,0 +1,82 @@
+package dist
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+type LivenessConfig struct {
+	// HeartbeatInterval задаёт, как часто воркер обязан присылать heartbeat.
+	HeartbeatInterval time.Duration
+
+	// MissedHeartbeats задаёт, сколько heartbeat-ов подряд воркер может пропустить,
+	// прежде чем координатор посчитает его мёртвым.
+	MissedHeartbeats int
+}
+
+// DeadWorker описывает воркера, который перестал присылать heartbeat-ы.
+type DeadWorker struct {
+	ID api.WorkerID
+
+	// RunningJobs перечисляет джобы, которые выполнялись на воркере в момент последнего heartbeat-а.
+	RunningJobs []build.ID
+}
+
+// LivenessTracker следит за heartbeat-ами воркеров.
+type LivenessTracker struct {
+	config LivenessConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	lastSeen map[api.WorkerID]time.Time
+	running  map[api.WorkerID][]build.ID
+}
+
+func NewLivenessTracker(config LivenessConfig, now func() time.Time) *LivenessTracker {
+	return &LivenessTracker{
+		config:   config,
+		now:      now,
+		lastSeen: map[api.WorkerID]time.Time{},
+		running:  map[api.WorkerID][]build.ID{},
+	}
+}
+
+// Heartbeat запоминает, что воркер жив, и список джобов, которые он выполняет.
+func (t *LivenessTracker) Heartbeat(req *api.HeartbeatRequest) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastSeen[req.WorkerID] = t.now()
+	t.running[req.WorkerID] = append([]build.ID(nil), req.RunningJobs...)
+}
+
+// Expire возвращает воркеров, пропустивших больше MissedHeartbeats heartbeat-ов, и забывает про них.
+//
+// Если такой воркер пришлёт heartbeat позже, он будет считаться новым воркером.
+func (t *LivenessTracker) Expire() []DeadWorker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	deadline := t.now().Add(-t.config.HeartbeatInterval * time.Duration(t.config.MissedHeartbeats))
+
+	var dead []DeadWorker
+	for id, lastSeen := range t.lastSeen {
+		if !lastSeen.Before(deadline) {
+			continue
+		}
+
+		dead = append(dead, DeadWorker{ID: id, RunningJobs: t.running[id]})
+		delete(t.lastSeen, id)
+		delete(t.running, id)
+	}
+
+	sort.Slice(dead, func(i, j int) bool {
+		return dead[i].ID < dead[j].ID
+	})
+
+	return dead
+}
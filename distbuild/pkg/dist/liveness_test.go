@@ -0,0 +1,38 @@
+package dist_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/dist"
+)
+
+func TestLivenessTracker(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+
+	tracker := dist.NewLivenessTracker(dist.LivenessConfig{
+		HeartbeatInterval: time.Second,
+		MissedHeartbeats:  3,
+	}, clock.Now)
+
+	tracker.Heartbeat(&api.HeartbeatRequest{WorkerID: "w0", RunningJobs: []build.ID{{'a'}}})
+	tracker.Heartbeat(&api.HeartbeatRequest{WorkerID: "w1"})
+
+	clock.Advance(2 * time.Second)
+	tracker.Heartbeat(&api.HeartbeatRequest{WorkerID: "w1"})
+	require.Empty(t, tracker.Expire())
+
+	clock.Advance(2 * time.Second)
+	require.Equal(t, []dist.DeadWorker{
+		{ID: "w0", RunningJobs: []build.ID{{'a'}}},
+	}, tracker.Expire())
+	require.Empty(t, tracker.Expire())
+
+	clock.Advance(2 * time.Second)
+	require.Equal(t, []dist.DeadWorker{{ID: "w1"}}, tracker.Expire())
+}
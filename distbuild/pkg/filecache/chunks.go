@@ -0,0 +1,110 @@
+package filecache
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+const chunksDir = "chunks"
+
+var (
+	ErrChunkChecksum = errors.New("chunk content does not match its id")
+	ErrFileChecksum  = errors.New("assembled file does not match its id")
+)
+
+// MissingChunks возвращает чанки из ids, которых нет в кеше.
+func (c *Cache) MissingChunks(ids []build.ID) []build.ID {
+	var missing []build.ID
+	for _, id := range ids {
+		_, unlock, err := c.chunks.Get(id)
+		if err != nil {
+			missing = append(missing, id)
+			continue
+		}
+		unlock()
+	}
+	return missing
+}
+
+// WriteChunk сохраняет чанк в кеш.
+//
+// id должен совпадать с sha1 от data, иначе WriteChunk возвращает ErrChunkChecksum.
+// Повторная запись уже сохранённого чанка не считается ошибкой.
+func (c *Cache) WriteChunk(id build.ID, data []byte) error {
+	if sha1.Sum(data) != id {
+		return fmt.Errorf("%w: %s", ErrChunkChecksum, id)
+	}
+
+	path, commit, abort, err := c.chunks.Create(id)
+	if errors.Is(convertErr(err), ErrExists) {
+		return nil
+	} else if err != nil {
+		return convertErr(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(path, fileName), data, 0666); err != nil {
+		_ = abort()
+		return err
+	}
+
+	return commit()
+}
+
+// Assemble собирает файл file из чанков, уже лежащих в кеше.
+//
+// Файл появляется в кеше атомарно: пока Assemble не закончил, Get возвращает ErrNotFound.
+// Если какого-то чанка нет в кеше, Assemble возвращает ErrNotFound, и файл не создаётся.
+// file должен совпадать с sha1 собранного содержимого, иначе Assemble возвращает ErrFileChecksum.
+//
+// Чанки после сборки остаются в кеше, чтобы следующая заливка изменённого файла переиспользовала их.
+// Поэтому содержимое файла занимает место на диске дважды: в самом файле и в его чанках. Вместе они
+// укладываются в бюджет SetMaxSize, который делится между файлами и чанками.
+func (c *Cache) Assemble(file build.ID, chunks []build.ID) error {
+	var paths []string
+	for _, id := range chunks {
+		root, unlock, err := c.chunks.Get(id)
+		if err != nil {
+			return fmt.Errorf("chunk %s: %w", id, convertErr(err))
+		}
+		defer unlock()
+
+		paths = append(paths, filepath.Join(root, fileName))
+	}
+
+	w, abort, err := c.Write(file)
+	if err != nil {
+		return err
+	}
+
+	hash := sha1.New()
+	copyChunk := func(path string) error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(io.MultiWriter(w, hash), f)
+		return err
+	}
+
+	for _, path := range paths {
+		if err := copyChunk(path); err != nil {
+			_ = abort()
+			return err
+		}
+	}
+
+	if sum := build.ID(hash.Sum(nil)); sum != file {
+		_ = abort()
+		return fmt.Errorf("%w: %s, got %s", ErrFileChecksum, file, sum)
+	}
+
+	return w.Close()
+}
@@ -0,0 +1,63 @@
+package filecache_test
+
+import (
+	"crypto/sha1"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/filecache"
+)
+
+func TestAssemble(t *testing.T) {
+	cache := newCache(t)
+
+	foo, bar := []byte("foo"), []byte("bar")
+	fooID, barID := build.ID(sha1.Sum(foo)), build.ID(sha1.Sum(bar))
+
+	require.Equal(t, []build.ID{fooID, barID}, cache.MissingChunks([]build.ID{fooID, barID}))
+
+	require.NoError(t, cache.WriteChunk(fooID, foo))
+	require.NoError(t, cache.WriteChunk(fooID, foo))
+	require.ErrorIs(t, cache.WriteChunk(barID, foo), filecache.ErrChunkChecksum)
+
+	require.Equal(t, []build.ID{barID}, cache.MissingChunks([]build.ID{fooID, barID}))
+
+	fileID := build.ID(sha1.Sum([]byte("foobarfoo")))
+
+	err := cache.Assemble(fileID, []build.ID{fooID, barID, fooID})
+	require.ErrorIs(t, err, filecache.ErrNotFound)
+
+	_, _, err = cache.Get(fileID)
+	require.ErrorIs(t, err, filecache.ErrNotFound)
+
+	require.NoError(t, cache.WriteChunk(barID, bar))
+
+	// Чанки в другом порядке дают другое содержимое, такой файл не попадает в кеш.
+	err = cache.Assemble(fileID, []build.ID{barID, fooID, fooID})
+	require.ErrorIs(t, err, filecache.ErrFileChecksum)
+
+	_, _, err = cache.Get(fileID)
+	require.ErrorIs(t, err, filecache.ErrNotFound)
+
+	require.NoError(t, cache.Assemble(fileID, []build.ID{fooID, barID, fooID}))
+
+	path, unlock, err := cache.Get(fileID)
+	require.NoError(t, err)
+	defer unlock()
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, []byte("foobarfoo"), content)
+
+	require.ErrorIs(t, cache.Assemble(fileID, []build.ID{fooID}), filecache.ErrExists)
+
+	var files []build.ID
+	require.NoError(t, cache.Range(func(file build.ID) error {
+		files = append(files, file)
+		return nil
+	}))
+	require.Equal(t, []build.ID{fileID}, files)
+}
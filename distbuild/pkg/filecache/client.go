@@ -4,6 +4,7 @@ package filecache
 
 import (
 	"context"
+	"net/http"
 
 	"go.uber.org/zap"
 
@@ -17,6 +18,21 @@ func NewClient(l *zap.Logger, endpoint string) *Client {
 	panic("implement me")
 }
 
+// SetHTTPClient задаёт http.Client, через который ходит клиент. По умолчанию используется http.DefaultClient.
+//
+// Через него клиенту передаются настройки TLS и авторизации, см. пакет auth.
+func (c *Client) SetHTTPClient(client *http.Client) {
+	panic("implement me")
+}
+
+// Upload заливает файл localPath в кеш под именем id.
+//
+// Файл режется на чанки через chunker.Chunker с chunker.DefaultConfig. Клиент спрашивает у сервера,
+// каких чанков не хватает, заливает только их и просит сервер собрать из чанков файл.
+// Поэтому после небольшой правки большого файла заливаются только изменившиеся чанки.
+//
+// Сервер собирает файл, только если id совпадает с sha1 его содержимого (Cache.Assemble). Файлы с другим id
+// клиент заливает целиком через PUT /file.
 func (c *Client) Upload(ctx context.Context, id build.ID, localPath string) error {
 	panic("implement me")
 }
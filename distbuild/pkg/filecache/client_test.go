@@ -3,11 +3,13 @@ package filecache_test
 import (
 	"bytes"
 	"context"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -127,3 +129,53 @@ func TestFileDownload(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []byte("foobar"), content)
 }
+
+func TestChunkedUpload(t *testing.T) {
+	l := zaptest.NewLogger(t)
+	cache := newCache(t)
+
+	mux := http.NewServeMux()
+	filecache.NewHandler(l, cache.Cache).Register(mux)
+
+	var uploaded int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > 0 {
+			atomic.AddInt64(&uploaded, r.ContentLength)
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := filecache.NewClient(l, server.URL)
+	ctx := context.Background()
+
+	content := make([]byte, 16<<20)
+	rand.New(rand.NewSource(1)).Read(content)
+
+	upload := func(id build.ID, content []byte) int64 {
+		path := filepath.Join(t.TempDir(), "generated.go")
+		require.NoError(t, os.WriteFile(path, content, 0666))
+
+		before := atomic.LoadInt64(&uploaded)
+		require.NoError(t, client.Upload(ctx, id, path))
+
+		stored, unlock, err := cache.Get(id)
+		require.NoError(t, err)
+		defer unlock()
+
+		actual, err := os.ReadFile(stored)
+		require.NoError(t, err)
+		require.Equal(t, content, actual)
+
+		return atomic.LoadInt64(&uploaded) - before
+	}
+
+	full := upload(build.ID{0x01}, content)
+	require.GreaterOrEqual(t, full, int64(len(content)))
+
+	edited := append([]byte{}, content[:len(content)/2]...)
+	edited = append(edited, []byte("// one more line\n")...)
+	edited = append(edited, content[len(content)/2:]...)
+
+	require.Less(t, upload(build.ID{0x02}, edited), full/4)
+}
@@ -1,10 +1,13 @@
 package filecache
 
 import (
+	"context"
 	"errors"
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"gitlab.com/slon/shad-go/distbuild/pkg/artifact"
 	"gitlab.com/slon/shad-go/distbuild/pkg/build"
@@ -36,22 +39,84 @@ func convertErr(err error) error {
 
 type Cache struct {
 	cache *artifact.Cache
+
+	// chunks хранит чанки файлов, залитых по частям. Подробности в chunks.go.
+	chunks *artifact.Cache
+
+	// remote - удалённое хранилище, см. SetRemote.
+	remote Remote
+
+	mu sync.Mutex
+	// fetching хранит файлы, которые сейчас скачиваются из remote. Их не нужно заливать обратно.
+	fetching map[build.ID]int
 }
 
+// remoteTimeout ограничивает одну операцию с удалённым хранилищем.
+const remoteTimeout = time.Minute
+
 func New(rootDir string) (*Cache, error) {
 	cache, err := artifact.NewCache(rootDir)
 	if err != nil {
 		return nil, err
 	}
 
-	c := &Cache{cache: cache}
+	chunks, err := artifact.NewCache(filepath.Join(rootDir, chunksDir))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cache{cache: cache, chunks: chunks, fetching: make(map[build.ID]int)}
 	return c, nil
 }
 
+// SetRemote подключает удалённое хранилище. Вызывается до начала работы с кешем.
+//
+// Get скачивает из remote файлы, которых нет в локальном кеше (read-through). Файл, записанный через
+// Write, после коммита заливается в remote (write-through). Файлы, скачанные из remote, обратно не заливаются.
+func (c *Cache) SetRemote(remote Remote) {
+	c.remote = remote
+}
+
 func (c *Cache) Range(fileFn func(file build.ID) error) error {
 	return c.cache.Range(fileFn)
 }
 
+// SetMaxSize ограничивает суммарный размер файлов и чанков в кеше. Подробности в artifact.Cache.SetMaxSize.
+//
+// Бюджет делится пополам между файлами и чанками, которые хранятся отдельно.
+func (c *Cache) SetMaxSize(maxSize int64) {
+	files, chunks := SplitSize(maxSize)
+	c.cache.SetMaxSize(files)
+	c.chunks.SetMaxSize(chunks)
+}
+
+// SplitSize делит бюджет maxSize на две части, которые в сумме дают maxSize.
+//
+// maxSize == 0 означает отсутствие ограничения и остаётся нулём в обеих частях. Ноль в части тоже снял бы
+// ограничение, поэтому бюджет в один байт превращается в две части по байту.
+func SplitSize(maxSize int64) (int64, int64) {
+	if maxSize == 0 {
+		return 0, 0
+	}
+
+	first := max(maxSize/2, 1)
+	return first, max(maxSize-first, 1)
+}
+
+func (c *Cache) Size() int64 {
+	return c.cache.Size()
+}
+
+// Stats возвращает статистику кеша. Подробности в artifact.Stats.
+func (c *Cache) Stats() artifact.Stats {
+	return c.cache.Stats()
+}
+
+// TakeEvicted возвращает файлы, вытесненные из кеша с прошлого вызова TakeEvicted.
+func (c *Cache) TakeEvicted() []build.ID {
+	return c.cache.TakeEvicted()
+}
+
 func (c *Cache) Remove(file build.ID) error {
 	return convertErr(c.cache.Remove(file))
 }
@@ -59,6 +124,9 @@ func (c *Cache) Remove(file build.ID) error {
 type fileWriter struct {
 	f      *os.File
 	commit func() error
+
+	// upload заливает закоммиченный файл в удалённое хранилище, если оно подключено.
+	upload func() error
 }
 
 func (f *fileWriter) Write(p []byte) (int, error) {
@@ -73,7 +141,12 @@ func (f *fileWriter) Close() error {
 		return closeErr
 	}
 
-	return commitErr
+	if commitErr != nil || f.upload == nil {
+		return commitErr
+	}
+
+	// Файл уже лежит в локальном кеше, ошибка означает, что его нет в удалённом хранилище.
+	return f.upload()
 }
 
 func (c *Cache) Write(file build.ID) (w io.WriteCloser, abort func() error, err error) {
@@ -85,11 +158,16 @@ func (c *Cache) Write(file build.ID) (w io.WriteCloser, abort func() error, err
 
 	f, err := os.Create(filepath.Join(path, fileName))
 	if err != nil {
-		_ = abort()
+		_ = abortDir()
 		return
 	}
 
-	w = &fileWriter{f: f, commit: commit}
+	fw := &fileWriter{f: f, commit: commit}
+	if c.remote != nil && !c.isFetching(file) {
+		fw.upload = func() error { return c.upload(file) }
+	}
+
+	w = fw
 	abort = func() error {
 		closeErr := f.Close()
 		abortErr := abortDir()
@@ -103,9 +181,63 @@ func (c *Cache) Write(file build.ID) (w io.WriteCloser, abort func() error, err
 	return
 }
 
+// Get возвращает путь к файлу. Если файла нет локально, Get скачивает его из удалённого хранилища.
 func (c *Cache) Get(file build.ID) (path string, unlock func(), err error) {
+	path, unlock, err = c.get(file)
+	if !errors.Is(err, ErrNotFound) || c.remote == nil {
+		return
+	}
+
+	if err = c.fetch(file); err != nil {
+		return "", nil, err
+	}
+
+	return c.get(file)
+}
+
+func (c *Cache) get(file build.ID) (path string, unlock func(), err error) {
 	root, unlock, err := c.cache.Get(file)
 	path = filepath.Join(root, fileName)
 	err = convertErr(err)
 	return
 }
+
+func (c *Cache) isFetching(file build.ID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.fetching[file] != 0
+}
+
+func (c *Cache) fetch(file build.ID) error {
+	c.mu.Lock()
+	c.fetching[file]++
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if c.fetching[file]--; c.fetching[file] == 0 {
+			delete(c.fetching, file)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+
+	return c.remote.Download(ctx, c, file)
+}
+
+func (c *Cache) upload(file build.ID) error {
+	path, unlock, err := c.get(file)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+
+	return c.remote.Upload(ctx, file, path)
+}
@@ -1,8 +1,11 @@
 package filecache_test
 
 import (
+	"context"
 	"errors"
+	"io"
 	"os"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -57,3 +60,103 @@ func TestFileCache(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []byte("foo bar"), content)
 }
+
+type fakeRemote struct {
+	mu      sync.Mutex
+	files   map[build.ID][]byte
+	uploads int
+}
+
+func (r *fakeRemote) Upload(ctx context.Context, id build.ID, localPath string) error {
+	content, err := os.ReadFile(localPath)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.files[id] = content
+	r.uploads++
+	return nil
+}
+
+func (r *fakeRemote) Download(ctx context.Context, localCache *filecache.Cache, id build.ID) error {
+	r.mu.Lock()
+	content, ok := r.files[id]
+	r.mu.Unlock()
+
+	if !ok {
+		return filecache.ErrNotFound
+	}
+
+	w, abort, err := localCache.Write(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(content); err != nil {
+		_ = abort()
+		return err
+	}
+	return w.Close()
+}
+
+func TestRemote(t *testing.T) {
+	remote := &fakeRemote{files: map[build.ID][]byte{{02}: []byte("remote")}}
+
+	cache := newCache(t)
+	cache.SetRemote(remote)
+
+	w, _, err := cache.Write(build.ID{01})
+	require.NoError(t, err)
+	_, err = io.WriteString(w, "local")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	require.Equal(t, []byte("local"), remote.files[build.ID{01}])
+	require.Equal(t, 1, remote.uploads)
+
+	path, unlock, err := cache.Get(build.ID{02})
+	require.NoError(t, err)
+	defer unlock()
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, []byte("remote"), content)
+
+	// Скачанный файл не заливается обратно.
+	require.Equal(t, 1, remote.uploads)
+
+	_, _, err = cache.Get(build.ID{03})
+	require.Truef(t, errors.Is(err, filecache.ErrNotFound), "%v", err)
+}
+
+func TestSplitSize(t *testing.T) {
+	for _, c := range []struct{ size, first, second int64 }{
+		{0, 0, 0},
+		{1, 1, 1},
+		{10, 5, 5},
+		{11, 5, 6},
+	} {
+		first, second := filecache.SplitSize(c.size)
+		require.Equal(t, [2]int64{c.first, c.second}, [2]int64{first, second}, "size %d", c.size)
+	}
+}
+
+func TestMaxSizeIncludesChunks(t *testing.T) {
+	cache := newCache(t)
+	cache.SetMaxSize(20)
+
+	// Файлам достаётся только половина бюджета, поэтому второй файл вытесняет первый.
+	for _, id := range []build.ID{{01}, {02}} {
+		w, _, err := cache.Write(id)
+		require.NoError(t, err)
+		_, err = w.Write(make([]byte, 8))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	}
+
+	require.Equal(t, []build.ID{{01}}, cache.TakeEvicted())
+	require.Equal(t, int64(8), cache.Size())
+}
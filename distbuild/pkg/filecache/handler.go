@@ -15,6 +15,9 @@ func NewHandler(l *zap.Logger, cache *Cache) *Handler {
 	panic("implement me")
 }
 
+// Register регистрирует обработчики /file, /file/chunks/missing, /file/chunk и /file/assemble.
+//
+// Протокол описан в README.md пакета.
 func (h *Handler) Register(mux *http.ServeMux) {
 	panic("implement me")
 }
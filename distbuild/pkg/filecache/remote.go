@@ -0,0 +1,18 @@
+package filecache
+
+import (
+	"context"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+// Remote описывает удалённое хранилище файлов.
+//
+// Кроме Client, этот интерфейс реализует remotecache.Client, который хранит файлы по ключам
+// /cas/<sha1>, как remotecache.Handler.
+type Remote interface {
+	Upload(ctx context.Context, id build.ID, localPath string) error
+	Download(ctx context.Context, localCache *Cache, id build.ID) error
+}
+
+var _ Remote = (*Client)(nil)
@@ -0,0 +1,512 @@
+package gograph
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+// Config описывает, для какого модуля и как строить граф.
+type Config struct {
+	// Dir задаёт директорию внутри модуля, из которой запускается go list.
+	Dir string
+
+	// Patterns задаёт пакеты, для которых нужно построить граф. По умолчанию ./...
+	Patterns []string
+
+	// Go задаёт путь до go на воркерах. По умолчанию go.
+	Go string
+
+	// GoVersion задаёт версию go на воркерах в формате go env GOVERSION. По умолчанию версия локального go.
+	//
+	// Версия входит в ID джоба std и через зависимости в ID всех остальных джобов, поэтому
+	// артефакты, собранные другим тулчейном, не берутся из кеша. Джоб std падает, если go на воркере другой версии.
+	GoVersion string
+
+	// Env задаёт переменные окружения для всех команд графа. По умолчанию PATH и HOME текущего процесса.
+	Env []string
+}
+
+// Generate строит граф сборки Go модуля.
+//
+// Для каждого пакета модуля граф содержит джоб компиляции, для каждого main пакета - джоб линковки,
+// а для пакетов из Patterns - джобы vet и test. Пути в SourceFiles и Inputs отсчитываются от корня модуля.
+//
+// Пакеты стандартной библиотеки не компилируются отдельными джобами. Вместо этого один джоб std
+// собирает export data всех нужных пакетов стандартной библиотеки тулчейном воркера.
+func Generate(ctx context.Context, config Config) (*build.Graph, error) {
+	if len(config.Patterns) == 0 {
+		config.Patterns = []string{"./..."}
+	}
+	if config.Go == "" {
+		config.Go = "go"
+	}
+	if config.Env == nil {
+		config.Env = []string{"PATH=" + os.Getenv("PATH"), "HOME=" + os.Getenv("HOME")}
+	}
+
+	if config.GoVersion == "" {
+		version, err := goVersion(ctx, "go", config.Dir)
+		if err != nil {
+			return nil, err
+		}
+		config.GoVersion = version
+	}
+
+	pkgs, err := goList(ctx, "go", config.Dir, config.Patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		config:   config,
+		graph:    &build.Graph{SourceFiles: map[build.ID]string{}},
+		pkgs:     map[string]*listPackage{},
+		compiled: map[string]build.ID{},
+		files:    map[string]build.ID{},
+	}
+	if err := g.generate(pkgs); err != nil {
+		return nil, err
+	}
+	return g.graph, nil
+}
+
+type generator struct {
+	config Config
+	graph  *build.Graph
+
+	module *listModule
+
+	// pkgs содержит пакеты главного модуля по import path.
+	pkgs map[string]*listPackage
+
+	// compiled содержит ID джобов компиляции пакетов главного модуля.
+	compiled map[string]build.ID
+
+	// files содержит хеши содержимого файлов из SourceFiles по их пути.
+	files map[string]build.ID
+
+	stdID build.ID
+}
+
+func (g *generator) generate(pkgs []*listPackage) error {
+	var std []string
+	for _, p := range pkgs {
+		switch {
+		case p.Standard:
+			std = append(std, p.ImportPath)
+		case p.inMainModule():
+			if g.module == nil {
+				g.module = p.Module
+			}
+			g.pkgs[p.ImportPath] = p
+		default:
+			return fmt.Errorf("package %s: packages outside of the main module are not supported", p.ImportPath)
+		}
+	}
+
+	if g.module == nil {
+		return fmt.Errorf("no packages of the main module match %q", g.config.Patterns)
+	}
+
+	if err := g.addModuleFiles(); err != nil {
+		return err
+	}
+
+	g.stdID = g.addJob(g.stdJob(std))
+
+	for _, p := range pkgs {
+		if !p.inMainModule() {
+			continue
+		}
+
+		if err := g.addCompileJob(p); err != nil {
+			return err
+		}
+
+		if p.DepOnly {
+			continue
+		}
+
+		if p.Name == "main" {
+			g.addLinkJob(p)
+		}
+
+		if err := g.addCheckJobs(p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// relPath возвращает путь файла из директории пакета относительно корня модуля.
+func (g *generator) relPath(p *listPackage, name string) (string, error) {
+	rel, err := filepath.Rel(g.module.Dir, filepath.Join(p.Dir, name))
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// addFile добавляет файл в SourceFiles.
+//
+// ID файла совпадает с sha1 его содержимого. Если в модуле есть другой файл с таким же содержимым,
+// к хешу дополнительно подмешивается путь файла.
+func (g *generator) addFile(rel string) error {
+	if _, ok := g.files[rel]; ok {
+		return nil
+	}
+
+	content, err := os.ReadFile(filepath.Join(g.module.Dir, filepath.FromSlash(rel)))
+	if err != nil {
+		return err
+	}
+
+	id := build.ID(sha1.Sum(content))
+	if _, ok := g.graph.SourceFiles[id]; ok {
+		id = build.ID(sha1.Sum(append([]byte(rel+"\x00"), content...)))
+	}
+
+	g.files[rel] = id
+	g.graph.SourceFiles[id] = rel
+	return nil
+}
+
+func (g *generator) addModuleFiles() error {
+	for _, name := range []string{"go.mod", "go.sum"} {
+		if _, err := os.Stat(filepath.Join(g.module.Dir, name)); err != nil {
+			continue
+		}
+
+		if err := g.addFile(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// packageFiles возвращает файлы пакета относительно корня модуля.
+func (g *generator) packageFiles(p *listPackage, withTests bool) ([]string, error) {
+	names := append([]string{}, p.GoFiles...)
+	if withTests {
+		names = append(names, p.TestGoFiles...)
+		names = append(names, p.XTestGoFiles...)
+	}
+
+	var files []string
+	for _, name := range names {
+		rel, err := g.relPath(p, name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, rel)
+	}
+
+	if withTests {
+		// Тесты обычно читают файлы из testdata, о которых go list ничего не знает.
+		testdata := filepath.Join(p.Dir, "testdata")
+		err := filepath.WalkDir(testdata, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+
+			rel, err := filepath.Rel(g.module.Dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	for _, f := range files {
+		if err := g.addFile(f); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// addJob вычисляет ID джоба и добавляет его в граф.
+//
+// ID вычисляется как хеш от описания джоба, содержимого входных файлов и ID зависимостей.
+func (g *generator) addJob(job build.Job) build.ID {
+	h := sha1.New()
+
+	job.ID = build.ID{}
+	if err := json.NewEncoder(h).Encode(job); err != nil {
+		panic(err)
+	}
+
+	for _, in := range job.Inputs {
+		_, _ = fmt.Fprintf(h, "%s %s\n", in, g.files[in])
+	}
+
+	copy(job.ID[:], h.Sum(nil))
+	g.graph.Jobs = append(g.graph.Jobs, job)
+	return job.ID
+}
+
+func depDir(id build.ID) string {
+	return fmt.Sprintf("{{index .Deps %q}}", id)
+}
+
+// escapeTemplate экранирует строку так, чтобы build.Cmd.Render оставил её без изменений.
+func escapeTemplate(s string) string {
+	return strings.NewReplacer("{{", `{{"{{"}}`, "}}", `{{"}}"}}`).Replace(s)
+}
+
+// stdScript копирует export data пакетов стандартной библиотеки в выходную директорию и
+// пишет importcfg с путями относительно неё. Перед этим скрипт проверяет, что версия go на воркере
+// совпадает с версией, для которой построен граф.
+const stdScript = `set -e
+go=$0; version=$1; out=$2; shift 2
+actual=$("$go" env GOVERSION)
+if [ "$actual" != "$version" ]; then
+	echo "graph was generated for $version, but worker has $actual" >&2
+	exit 1
+fi
+"$go" list -export -deps -f '{{if .Export}}{{.ImportPath}} {{.Export}}{{end}}' -- "$@" | while read -r pkg export; do
+	[ -n "$export" ] || continue
+	mkdir -p "$out/$(dirname "$pkg")"
+	cp "$export" "$out/$pkg.a"
+	echo "packagefile $pkg=$pkg.a"
+done > "$out/importcfg"
+`
+
+func (g *generator) stdJob(std []string) build.Job {
+	sort.Strings(std)
+
+	return build.Job{
+		Name: "std",
+		Cmds: []build.Cmd{
+			{
+				Exec:    append([]string{"sh", "-c", escapeTemplate(stdScript), g.config.Go, g.config.GoVersion, "{{.OutputDir}}"}, std...),
+				Environ: g.config.Env,
+			},
+		},
+	}
+}
+
+// importcfgCmds собирает в {{.OutputDir}}/importcfg пути до export data пакетов модуля и стандартной библиотеки.
+//
+// Пути до пакетов стандартной библиотеки в importcfg относительные, поэтому команды, читающие importcfg,
+// должны запускаться в директории артефакта std.
+func (g *generator) importcfgCmds(imports []string) []build.Cmd {
+	var cfg strings.Builder
+	for _, imp := range imports {
+		fmt.Fprintf(&cfg, "packagefile %s=%s/lib.a\n", imp, depDir(g.compiled[imp]))
+	}
+
+	return []build.Cmd{
+		{
+			CatTemplate: cfg.String(),
+			CatOutput:   "{{.OutputDir}}/importcfg.mod",
+		},
+		{
+			Exec:             []string{"sh", "-c", `cat importcfg "$0" > "$1"`, "{{.OutputDir}}/importcfg.mod", "{{.OutputDir}}/importcfg"},
+			Environ:          g.config.Env,
+			WorkingDirectory: depDir(g.stdID),
+		},
+	}
+}
+
+// moduleImports возвращает пакеты главного модуля среди imports.
+func (g *generator) moduleImports(imports []string) []string {
+	var result []string
+	for _, imp := range imports {
+		if _, ok := g.pkgs[imp]; ok {
+			result = append(result, imp)
+		}
+	}
+	return result
+}
+
+// closure возвращает пакеты модуля, от которых транзитивно зависят roots, включая сами roots.
+func (g *generator) closure(roots []string) []string {
+	visited := map[string]bool{}
+
+	var visit func(imp string)
+	visit = func(imp string) {
+		if visited[imp] {
+			return
+		}
+		visited[imp] = true
+
+		for _, dep := range g.moduleImports(g.pkgs[imp].Imports) {
+			visit(dep)
+		}
+	}
+
+	for _, root := range g.moduleImports(roots) {
+		visit(root)
+	}
+
+	var result []string
+	for imp := range visited {
+		result = append(result, imp)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// langVersion превращает версию из директивы go в go.mod в значение флага -lang.
+func langVersion(goVersion string) string {
+	parts := strings.Split(goVersion, ".")
+	if len(parts) < 2 {
+		return ""
+	}
+	return "go" + parts[0] + "." + parts[1]
+}
+
+func (g *generator) addCompileJob(p *listPackage) error {
+	if len(p.CgoFiles)+len(p.SFiles)+len(p.EmbedFiles) != 0 {
+		return fmt.Errorf("package %s: cgo, assembly and embedded files are not supported", p.ImportPath)
+	}
+
+	inputs, err := g.packageFiles(p, false)
+	if err != nil {
+		return err
+	}
+
+	imports := g.moduleImports(p.Imports)
+
+	pkgPath := p.ImportPath
+	if p.Name == "main" {
+		pkgPath = "main"
+	}
+
+	compile := []string{
+		g.config.Go, "tool", "compile",
+		"-o", "{{.OutputDir}}/lib.a",
+		"-p", pkgPath,
+		"-pack", "-complete",
+		"-importcfg", "{{.OutputDir}}/importcfg",
+		"-trimpath", "{{.SourceDir}}",
+	}
+	if lang := langVersion(p.Module.GoVersion); lang != "" {
+		compile = append(compile, "-lang="+lang)
+	}
+	for _, in := range inputs {
+		compile = append(compile, "{{.SourceDir}}/"+in)
+	}
+
+	job := build.Job{
+		Name:   "build " + p.ImportPath,
+		Inputs: inputs,
+		Deps:   []build.ID{g.stdID},
+		Cmds: append(g.importcfgCmds(imports), build.Cmd{
+			Exec:             compile,
+			Environ:          g.config.Env,
+			WorkingDirectory: depDir(g.stdID),
+		}),
+	}
+	for _, imp := range imports {
+		job.Deps = append(job.Deps, g.compiled[imp])
+	}
+
+	g.compiled[p.ImportPath] = g.addJob(job)
+	return nil
+}
+
+func (g *generator) addLinkJob(p *listPackage) {
+	deps := g.closure(p.Imports)
+
+	job := build.Job{
+		Name: "link " + p.ImportPath,
+		Deps: []build.ID{g.stdID, g.compiled[p.ImportPath]},
+		Cmds: append(g.importcfgCmds(deps), build.Cmd{
+			Exec: []string{
+				g.config.Go, "tool", "link",
+				"-o", "{{.OutputDir}}/" + path.Base(p.ImportPath),
+				"-importcfg", "{{.OutputDir}}/importcfg",
+				"-buildmode=exe",
+				depDir(g.compiled[p.ImportPath]) + "/lib.a",
+			},
+			Environ:          g.config.Env,
+			WorkingDirectory: depDir(g.stdID),
+		}),
+	}
+	for _, imp := range deps {
+		job.Deps = append(job.Deps, g.compiled[imp])
+	}
+
+	g.addJob(job)
+}
+
+// addCheckJobs добавляет джобы vet и test. Они запускают go в копии модуля, поэтому их входами
+// являются go.mod и все пакеты модуля, которые видит go vet или go test. Артефакт std им не нужен,
+// но они зависят от std, чтобы их ID менялись вместе с версией go.
+func (g *generator) addCheckJobs(p *listPackage) error {
+	roots := append([]string{p.ImportPath}, p.TestImports...)
+	roots = append(roots, p.XTestImports...)
+
+	var inputs []string
+	for _, name := range []string{"go.mod", "go.sum"} {
+		if _, ok := g.files[name]; ok {
+			inputs = append(inputs, name)
+		}
+	}
+
+	for _, imp := range g.closure(roots) {
+		files, err := g.packageFiles(g.pkgs[imp], imp == p.ImportPath)
+		if err != nil {
+			return err
+		}
+		inputs = append(inputs, files...)
+	}
+
+	rel, err := g.relPath(p, ".")
+	if err != nil {
+		return err
+	}
+
+	target := "./" + rel
+	if rel == "." {
+		target = "."
+	}
+
+	g.addJob(build.Job{
+		Name:   "vet " + p.ImportPath,
+		Deps:   []build.ID{g.stdID},
+		Inputs: inputs,
+		Cmds: []build.Cmd{
+			{
+				Exec:             []string{g.config.Go, "vet", target},
+				Environ:          g.config.Env,
+				WorkingDirectory: "{{.SourceDir}}",
+			},
+		},
+	})
+
+	if !p.hasTests() {
+		return nil
+	}
+
+	g.addJob(build.Job{
+		Name:   "test " + p.ImportPath,
+		Deps:   []build.ID{g.stdID},
+		Inputs: inputs,
+		Cmds: []build.Cmd{
+			{
+				Exec:             []string{g.config.Go, "test", "-count=1", target},
+				Environ:          g.config.Env,
+				WorkingDirectory: "{{.SourceDir}}",
+			},
+		},
+	})
+	return nil
+}
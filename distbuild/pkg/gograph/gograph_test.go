@@ -0,0 +1,205 @@
+package gograph_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/gograph"
+)
+
+func copyDir(t *testing.T, from, to string) {
+	err := filepath.Walk(from, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(to, rel), 0777)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(to, rel), content, 0666)
+	})
+	require.NoError(t, err)
+}
+
+func generate(t *testing.T, dir string) *build.Graph {
+	graph, err := gograph.Generate(context.Background(), gograph.Config{Dir: dir})
+	require.NoError(t, err)
+	require.NoError(t, build.Validate(graph))
+	return graph
+}
+
+func jobsByName(graph *build.Graph) map[string]build.Job {
+	jobs := map[string]build.Job{}
+	for _, job := range graph.Jobs {
+		jobs[job.Name] = job
+	}
+	return jobs
+}
+
+func TestGenerate(t *testing.T) {
+	graph := generate(t, "testdata/mod")
+
+	jobs := jobsByName(graph)
+
+	var names []string
+	for name := range jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	require.Equal(t, []string{
+		"build example.com/mod/cmd/hello",
+		"build example.com/mod/greet",
+		"link example.com/mod/cmd/hello",
+		"std",
+		"test example.com/mod/greet",
+		"vet example.com/mod/cmd/hello",
+		"vet example.com/mod/greet",
+	}, names)
+
+	var files []string
+	for _, path := range graph.SourceFiles {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	require.Equal(t, []string{
+		"cmd/hello/main.go",
+		"go.mod",
+		"greet/greet.go",
+		"greet/greet_test.go",
+		"greet/testdata/hello.txt",
+	}, files)
+
+	ids := map[build.ID]bool{}
+	for _, job := range graph.Jobs {
+		ids[job.ID] = true
+	}
+	require.Len(t, ids, len(graph.Jobs))
+
+	for _, job := range graph.Jobs {
+		for _, dep := range job.Deps {
+			require.True(t, ids[dep], "%s depends on unknown job %s", job.Name, dep)
+		}
+
+		for _, in := range job.Inputs {
+			require.Contains(t, files, in, "%s", job.Name)
+		}
+	}
+
+	std := jobs["std"].ID
+	greet := jobs["build example.com/mod/greet"].ID
+	hello := jobs["build example.com/mod/cmd/hello"].ID
+
+	require.Equal(t, []build.ID{std}, jobs["build example.com/mod/greet"].Deps)
+	require.Equal(t, []build.ID{std, greet}, jobs["build example.com/mod/cmd/hello"].Deps)
+	require.Equal(t, []build.ID{std, hello, greet}, jobs["link example.com/mod/cmd/hello"].Deps)
+	require.Equal(t, []string{"cmd/hello/main.go"}, jobs["build example.com/mod/cmd/hello"].Inputs)
+	require.Equal(t, []string{"go.mod", "cmd/hello/main.go", "greet/greet.go"}, jobs["vet example.com/mod/cmd/hello"].Inputs)
+
+	require.Equal(t, graph, generate(t, "testdata/mod"))
+}
+
+func TestGenerateIDs(t *testing.T) {
+	dir := t.TempDir()
+	copyDir(t, "testdata/mod", dir)
+
+	before := jobsByName(generate(t, dir))
+
+	main := filepath.Join(dir, "cmd", "hello", "main.go")
+	content, err := os.ReadFile(main)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(main, append(content, "\n// changed\n"...), 0666))
+
+	after := jobsByName(generate(t, dir))
+
+	for _, name := range []string{"std", "build example.com/mod/greet", "test example.com/mod/greet", "vet example.com/mod/greet"} {
+		require.Equal(t, before[name].ID, after[name].ID, name)
+	}
+
+	for _, name := range []string{"build example.com/mod/cmd/hello", "link example.com/mod/cmd/hello", "vet example.com/mod/cmd/hello"} {
+		require.NotEqual(t, before[name].ID, after[name].ID, name)
+	}
+}
+
+func TestGenerateIDsDependOnGoVersion(t *testing.T) {
+	before := jobsByName(generate(t, "testdata/mod"))
+
+	graph, err := gograph.Generate(context.Background(), gograph.Config{Dir: "testdata/mod", GoVersion: "go1.0"})
+	require.NoError(t, err)
+	after := jobsByName(graph)
+
+	require.Len(t, after, len(before))
+	for name, job := range before {
+		require.NotEqual(t, job.ID, after[name].ID, name)
+	}
+}
+
+// runGraph выполняет граф локально, так же как это делали бы воркеры.
+func runGraph(t *testing.T, sourceDir string, graph *build.Graph) map[build.ID]string {
+	outputs := map[build.ID]string{}
+
+	for _, job := range build.TopSort(graph.Jobs) {
+		jobCtx := build.JobContext{
+			SourceDir: sourceDir,
+			OutputDir: t.TempDir(),
+			Deps:      map[build.ID]string{},
+		}
+		for _, dep := range job.Deps {
+			jobCtx.Deps[dep] = outputs[dep]
+		}
+
+		for _, cmd := range job.Cmds {
+			rendered, err := cmd.Render(jobCtx)
+			require.NoError(t, err)
+
+			if rendered.CatOutput != "" {
+				require.NoError(t, os.WriteFile(rendered.CatOutput, []byte(rendered.CatTemplate), 0666))
+				continue
+			}
+
+			c := exec.Command(rendered.Exec[0], rendered.Exec[1:]...)
+			c.Env = rendered.Environ
+			c.Dir = rendered.WorkingDirectory
+			out, err := c.CombinedOutput()
+			require.NoError(t, err, "%s: %s", job.Name, out)
+		}
+
+		outputs[job.ID] = jobCtx.OutputDir
+	}
+
+	return outputs
+}
+
+func TestGeneratedGraphBuilds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles the standard library")
+	}
+
+	sourceDir, err := filepath.Abs("testdata/mod")
+	require.NoError(t, err)
+
+	graph := generate(t, sourceDir)
+	outputs := runGraph(t, sourceDir, graph)
+
+	link := jobsByName(graph)["link example.com/mod/cmd/hello"]
+	out, err := exec.Command(filepath.Join(outputs[link.ID], "hello")).Output()
+	require.NoError(t, err)
+	require.Equal(t, "Hello, DISTBUILD!\n", string(out))
+}
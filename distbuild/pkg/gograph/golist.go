@@ -0,0 +1,102 @@
+package gograph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+type listModule struct {
+	Path      string
+	Dir       string
+	GoMod     string
+	GoVersion string
+	Main      bool
+}
+
+type listError struct {
+	Err string
+}
+
+// listPackage содержит поля из вывода go list -json, которые нужны генератору.
+type listPackage struct {
+	Dir        string
+	ImportPath string
+	Name       string
+	Standard   bool
+	DepOnly    bool
+	Module     *listModule
+
+	GoFiles      []string
+	CgoFiles     []string
+	SFiles       []string
+	EmbedFiles   []string
+	TestGoFiles  []string
+	XTestGoFiles []string
+
+	Imports      []string
+	TestImports  []string
+	XTestImports []string
+
+	Error *listError
+}
+
+func (p *listPackage) inMainModule() bool {
+	return p.Module != nil && p.Module.Main
+}
+
+func (p *listPackage) hasTests() bool {
+	return len(p.TestGoFiles)+len(p.XTestGoFiles) != 0
+}
+
+// goVersion возвращает вывод go env GOVERSION.
+func goVersion(ctx context.Context, goBinary, dir string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, goBinary, "env", "GOVERSION")
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("go env: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return string(bytes.TrimSpace(stdout.Bytes())), nil
+}
+
+// goList возвращает пакеты из вывода go list -deps -json в порядке вывода.
+//
+// go list выводит зависимости раньше пакетов, которые от них зависят.
+func goList(ctx context.Context, goBinary, dir string, patterns []string) ([]*listPackage, error) {
+	args := append([]string{"list", "-deps", "-json", "--"}, patterns...)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, goBinary, args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go list: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	var pkgs []*listPackage
+	dec := json.NewDecoder(&stdout)
+	for {
+		var p listPackage
+		if err := dec.Decode(&p); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("go list: %w", err)
+		}
+
+		if p.Error != nil {
+			return nil, fmt.Errorf("go list: package %s: %s", p.ImportPath, p.Error.Err)
+		}
+		pkgs = append(pkgs, &p)
+	}
+	return pkgs, nil
+}
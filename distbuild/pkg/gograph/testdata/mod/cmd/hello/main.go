@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"example.com/mod/greet"
+)
+
+func main() {
+	fmt.Println(greet.Hello("distbuild"))
+}
@@ -0,0 +1,7 @@
+package greet
+
+import "strings"
+
+func Hello(name string) string {
+	return "Hello, " + strings.ToUpper(name) + "!"
+}
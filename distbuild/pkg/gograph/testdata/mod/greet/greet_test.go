@@ -0,0 +1,18 @@
+package greet
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHello(t *testing.T) {
+	want, err := os.ReadFile("testdata/hello.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := Hello("gopher"); got != strings.TrimSpace(string(want)) {
+		t.Errorf("Hello() = %q, want %q", got, want)
+	}
+}
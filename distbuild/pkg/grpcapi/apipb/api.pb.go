@@ -0,0 +1,2237 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: apipb/api.proto
+
+package apipb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Resources struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cpu           int64                  `protobuf:"varint,1,opt,name=cpu,proto3" json:"cpu,omitempty"`
+	Memory        int64                  `protobuf:"varint,2,opt,name=memory,proto3" json:"memory,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Resources) Reset() {
+	*x = Resources{}
+	mi := &file_apipb_api_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Resources) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Resources) ProtoMessage() {}
+
+func (x *Resources) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Resources.ProtoReflect.Descriptor instead.
+func (*Resources) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Resources) GetCpu() int64 {
+	if x != nil {
+		return x.Cpu
+	}
+	return 0
+}
+
+func (x *Resources) GetMemory() int64 {
+	if x != nil {
+		return x.Memory
+	}
+	return 0
+}
+
+type Requirements struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Resources     *Resources             `protobuf:"bytes,1,opt,name=resources,proto3" json:"resources,omitempty"`
+	Labels        []string               `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Requirements) Reset() {
+	*x = Requirements{}
+	mi := &file_apipb_api_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Requirements) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Requirements) ProtoMessage() {}
+
+func (x *Requirements) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Requirements.ProtoReflect.Descriptor instead.
+func (*Requirements) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Requirements) GetResources() *Resources {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+func (x *Requirements) GetLabels() []string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+type RetryPolicy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MaxAttempts   int64                  `protobuf:"varint,1,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"`
+	ExitCodes     []int64                `protobuf:"varint,2,rep,packed,name=exit_codes,json=exitCodes,proto3" json:"exit_codes,omitempty"`
+	Errors        []string               `protobuf:"bytes,3,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetryPolicy) Reset() {
+	*x = RetryPolicy{}
+	mi := &file_apipb_api_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetryPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetryPolicy) ProtoMessage() {}
+
+func (x *RetryPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetryPolicy.ProtoReflect.Descriptor instead.
+func (*RetryPolicy) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RetryPolicy) GetMaxAttempts() int64 {
+	if x != nil {
+		return x.MaxAttempts
+	}
+	return 0
+}
+
+func (x *RetryPolicy) GetExitCodes() []int64 {
+	if x != nil {
+		return x.ExitCodes
+	}
+	return nil
+}
+
+func (x *RetryPolicy) GetErrors() []string {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+type Cmd struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Exec             []string               `protobuf:"bytes,1,rep,name=exec,proto3" json:"exec,omitempty"`
+	Environ          []string               `protobuf:"bytes,2,rep,name=environ,proto3" json:"environ,omitempty"`
+	WorkingDirectory string                 `protobuf:"bytes,3,opt,name=working_directory,json=workingDirectory,proto3" json:"working_directory,omitempty"`
+	CatTemplate      string                 `protobuf:"bytes,4,opt,name=cat_template,json=catTemplate,proto3" json:"cat_template,omitempty"`
+	CatOutput        string                 `protobuf:"bytes,5,opt,name=cat_output,json=catOutput,proto3" json:"cat_output,omitempty"`
+	CopySource       string                 `protobuf:"bytes,6,opt,name=copy_source,json=copySource,proto3" json:"copy_source,omitempty"`
+	CopyOutput       string                 `protobuf:"bytes,7,opt,name=copy_output,json=copyOutput,proto3" json:"copy_output,omitempty"`
+	SymlinkTarget    string                 `protobuf:"bytes,8,opt,name=symlink_target,json=symlinkTarget,proto3" json:"symlink_target,omitempty"`
+	SymlinkOutput    string                 `protobuf:"bytes,9,opt,name=symlink_output,json=symlinkOutput,proto3" json:"symlink_output,omitempty"`
+	MkdirOutput      string                 `protobuf:"bytes,10,opt,name=mkdir_output,json=mkdirOutput,proto3" json:"mkdir_output,omitempty"`
+	EnvFileOutput    string                 `protobuf:"bytes,11,opt,name=env_file_output,json=envFileOutput,proto3" json:"env_file_output,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Cmd) Reset() {
+	*x = Cmd{}
+	mi := &file_apipb_api_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Cmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Cmd) ProtoMessage() {}
+
+func (x *Cmd) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Cmd.ProtoReflect.Descriptor instead.
+func (*Cmd) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Cmd) GetExec() []string {
+	if x != nil {
+		return x.Exec
+	}
+	return nil
+}
+
+func (x *Cmd) GetEnviron() []string {
+	if x != nil {
+		return x.Environ
+	}
+	return nil
+}
+
+func (x *Cmd) GetWorkingDirectory() string {
+	if x != nil {
+		return x.WorkingDirectory
+	}
+	return ""
+}
+
+func (x *Cmd) GetCatTemplate() string {
+	if x != nil {
+		return x.CatTemplate
+	}
+	return ""
+}
+
+func (x *Cmd) GetCatOutput() string {
+	if x != nil {
+		return x.CatOutput
+	}
+	return ""
+}
+
+func (x *Cmd) GetCopySource() string {
+	if x != nil {
+		return x.CopySource
+	}
+	return ""
+}
+
+func (x *Cmd) GetCopyOutput() string {
+	if x != nil {
+		return x.CopyOutput
+	}
+	return ""
+}
+
+func (x *Cmd) GetSymlinkTarget() string {
+	if x != nil {
+		return x.SymlinkTarget
+	}
+	return ""
+}
+
+func (x *Cmd) GetSymlinkOutput() string {
+	if x != nil {
+		return x.SymlinkOutput
+	}
+	return ""
+}
+
+func (x *Cmd) GetMkdirOutput() string {
+	if x != nil {
+		return x.MkdirOutput
+	}
+	return ""
+}
+
+func (x *Cmd) GetEnvFileOutput() string {
+	if x != nil {
+		return x.EnvFileOutput
+	}
+	return ""
+}
+
+type Job struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            []byte                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Inputs        []string               `protobuf:"bytes,3,rep,name=inputs,proto3" json:"inputs,omitempty"`
+	Deps          [][]byte               `protobuf:"bytes,4,rep,name=deps,proto3" json:"deps,omitempty"`
+	Cmds          []*Cmd                 `protobuf:"bytes,5,rep,name=cmds,proto3" json:"cmds,omitempty"`
+	Requirements  *Requirements          `protobuf:"bytes,6,opt,name=requirements,proto3" json:"requirements,omitempty"`
+	NoCache       bool                   `protobuf:"varint,7,opt,name=no_cache,json=noCache,proto3" json:"no_cache,omitempty"`
+	Retry         *RetryPolicy           `protobuf:"bytes,8,opt,name=retry,proto3" json:"retry,omitempty"`
+	Output        string                 `protobuf:"bytes,9,opt,name=output,proto3" json:"output,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_apipb_api_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Job) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *Job) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Job) GetInputs() []string {
+	if x != nil {
+		return x.Inputs
+	}
+	return nil
+}
+
+func (x *Job) GetDeps() [][]byte {
+	if x != nil {
+		return x.Deps
+	}
+	return nil
+}
+
+func (x *Job) GetCmds() []*Cmd {
+	if x != nil {
+		return x.Cmds
+	}
+	return nil
+}
+
+func (x *Job) GetRequirements() *Requirements {
+	if x != nil {
+		return x.Requirements
+	}
+	return nil
+}
+
+func (x *Job) GetNoCache() bool {
+	if x != nil {
+		return x.NoCache
+	}
+	return false
+}
+
+func (x *Job) GetRetry() *RetryPolicy {
+	if x != nil {
+		return x.Retry
+	}
+	return nil
+}
+
+func (x *Job) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+// SourceFile - элемент build.Graph.SourceFiles и api.JobSpec.SourceFiles.
+type SourceFile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            []byte                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SourceFile) Reset() {
+	*x = SourceFile{}
+	mi := &file_apipb_api_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SourceFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SourceFile) ProtoMessage() {}
+
+func (x *SourceFile) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SourceFile.ProtoReflect.Descriptor instead.
+func (*SourceFile) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SourceFile) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *SourceFile) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type Graph struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SourceFiles   []*SourceFile          `protobuf:"bytes,1,rep,name=source_files,json=sourceFiles,proto3" json:"source_files,omitempty"`
+	Jobs          []*Job                 `protobuf:"bytes,2,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Graph) Reset() {
+	*x = Graph{}
+	mi := &file_apipb_api_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Graph) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Graph) ProtoMessage() {}
+
+func (x *Graph) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Graph.ProtoReflect.Descriptor instead.
+func (*Graph) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Graph) GetSourceFiles() []*SourceFile {
+	if x != nil {
+		return x.SourceFiles
+	}
+	return nil
+}
+
+func (x *Graph) GetJobs() []*Job {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+type BuildRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Graph         *Graph                 `protobuf:"bytes,1,opt,name=graph,proto3" json:"graph,omitempty"`
+	Verify        bool                   `protobuf:"varint,2,opt,name=verify,proto3" json:"verify,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BuildRequest) Reset() {
+	*x = BuildRequest{}
+	mi := &file_apipb_api_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BuildRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildRequest) ProtoMessage() {}
+
+func (x *BuildRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildRequest.ProtoReflect.Descriptor instead.
+func (*BuildRequest) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BuildRequest) GetGraph() *Graph {
+	if x != nil {
+		return x.Graph
+	}
+	return nil
+}
+
+func (x *BuildRequest) GetVerify() bool {
+	if x != nil {
+		return x.Verify
+	}
+	return false
+}
+
+type BuildStarted struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            []byte                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	MissingFiles  [][]byte               `protobuf:"bytes,2,rep,name=missing_files,json=missingFiles,proto3" json:"missing_files,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BuildStarted) Reset() {
+	*x = BuildStarted{}
+	mi := &file_apipb_api_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BuildStarted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildStarted) ProtoMessage() {}
+
+func (x *BuildStarted) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildStarted.ProtoReflect.Descriptor instead.
+func (*BuildStarted) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *BuildStarted) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *BuildStarted) GetMissingFiles() [][]byte {
+	if x != nil {
+		return x.MissingFiles
+	}
+	return nil
+}
+
+type JobOutput struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            []byte                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Stdout        []byte                 `protobuf:"bytes,2,opt,name=stdout,proto3" json:"stdout,omitempty"`
+	Stderr        []byte                 `protobuf:"bytes,3,opt,name=stderr,proto3" json:"stderr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobOutput) Reset() {
+	*x = JobOutput{}
+	mi := &file_apipb_api_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobOutput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobOutput) ProtoMessage() {}
+
+func (x *JobOutput) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobOutput.ProtoReflect.Descriptor instead.
+func (*JobOutput) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *JobOutput) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *JobOutput) GetStdout() []byte {
+	if x != nil {
+		return x.Stdout
+	}
+	return nil
+}
+
+func (x *JobOutput) GetStderr() []byte {
+	if x != nil {
+		return x.Stderr
+	}
+	return nil
+}
+
+type CmdTrace struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CmdTrace) Reset() {
+	*x = CmdTrace{}
+	mi := &file_apipb_api_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CmdTrace) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CmdTrace) ProtoMessage() {}
+
+func (x *CmdTrace) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CmdTrace.ProtoReflect.Descriptor instead.
+func (*CmdTrace) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CmdTrace) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *CmdTrace) GetEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.End
+	}
+	return nil
+}
+
+type JobTrace struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	WorkerId       string                 `protobuf:"bytes,1,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
+	Slot           int64                  `protobuf:"varint,2,opt,name=slot,proto3" json:"slot,omitempty"`
+	Scheduled      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=scheduled,proto3" json:"scheduled,omitempty"`
+	Picked         *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=picked,proto3" json:"picked,omitempty"`
+	DepsDownloaded *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=deps_downloaded,json=depsDownloaded,proto3" json:"deps_downloaded,omitempty"`
+	Cmds           []*CmdTrace            `protobuf:"bytes,6,rep,name=cmds,proto3" json:"cmds,omitempty"`
+	Committed      *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=committed,proto3" json:"committed,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *JobTrace) Reset() {
+	*x = JobTrace{}
+	mi := &file_apipb_api_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobTrace) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobTrace) ProtoMessage() {}
+
+func (x *JobTrace) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobTrace.ProtoReflect.Descriptor instead.
+func (*JobTrace) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *JobTrace) GetWorkerId() string {
+	if x != nil {
+		return x.WorkerId
+	}
+	return ""
+}
+
+func (x *JobTrace) GetSlot() int64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *JobTrace) GetScheduled() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Scheduled
+	}
+	return nil
+}
+
+func (x *JobTrace) GetPicked() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Picked
+	}
+	return nil
+}
+
+func (x *JobTrace) GetDepsDownloaded() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DepsDownloaded
+	}
+	return nil
+}
+
+func (x *JobTrace) GetCmds() []*CmdTrace {
+	if x != nil {
+		return x.Cmds
+	}
+	return nil
+}
+
+func (x *JobTrace) GetCommitted() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Committed
+	}
+	return nil
+}
+
+type JobResult struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       []byte                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Stdout   []byte                 `protobuf:"bytes,2,opt,name=stdout,proto3" json:"stdout,omitempty"`
+	Stderr   []byte                 `protobuf:"bytes,3,opt,name=stderr,proto3" json:"stderr,omitempty"`
+	ExitCode int64                  `protobuf:"varint,4,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	Error    *string                `protobuf:"bytes,5,opt,name=error,proto3,oneof" json:"error,omitempty"`
+	Cached   bool                   `protobuf:"varint,6,opt,name=cached,proto3" json:"cached,omitempty"`
+	Trace    *JobTrace              `protobuf:"bytes,7,opt,name=trace,proto3" json:"trace,omitempty"`
+	// Пустой digest соответствует nil.
+	Digest        []byte `protobuf:"bytes,8,opt,name=digest,proto3" json:"digest,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobResult) Reset() {
+	*x = JobResult{}
+	mi := &file_apipb_api_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobResult) ProtoMessage() {}
+
+func (x *JobResult) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobResult.ProtoReflect.Descriptor instead.
+func (*JobResult) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *JobResult) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *JobResult) GetStdout() []byte {
+	if x != nil {
+		return x.Stdout
+	}
+	return nil
+}
+
+func (x *JobResult) GetStderr() []byte {
+	if x != nil {
+		return x.Stderr
+	}
+	return nil
+}
+
+func (x *JobResult) GetExitCode() int64 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *JobResult) GetError() string {
+	if x != nil && x.Error != nil {
+		return *x.Error
+	}
+	return ""
+}
+
+func (x *JobResult) GetCached() bool {
+	if x != nil {
+		return x.Cached
+	}
+	return false
+}
+
+func (x *JobResult) GetTrace() *JobTrace {
+	if x != nil {
+		return x.Trace
+	}
+	return nil
+}
+
+func (x *JobResult) GetDigest() []byte {
+	if x != nil {
+		return x.Digest
+	}
+	return nil
+}
+
+type JobFlaky struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            []byte                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Failures      []*JobResult           `protobuf:"bytes,2,rep,name=failures,proto3" json:"failures,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobFlaky) Reset() {
+	*x = JobFlaky{}
+	mi := &file_apipb_api_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobFlaky) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobFlaky) ProtoMessage() {}
+
+func (x *JobFlaky) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobFlaky.ProtoReflect.Descriptor instead.
+func (*JobFlaky) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *JobFlaky) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *JobFlaky) GetFailures() []*JobResult {
+	if x != nil {
+		return x.Failures
+	}
+	return nil
+}
+
+type FileDiff struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Kind          string                 `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	Offset        int64                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileDiff) Reset() {
+	*x = FileDiff{}
+	mi := &file_apipb_api_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileDiff) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileDiff) ProtoMessage() {}
+
+func (x *FileDiff) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileDiff.ProtoReflect.Descriptor instead.
+func (*FileDiff) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *FileDiff) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *FileDiff) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *FileDiff) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type JobMismatch struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    []byte                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Ровно два воркера: первый и второй запуск.
+	Workers       []string    `protobuf:"bytes,2,rep,name=workers,proto3" json:"workers,omitempty"`
+	Diffs         []*FileDiff `protobuf:"bytes,3,rep,name=diffs,proto3" json:"diffs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobMismatch) Reset() {
+	*x = JobMismatch{}
+	mi := &file_apipb_api_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobMismatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobMismatch) ProtoMessage() {}
+
+func (x *JobMismatch) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobMismatch.ProtoReflect.Descriptor instead.
+func (*JobMismatch) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *JobMismatch) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *JobMismatch) GetWorkers() []string {
+	if x != nil {
+		return x.Workers
+	}
+	return nil
+}
+
+func (x *JobMismatch) GetDiffs() []*FileDiff {
+	if x != nil {
+		return x.Diffs
+	}
+	return nil
+}
+
+type BuildFailed struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Error         string                 `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	Cancelled     bool                   `protobuf:"varint,2,opt,name=cancelled,proto3" json:"cancelled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BuildFailed) Reset() {
+	*x = BuildFailed{}
+	mi := &file_apipb_api_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BuildFailed) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildFailed) ProtoMessage() {}
+
+func (x *BuildFailed) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildFailed.ProtoReflect.Descriptor instead.
+func (*BuildFailed) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *BuildFailed) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *BuildFailed) GetCancelled() bool {
+	if x != nil {
+		return x.Cancelled
+	}
+	return false
+}
+
+type BuildFinished struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BuildFinished) Reset() {
+	*x = BuildFinished{}
+	mi := &file_apipb_api_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BuildFinished) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildFinished) ProtoMessage() {}
+
+func (x *BuildFinished) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildFinished.ProtoReflect.Descriptor instead.
+func (*BuildFinished) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{17}
+}
+
+// StatusUpdate повторяет api.StatusUpdate: у каждого поля есть presence, как у указателя.
+type StatusUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobOutput     *JobOutput             `protobuf:"bytes,1,opt,name=job_output,json=jobOutput,proto3" json:"job_output,omitempty"`
+	JobFinished   *JobResult             `protobuf:"bytes,2,opt,name=job_finished,json=jobFinished,proto3" json:"job_finished,omitempty"`
+	JobFlaky      *JobFlaky              `protobuf:"bytes,3,opt,name=job_flaky,json=jobFlaky,proto3" json:"job_flaky,omitempty"`
+	JobMismatch   *JobMismatch           `protobuf:"bytes,4,opt,name=job_mismatch,json=jobMismatch,proto3" json:"job_mismatch,omitempty"`
+	BuildFailed   *BuildFailed           `protobuf:"bytes,5,opt,name=build_failed,json=buildFailed,proto3" json:"build_failed,omitempty"`
+	BuildFinished *BuildFinished         `protobuf:"bytes,6,opt,name=build_finished,json=buildFinished,proto3" json:"build_finished,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusUpdate) Reset() {
+	*x = StatusUpdate{}
+	mi := &file_apipb_api_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusUpdate) ProtoMessage() {}
+
+func (x *StatusUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusUpdate.ProtoReflect.Descriptor instead.
+func (*StatusUpdate) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *StatusUpdate) GetJobOutput() *JobOutput {
+	if x != nil {
+		return x.JobOutput
+	}
+	return nil
+}
+
+func (x *StatusUpdate) GetJobFinished() *JobResult {
+	if x != nil {
+		return x.JobFinished
+	}
+	return nil
+}
+
+func (x *StatusUpdate) GetJobFlaky() *JobFlaky {
+	if x != nil {
+		return x.JobFlaky
+	}
+	return nil
+}
+
+func (x *StatusUpdate) GetJobMismatch() *JobMismatch {
+	if x != nil {
+		return x.JobMismatch
+	}
+	return nil
+}
+
+func (x *StatusUpdate) GetBuildFailed() *BuildFailed {
+	if x != nil {
+		return x.BuildFailed
+	}
+	return nil
+}
+
+func (x *StatusUpdate) GetBuildFinished() *BuildFinished {
+	if x != nil {
+		return x.BuildFinished
+	}
+	return nil
+}
+
+type StatusMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Status:
+	//
+	//	*StatusMessage_Started
+	//	*StatusMessage_Update
+	Status        isStatusMessage_Status `protobuf_oneof:"status"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusMessage) Reset() {
+	*x = StatusMessage{}
+	mi := &file_apipb_api_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusMessage) ProtoMessage() {}
+
+func (x *StatusMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusMessage.ProtoReflect.Descriptor instead.
+func (*StatusMessage) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *StatusMessage) GetStatus() isStatusMessage_Status {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+func (x *StatusMessage) GetStarted() *BuildStarted {
+	if x != nil {
+		if x, ok := x.Status.(*StatusMessage_Started); ok {
+			return x.Started
+		}
+	}
+	return nil
+}
+
+func (x *StatusMessage) GetUpdate() *StatusUpdate {
+	if x != nil {
+		if x, ok := x.Status.(*StatusMessage_Update); ok {
+			return x.Update
+		}
+	}
+	return nil
+}
+
+type isStatusMessage_Status interface {
+	isStatusMessage_Status()
+}
+
+type StatusMessage_Started struct {
+	// Всегда первое сообщение потока.
+	Started *BuildStarted `protobuf:"bytes,1,opt,name=started,proto3,oneof"`
+}
+
+type StatusMessage_Update struct {
+	Update *StatusUpdate `protobuf:"bytes,2,opt,name=update,proto3,oneof"`
+}
+
+func (*StatusMessage_Started) isStatusMessage_Status() {}
+
+func (*StatusMessage_Update) isStatusMessage_Status() {}
+
+type AttachBuildRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BuildId       []byte                 `protobuf:"bytes,1,opt,name=build_id,json=buildId,proto3" json:"build_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttachBuildRequest) Reset() {
+	*x = AttachBuildRequest{}
+	mi := &file_apipb_api_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachBuildRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachBuildRequest) ProtoMessage() {}
+
+func (x *AttachBuildRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachBuildRequest.ProtoReflect.Descriptor instead.
+func (*AttachBuildRequest) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *AttachBuildRequest) GetBuildId() []byte {
+	if x != nil {
+		return x.BuildId
+	}
+	return nil
+}
+
+type UploadDone struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadDone) Reset() {
+	*x = UploadDone{}
+	mi := &file_apipb_api_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadDone) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadDone) ProtoMessage() {}
+
+func (x *UploadDone) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadDone.ProtoReflect.Descriptor instead.
+func (*UploadDone) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{21}
+}
+
+type Cancel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Cancel) Reset() {
+	*x = Cancel{}
+	mi := &file_apipb_api_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Cancel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Cancel) ProtoMessage() {}
+
+func (x *Cancel) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Cancel.ProtoReflect.Descriptor instead.
+func (*Cancel) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{22}
+}
+
+type SignalRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UploadDone    *UploadDone            `protobuf:"bytes,1,opt,name=upload_done,json=uploadDone,proto3" json:"upload_done,omitempty"`
+	Cancel        *Cancel                `protobuf:"bytes,2,opt,name=cancel,proto3" json:"cancel,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignalRequest) Reset() {
+	*x = SignalRequest{}
+	mi := &file_apipb_api_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignalRequest) ProtoMessage() {}
+
+func (x *SignalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignalRequest.ProtoReflect.Descriptor instead.
+func (*SignalRequest) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *SignalRequest) GetUploadDone() *UploadDone {
+	if x != nil {
+		return x.UploadDone
+	}
+	return nil
+}
+
+func (x *SignalRequest) GetCancel() *Cancel {
+	if x != nil {
+		return x.Cancel
+	}
+	return nil
+}
+
+type SignalBuildRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BuildId       []byte                 `protobuf:"bytes,1,opt,name=build_id,json=buildId,proto3" json:"build_id,omitempty"`
+	Signal        *SignalRequest         `protobuf:"bytes,2,opt,name=signal,proto3" json:"signal,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignalBuildRequest) Reset() {
+	*x = SignalBuildRequest{}
+	mi := &file_apipb_api_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignalBuildRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignalBuildRequest) ProtoMessage() {}
+
+func (x *SignalBuildRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignalBuildRequest.ProtoReflect.Descriptor instead.
+func (*SignalBuildRequest) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *SignalBuildRequest) GetBuildId() []byte {
+	if x != nil {
+		return x.BuildId
+	}
+	return nil
+}
+
+func (x *SignalBuildRequest) GetSignal() *SignalRequest {
+	if x != nil {
+		return x.Signal
+	}
+	return nil
+}
+
+type SignalResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignalResponse) Reset() {
+	*x = SignalResponse{}
+	mi := &file_apipb_api_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignalResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignalResponse) ProtoMessage() {}
+
+func (x *SignalResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignalResponse.ProtoReflect.Descriptor instead.
+func (*SignalResponse) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{25}
+}
+
+type HeartbeatRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	WorkerId         string                 `protobuf:"bytes,1,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
+	RunningJobs      [][]byte               `protobuf:"bytes,2,rep,name=running_jobs,json=runningJobs,proto3" json:"running_jobs,omitempty"`
+	FreeSlots        int64                  `protobuf:"varint,3,opt,name=free_slots,json=freeSlots,proto3" json:"free_slots,omitempty"`
+	Capacity         *Resources             `protobuf:"bytes,4,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	FreeResources    *Resources             `protobuf:"bytes,5,opt,name=free_resources,json=freeResources,proto3" json:"free_resources,omitempty"`
+	Labels           []string               `protobuf:"bytes,6,rep,name=labels,proto3" json:"labels,omitempty"`
+	FinishedJob      []*JobResult           `protobuf:"bytes,7,rep,name=finished_job,json=finishedJob,proto3" json:"finished_job,omitempty"`
+	JobOutput        []*JobOutput           `protobuf:"bytes,8,rep,name=job_output,json=jobOutput,proto3" json:"job_output,omitempty"`
+	AddedArtifacts   [][]byte               `protobuf:"bytes,9,rep,name=added_artifacts,json=addedArtifacts,proto3" json:"added_artifacts,omitempty"`
+	RemovedArtifacts [][]byte               `protobuf:"bytes,10,rep,name=removed_artifacts,json=removedArtifacts,proto3" json:"removed_artifacts,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *HeartbeatRequest) Reset() {
+	*x = HeartbeatRequest{}
+	mi := &file_apipb_api_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatRequest) ProtoMessage() {}
+
+func (x *HeartbeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatRequest.ProtoReflect.Descriptor instead.
+func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *HeartbeatRequest) GetWorkerId() string {
+	if x != nil {
+		return x.WorkerId
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetRunningJobs() [][]byte {
+	if x != nil {
+		return x.RunningJobs
+	}
+	return nil
+}
+
+func (x *HeartbeatRequest) GetFreeSlots() int64 {
+	if x != nil {
+		return x.FreeSlots
+	}
+	return 0
+}
+
+func (x *HeartbeatRequest) GetCapacity() *Resources {
+	if x != nil {
+		return x.Capacity
+	}
+	return nil
+}
+
+func (x *HeartbeatRequest) GetFreeResources() *Resources {
+	if x != nil {
+		return x.FreeResources
+	}
+	return nil
+}
+
+func (x *HeartbeatRequest) GetLabels() []string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *HeartbeatRequest) GetFinishedJob() []*JobResult {
+	if x != nil {
+		return x.FinishedJob
+	}
+	return nil
+}
+
+func (x *HeartbeatRequest) GetJobOutput() []*JobOutput {
+	if x != nil {
+		return x.JobOutput
+	}
+	return nil
+}
+
+func (x *HeartbeatRequest) GetAddedArtifacts() [][]byte {
+	if x != nil {
+		return x.AddedArtifacts
+	}
+	return nil
+}
+
+func (x *HeartbeatRequest) GetRemovedArtifacts() [][]byte {
+	if x != nil {
+		return x.RemovedArtifacts
+	}
+	return nil
+}
+
+// Artifact - элемент api.JobSpec.Artifacts.
+type Artifact struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            []byte                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	WorkerId      string                 `protobuf:"bytes,2,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Artifact) Reset() {
+	*x = Artifact{}
+	mi := &file_apipb_api_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Artifact) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Artifact) ProtoMessage() {}
+
+func (x *Artifact) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Artifact.ProtoReflect.Descriptor instead.
+func (*Artifact) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *Artifact) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *Artifact) GetWorkerId() string {
+	if x != nil {
+		return x.WorkerId
+	}
+	return ""
+}
+
+type JobSpec struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SourceFiles   []*SourceFile          `protobuf:"bytes,1,rep,name=source_files,json=sourceFiles,proto3" json:"source_files,omitempty"`
+	Artifacts     []*Artifact            `protobuf:"bytes,2,rep,name=artifacts,proto3" json:"artifacts,omitempty"`
+	Priority      *durationpb.Duration   `protobuf:"bytes,3,opt,name=priority,proto3" json:"priority,omitempty"`
+	Verify        bool                   `protobuf:"varint,4,opt,name=verify,proto3" json:"verify,omitempty"`
+	ExcludeWorker string                 `protobuf:"bytes,5,opt,name=exclude_worker,json=excludeWorker,proto3" json:"exclude_worker,omitempty"`
+	Job           *Job                   `protobuf:"bytes,6,opt,name=job,proto3" json:"job,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobSpec) Reset() {
+	*x = JobSpec{}
+	mi := &file_apipb_api_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobSpec) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobSpec) ProtoMessage() {}
+
+func (x *JobSpec) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobSpec.ProtoReflect.Descriptor instead.
+func (*JobSpec) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *JobSpec) GetSourceFiles() []*SourceFile {
+	if x != nil {
+		return x.SourceFiles
+	}
+	return nil
+}
+
+func (x *JobSpec) GetArtifacts() []*Artifact {
+	if x != nil {
+		return x.Artifacts
+	}
+	return nil
+}
+
+func (x *JobSpec) GetPriority() *durationpb.Duration {
+	if x != nil {
+		return x.Priority
+	}
+	return nil
+}
+
+func (x *JobSpec) GetVerify() bool {
+	if x != nil {
+		return x.Verify
+	}
+	return false
+}
+
+func (x *JobSpec) GetExcludeWorker() string {
+	if x != nil {
+		return x.ExcludeWorker
+	}
+	return ""
+}
+
+func (x *JobSpec) GetJob() *Job {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+type HeartbeatResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Ключ api.HeartbeatResponse.JobsToRun совпадает с ID джоба, поэтому передаётся только список.
+	JobsToRun     []*JobSpec `protobuf:"bytes,1,rep,name=jobs_to_run,json=jobsToRun,proto3" json:"jobs_to_run,omitempty"`
+	JobsToKill    [][]byte   `protobuf:"bytes,2,rep,name=jobs_to_kill,json=jobsToKill,proto3" json:"jobs_to_kill,omitempty"`
+	Peers         []string   `protobuf:"bytes,3,rep,name=peers,proto3" json:"peers,omitempty"`
+	Coordinator   string     `protobuf:"bytes,4,opt,name=coordinator,proto3" json:"coordinator,omitempty"`
+	PausedOutput  [][]byte   `protobuf:"bytes,5,rep,name=paused_output,json=pausedOutput,proto3" json:"paused_output,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatResponse) Reset() {
+	*x = HeartbeatResponse{}
+	mi := &file_apipb_api_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatResponse) ProtoMessage() {}
+
+func (x *HeartbeatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_apipb_api_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatResponse.ProtoReflect.Descriptor instead.
+func (*HeartbeatResponse) Descriptor() ([]byte, []int) {
+	return file_apipb_api_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *HeartbeatResponse) GetJobsToRun() []*JobSpec {
+	if x != nil {
+		return x.JobsToRun
+	}
+	return nil
+}
+
+func (x *HeartbeatResponse) GetJobsToKill() [][]byte {
+	if x != nil {
+		return x.JobsToKill
+	}
+	return nil
+}
+
+func (x *HeartbeatResponse) GetPeers() []string {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+func (x *HeartbeatResponse) GetCoordinator() string {
+	if x != nil {
+		return x.Coordinator
+	}
+	return ""
+}
+
+func (x *HeartbeatResponse) GetPausedOutput() [][]byte {
+	if x != nil {
+		return x.PausedOutput
+	}
+	return nil
+}
+
+var File_apipb_api_proto protoreflect.FileDescriptor
+
+const file_apipb_api_proto_rawDesc = "" +
+	"\n" +
+	"\x0fapipb/api.proto\x12\rdistbuild.api\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"5\n" +
+	"\tResources\x12\x10\n" +
+	"\x03cpu\x18\x01 \x01(\x03R\x03cpu\x12\x16\n" +
+	"\x06memory\x18\x02 \x01(\x03R\x06memory\"^\n" +
+	"\fRequirements\x126\n" +
+	"\tresources\x18\x01 \x01(\v2\x18.distbuild.api.ResourcesR\tresources\x12\x16\n" +
+	"\x06labels\x18\x02 \x03(\tR\x06labels\"g\n" +
+	"\vRetryPolicy\x12!\n" +
+	"\fmax_attempts\x18\x01 \x01(\x03R\vmaxAttempts\x12\x1d\n" +
+	"\n" +
+	"exit_codes\x18\x02 \x03(\x03R\texitCodes\x12\x16\n" +
+	"\x06errors\x18\x03 \x03(\tR\x06errors\"\xfd\x02\n" +
+	"\x03Cmd\x12\x12\n" +
+	"\x04exec\x18\x01 \x03(\tR\x04exec\x12\x18\n" +
+	"\aenviron\x18\x02 \x03(\tR\aenviron\x12+\n" +
+	"\x11working_directory\x18\x03 \x01(\tR\x10workingDirectory\x12!\n" +
+	"\fcat_template\x18\x04 \x01(\tR\vcatTemplate\x12\x1d\n" +
+	"\n" +
+	"cat_output\x18\x05 \x01(\tR\tcatOutput\x12\x1f\n" +
+	"\vcopy_source\x18\x06 \x01(\tR\n" +
+	"copySource\x12\x1f\n" +
+	"\vcopy_output\x18\a \x01(\tR\n" +
+	"copyOutput\x12%\n" +
+	"\x0esymlink_target\x18\b \x01(\tR\rsymlinkTarget\x12%\n" +
+	"\x0esymlink_output\x18\t \x01(\tR\rsymlinkOutput\x12!\n" +
+	"\fmkdir_output\x18\n" +
+	" \x01(\tR\vmkdirOutput\x12&\n" +
+	"\x0fenv_file_output\x18\v \x01(\tR\renvFileOutput\"\xa3\x02\n" +
+	"\x03Job\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\fR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06inputs\x18\x03 \x03(\tR\x06inputs\x12\x12\n" +
+	"\x04deps\x18\x04 \x03(\fR\x04deps\x12&\n" +
+	"\x04cmds\x18\x05 \x03(\v2\x12.distbuild.api.CmdR\x04cmds\x12?\n" +
+	"\frequirements\x18\x06 \x01(\v2\x1b.distbuild.api.RequirementsR\frequirements\x12\x19\n" +
+	"\bno_cache\x18\a \x01(\bR\anoCache\x120\n" +
+	"\x05retry\x18\b \x01(\v2\x1a.distbuild.api.RetryPolicyR\x05retry\x12\x16\n" +
+	"\x06output\x18\t \x01(\tR\x06output\"0\n" +
+	"\n" +
+	"SourceFile\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\fR\x02id\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\"m\n" +
+	"\x05Graph\x12<\n" +
+	"\fsource_files\x18\x01 \x03(\v2\x19.distbuild.api.SourceFileR\vsourceFiles\x12&\n" +
+	"\x04jobs\x18\x02 \x03(\v2\x12.distbuild.api.JobR\x04jobs\"R\n" +
+	"\fBuildRequest\x12*\n" +
+	"\x05graph\x18\x01 \x01(\v2\x14.distbuild.api.GraphR\x05graph\x12\x16\n" +
+	"\x06verify\x18\x02 \x01(\bR\x06verify\"C\n" +
+	"\fBuildStarted\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\fR\x02id\x12#\n" +
+	"\rmissing_files\x18\x02 \x03(\fR\fmissingFiles\"K\n" +
+	"\tJobOutput\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\fR\x02id\x12\x16\n" +
+	"\x06stdout\x18\x02 \x01(\fR\x06stdout\x12\x16\n" +
+	"\x06stderr\x18\x03 \x01(\fR\x06stderr\"j\n" +
+	"\bCmdTrace\x120\n" +
+	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12,\n" +
+	"\x03end\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x03end\"\xd5\x02\n" +
+	"\bJobTrace\x12\x1b\n" +
+	"\tworker_id\x18\x01 \x01(\tR\bworkerId\x12\x12\n" +
+	"\x04slot\x18\x02 \x01(\x03R\x04slot\x128\n" +
+	"\tscheduled\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tscheduled\x122\n" +
+	"\x06picked\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x06picked\x12C\n" +
+	"\x0fdeps_downloaded\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x0edepsDownloaded\x12+\n" +
+	"\x04cmds\x18\x06 \x03(\v2\x17.distbuild.api.CmdTraceR\x04cmds\x128\n" +
+	"\tcommitted\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcommitted\"\xec\x01\n" +
+	"\tJobResult\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\fR\x02id\x12\x16\n" +
+	"\x06stdout\x18\x02 \x01(\fR\x06stdout\x12\x16\n" +
+	"\x06stderr\x18\x03 \x01(\fR\x06stderr\x12\x1b\n" +
+	"\texit_code\x18\x04 \x01(\x03R\bexitCode\x12\x19\n" +
+	"\x05error\x18\x05 \x01(\tH\x00R\x05error\x88\x01\x01\x12\x16\n" +
+	"\x06cached\x18\x06 \x01(\bR\x06cached\x12-\n" +
+	"\x05trace\x18\a \x01(\v2\x17.distbuild.api.JobTraceR\x05trace\x12\x16\n" +
+	"\x06digest\x18\b \x01(\fR\x06digestB\b\n" +
+	"\x06_error\"P\n" +
+	"\bJobFlaky\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\fR\x02id\x124\n" +
+	"\bfailures\x18\x02 \x03(\v2\x18.distbuild.api.JobResultR\bfailures\"J\n" +
+	"\bFileDiff\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x03R\x06offset\"f\n" +
+	"\vJobMismatch\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\fR\x02id\x12\x18\n" +
+	"\aworkers\x18\x02 \x03(\tR\aworkers\x12-\n" +
+	"\x05diffs\x18\x03 \x03(\v2\x17.distbuild.api.FileDiffR\x05diffs\"A\n" +
+	"\vBuildFailed\x12\x14\n" +
+	"\x05error\x18\x01 \x01(\tR\x05error\x12\x1c\n" +
+	"\tcancelled\x18\x02 \x01(\bR\tcancelled\"\x0f\n" +
+	"\rBuildFinished\"\xfd\x02\n" +
+	"\fStatusUpdate\x127\n" +
+	"\n" +
+	"job_output\x18\x01 \x01(\v2\x18.distbuild.api.JobOutputR\tjobOutput\x12;\n" +
+	"\fjob_finished\x18\x02 \x01(\v2\x18.distbuild.api.JobResultR\vjobFinished\x124\n" +
+	"\tjob_flaky\x18\x03 \x01(\v2\x17.distbuild.api.JobFlakyR\bjobFlaky\x12=\n" +
+	"\fjob_mismatch\x18\x04 \x01(\v2\x1a.distbuild.api.JobMismatchR\vjobMismatch\x12=\n" +
+	"\fbuild_failed\x18\x05 \x01(\v2\x1a.distbuild.api.BuildFailedR\vbuildFailed\x12C\n" +
+	"\x0ebuild_finished\x18\x06 \x01(\v2\x1c.distbuild.api.BuildFinishedR\rbuildFinished\"\x89\x01\n" +
+	"\rStatusMessage\x127\n" +
+	"\astarted\x18\x01 \x01(\v2\x1b.distbuild.api.BuildStartedH\x00R\astarted\x125\n" +
+	"\x06update\x18\x02 \x01(\v2\x1b.distbuild.api.StatusUpdateH\x00R\x06updateB\b\n" +
+	"\x06status\"/\n" +
+	"\x12AttachBuildRequest\x12\x19\n" +
+	"\bbuild_id\x18\x01 \x01(\fR\abuildId\"\f\n" +
+	"\n" +
+	"UploadDone\"\b\n" +
+	"\x06Cancel\"z\n" +
+	"\rSignalRequest\x12:\n" +
+	"\vupload_done\x18\x01 \x01(\v2\x19.distbuild.api.UploadDoneR\n" +
+	"uploadDone\x12-\n" +
+	"\x06cancel\x18\x02 \x01(\v2\x15.distbuild.api.CancelR\x06cancel\"e\n" +
+	"\x12SignalBuildRequest\x12\x19\n" +
+	"\bbuild_id\x18\x01 \x01(\fR\abuildId\x124\n" +
+	"\x06signal\x18\x02 \x01(\v2\x1c.distbuild.api.SignalRequestR\x06signal\"\x10\n" +
+	"\x0eSignalResponse\"\xcc\x03\n" +
+	"\x10HeartbeatRequest\x12\x1b\n" +
+	"\tworker_id\x18\x01 \x01(\tR\bworkerId\x12!\n" +
+	"\frunning_jobs\x18\x02 \x03(\fR\vrunningJobs\x12\x1d\n" +
+	"\n" +
+	"free_slots\x18\x03 \x01(\x03R\tfreeSlots\x124\n" +
+	"\bcapacity\x18\x04 \x01(\v2\x18.distbuild.api.ResourcesR\bcapacity\x12?\n" +
+	"\x0efree_resources\x18\x05 \x01(\v2\x18.distbuild.api.ResourcesR\rfreeResources\x12\x16\n" +
+	"\x06labels\x18\x06 \x03(\tR\x06labels\x12;\n" +
+	"\ffinished_job\x18\a \x03(\v2\x18.distbuild.api.JobResultR\vfinishedJob\x127\n" +
+	"\n" +
+	"job_output\x18\b \x03(\v2\x18.distbuild.api.JobOutputR\tjobOutput\x12'\n" +
+	"\x0fadded_artifacts\x18\t \x03(\fR\x0eaddedArtifacts\x12+\n" +
+	"\x11removed_artifacts\x18\n" +
+	" \x03(\fR\x10removedArtifacts\"7\n" +
+	"\bArtifact\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\fR\x02id\x12\x1b\n" +
+	"\tworker_id\x18\x02 \x01(\tR\bworkerId\"\x9a\x02\n" +
+	"\aJobSpec\x12<\n" +
+	"\fsource_files\x18\x01 \x03(\v2\x19.distbuild.api.SourceFileR\vsourceFiles\x125\n" +
+	"\tartifacts\x18\x02 \x03(\v2\x17.distbuild.api.ArtifactR\tartifacts\x125\n" +
+	"\bpriority\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\bpriority\x12\x16\n" +
+	"\x06verify\x18\x04 \x01(\bR\x06verify\x12%\n" +
+	"\x0eexclude_worker\x18\x05 \x01(\tR\rexcludeWorker\x12$\n" +
+	"\x03job\x18\x06 \x01(\v2\x12.distbuild.api.JobR\x03job\"\xca\x01\n" +
+	"\x11HeartbeatResponse\x126\n" +
+	"\vjobs_to_run\x18\x01 \x03(\v2\x16.distbuild.api.JobSpecR\tjobsToRun\x12 \n" +
+	"\fjobs_to_kill\x18\x02 \x03(\fR\n" +
+	"jobsToKill\x12\x14\n" +
+	"\x05peers\x18\x03 \x03(\tR\x05peers\x12 \n" +
+	"\vcoordinator\x18\x04 \x01(\tR\vcoordinator\x12#\n" +
+	"\rpaused_output\x18\x05 \x03(\fR\fpausedOutput2\xfc\x01\n" +
+	"\fBuildService\x12I\n" +
+	"\n" +
+	"StartBuild\x12\x1b.distbuild.api.BuildRequest\x1a\x1c.distbuild.api.StatusMessage0\x01\x12P\n" +
+	"\vAttachBuild\x12!.distbuild.api.AttachBuildRequest\x1a\x1c.distbuild.api.StatusMessage0\x01\x12O\n" +
+	"\vSignalBuild\x12!.distbuild.api.SignalBuildRequest\x1a\x1d.distbuild.api.SignalResponse2b\n" +
+	"\x10HeartbeatService\x12N\n" +
+	"\tHeartbeat\x12\x1f.distbuild.api.HeartbeatRequest\x1a .distbuild.api.HeartbeatResponseB5Z3gitlab.com/slon/shad-go/distbuild/pkg/grpcapi/apipbb\x06proto3"
+
+var (
+	file_apipb_api_proto_rawDescOnce sync.Once
+	file_apipb_api_proto_rawDescData []byte
+)
+
+func file_apipb_api_proto_rawDescGZIP() []byte {
+	file_apipb_api_proto_rawDescOnce.Do(func() {
+		file_apipb_api_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_apipb_api_proto_rawDesc), len(file_apipb_api_proto_rawDesc)))
+	})
+	return file_apipb_api_proto_rawDescData
+}
+
+var file_apipb_api_proto_msgTypes = make([]protoimpl.MessageInfo, 30)
+var file_apipb_api_proto_goTypes = []any{
+	(*Resources)(nil),             // 0: distbuild.api.Resources
+	(*Requirements)(nil),          // 1: distbuild.api.Requirements
+	(*RetryPolicy)(nil),           // 2: distbuild.api.RetryPolicy
+	(*Cmd)(nil),                   // 3: distbuild.api.Cmd
+	(*Job)(nil),                   // 4: distbuild.api.Job
+	(*SourceFile)(nil),            // 5: distbuild.api.SourceFile
+	(*Graph)(nil),                 // 6: distbuild.api.Graph
+	(*BuildRequest)(nil),          // 7: distbuild.api.BuildRequest
+	(*BuildStarted)(nil),          // 8: distbuild.api.BuildStarted
+	(*JobOutput)(nil),             // 9: distbuild.api.JobOutput
+	(*CmdTrace)(nil),              // 10: distbuild.api.CmdTrace
+	(*JobTrace)(nil),              // 11: distbuild.api.JobTrace
+	(*JobResult)(nil),             // 12: distbuild.api.JobResult
+	(*JobFlaky)(nil),              // 13: distbuild.api.JobFlaky
+	(*FileDiff)(nil),              // 14: distbuild.api.FileDiff
+	(*JobMismatch)(nil),           // 15: distbuild.api.JobMismatch
+	(*BuildFailed)(nil),           // 16: distbuild.api.BuildFailed
+	(*BuildFinished)(nil),         // 17: distbuild.api.BuildFinished
+	(*StatusUpdate)(nil),          // 18: distbuild.api.StatusUpdate
+	(*StatusMessage)(nil),         // 19: distbuild.api.StatusMessage
+	(*AttachBuildRequest)(nil),    // 20: distbuild.api.AttachBuildRequest
+	(*UploadDone)(nil),            // 21: distbuild.api.UploadDone
+	(*Cancel)(nil),                // 22: distbuild.api.Cancel
+	(*SignalRequest)(nil),         // 23: distbuild.api.SignalRequest
+	(*SignalBuildRequest)(nil),    // 24: distbuild.api.SignalBuildRequest
+	(*SignalResponse)(nil),        // 25: distbuild.api.SignalResponse
+	(*HeartbeatRequest)(nil),      // 26: distbuild.api.HeartbeatRequest
+	(*Artifact)(nil),              // 27: distbuild.api.Artifact
+	(*JobSpec)(nil),               // 28: distbuild.api.JobSpec
+	(*HeartbeatResponse)(nil),     // 29: distbuild.api.HeartbeatResponse
+	(*timestamppb.Timestamp)(nil), // 30: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 31: google.protobuf.Duration
+}
+var file_apipb_api_proto_depIdxs = []int32{
+	0,  // 0: distbuild.api.Requirements.resources:type_name -> distbuild.api.Resources
+	3,  // 1: distbuild.api.Job.cmds:type_name -> distbuild.api.Cmd
+	1,  // 2: distbuild.api.Job.requirements:type_name -> distbuild.api.Requirements
+	2,  // 3: distbuild.api.Job.retry:type_name -> distbuild.api.RetryPolicy
+	5,  // 4: distbuild.api.Graph.source_files:type_name -> distbuild.api.SourceFile
+	4,  // 5: distbuild.api.Graph.jobs:type_name -> distbuild.api.Job
+	6,  // 6: distbuild.api.BuildRequest.graph:type_name -> distbuild.api.Graph
+	30, // 7: distbuild.api.CmdTrace.start:type_name -> google.protobuf.Timestamp
+	30, // 8: distbuild.api.CmdTrace.end:type_name -> google.protobuf.Timestamp
+	30, // 9: distbuild.api.JobTrace.scheduled:type_name -> google.protobuf.Timestamp
+	30, // 10: distbuild.api.JobTrace.picked:type_name -> google.protobuf.Timestamp
+	30, // 11: distbuild.api.JobTrace.deps_downloaded:type_name -> google.protobuf.Timestamp
+	10, // 12: distbuild.api.JobTrace.cmds:type_name -> distbuild.api.CmdTrace
+	30, // 13: distbuild.api.JobTrace.committed:type_name -> google.protobuf.Timestamp
+	11, // 14: distbuild.api.JobResult.trace:type_name -> distbuild.api.JobTrace
+	12, // 15: distbuild.api.JobFlaky.failures:type_name -> distbuild.api.JobResult
+	14, // 16: distbuild.api.JobMismatch.diffs:type_name -> distbuild.api.FileDiff
+	9,  // 17: distbuild.api.StatusUpdate.job_output:type_name -> distbuild.api.JobOutput
+	12, // 18: distbuild.api.StatusUpdate.job_finished:type_name -> distbuild.api.JobResult
+	13, // 19: distbuild.api.StatusUpdate.job_flaky:type_name -> distbuild.api.JobFlaky
+	15, // 20: distbuild.api.StatusUpdate.job_mismatch:type_name -> distbuild.api.JobMismatch
+	16, // 21: distbuild.api.StatusUpdate.build_failed:type_name -> distbuild.api.BuildFailed
+	17, // 22: distbuild.api.StatusUpdate.build_finished:type_name -> distbuild.api.BuildFinished
+	8,  // 23: distbuild.api.StatusMessage.started:type_name -> distbuild.api.BuildStarted
+	18, // 24: distbuild.api.StatusMessage.update:type_name -> distbuild.api.StatusUpdate
+	21, // 25: distbuild.api.SignalRequest.upload_done:type_name -> distbuild.api.UploadDone
+	22, // 26: distbuild.api.SignalRequest.cancel:type_name -> distbuild.api.Cancel
+	23, // 27: distbuild.api.SignalBuildRequest.signal:type_name -> distbuild.api.SignalRequest
+	0,  // 28: distbuild.api.HeartbeatRequest.capacity:type_name -> distbuild.api.Resources
+	0,  // 29: distbuild.api.HeartbeatRequest.free_resources:type_name -> distbuild.api.Resources
+	12, // 30: distbuild.api.HeartbeatRequest.finished_job:type_name -> distbuild.api.JobResult
+	9,  // 31: distbuild.api.HeartbeatRequest.job_output:type_name -> distbuild.api.JobOutput
+	5,  // 32: distbuild.api.JobSpec.source_files:type_name -> distbuild.api.SourceFile
+	27, // 33: distbuild.api.JobSpec.artifacts:type_name -> distbuild.api.Artifact
+	31, // 34: distbuild.api.JobSpec.priority:type_name -> google.protobuf.Duration
+	4,  // 35: distbuild.api.JobSpec.job:type_name -> distbuild.api.Job
+	28, // 36: distbuild.api.HeartbeatResponse.jobs_to_run:type_name -> distbuild.api.JobSpec
+	7,  // 37: distbuild.api.BuildService.StartBuild:input_type -> distbuild.api.BuildRequest
+	20, // 38: distbuild.api.BuildService.AttachBuild:input_type -> distbuild.api.AttachBuildRequest
+	24, // 39: distbuild.api.BuildService.SignalBuild:input_type -> distbuild.api.SignalBuildRequest
+	26, // 40: distbuild.api.HeartbeatService.Heartbeat:input_type -> distbuild.api.HeartbeatRequest
+	19, // 41: distbuild.api.BuildService.StartBuild:output_type -> distbuild.api.StatusMessage
+	19, // 42: distbuild.api.BuildService.AttachBuild:output_type -> distbuild.api.StatusMessage
+	25, // 43: distbuild.api.BuildService.SignalBuild:output_type -> distbuild.api.SignalResponse
+	29, // 44: distbuild.api.HeartbeatService.Heartbeat:output_type -> distbuild.api.HeartbeatResponse
+	41, // [41:45] is the sub-list for method output_type
+	37, // [37:41] is the sub-list for method input_type
+	37, // [37:37] is the sub-list for extension type_name
+	37, // [37:37] is the sub-list for extension extendee
+	0,  // [0:37] is the sub-list for field type_name
+}
+
+func init() { file_apipb_api_proto_init() }
+func file_apipb_api_proto_init() {
+	if File_apipb_api_proto != nil {
+		return
+	}
+	file_apipb_api_proto_msgTypes[12].OneofWrappers = []any{}
+	file_apipb_api_proto_msgTypes[19].OneofWrappers = []any{
+		(*StatusMessage_Started)(nil),
+		(*StatusMessage_Update)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_apipb_api_proto_rawDesc), len(file_apipb_api_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   30,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_apipb_api_proto_goTypes,
+		DependencyIndexes: file_apipb_api_proto_depIdxs,
+		MessageInfos:      file_apipb_api_proto_msgTypes,
+	}.Build()
+	File_apipb_api_proto = out.File
+	file_apipb_api_proto_goTypes = nil
+	file_apipb_api_proto_depIdxs = nil
+}
@@ -0,0 +1,328 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: apipb/api.proto
+
+package apipb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	BuildService_StartBuild_FullMethodName  = "/distbuild.api.BuildService/StartBuild"
+	BuildService_AttachBuild_FullMethodName = "/distbuild.api.BuildService/AttachBuild"
+	BuildService_SignalBuild_FullMethodName = "/distbuild.api.BuildService/SignalBuild"
+)
+
+// BuildServiceClient is the client API for BuildService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BuildServiceClient interface {
+	StartBuild(ctx context.Context, in *BuildRequest, opts ...grpc.CallOption) (BuildService_StartBuildClient, error)
+	AttachBuild(ctx context.Context, in *AttachBuildRequest, opts ...grpc.CallOption) (BuildService_AttachBuildClient, error)
+	SignalBuild(ctx context.Context, in *SignalBuildRequest, opts ...grpc.CallOption) (*SignalResponse, error)
+}
+
+type buildServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBuildServiceClient(cc grpc.ClientConnInterface) BuildServiceClient {
+	return &buildServiceClient{cc}
+}
+
+func (c *buildServiceClient) StartBuild(ctx context.Context, in *BuildRequest, opts ...grpc.CallOption) (BuildService_StartBuildClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BuildService_ServiceDesc.Streams[0], BuildService_StartBuild_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &buildServiceStartBuildClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BuildService_StartBuildClient interface {
+	Recv() (*StatusMessage, error)
+	grpc.ClientStream
+}
+
+type buildServiceStartBuildClient struct {
+	grpc.ClientStream
+}
+
+func (x *buildServiceStartBuildClient) Recv() (*StatusMessage, error) {
+	m := new(StatusMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *buildServiceClient) AttachBuild(ctx context.Context, in *AttachBuildRequest, opts ...grpc.CallOption) (BuildService_AttachBuildClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BuildService_ServiceDesc.Streams[1], BuildService_AttachBuild_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &buildServiceAttachBuildClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BuildService_AttachBuildClient interface {
+	Recv() (*StatusMessage, error)
+	grpc.ClientStream
+}
+
+type buildServiceAttachBuildClient struct {
+	grpc.ClientStream
+}
+
+func (x *buildServiceAttachBuildClient) Recv() (*StatusMessage, error) {
+	m := new(StatusMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *buildServiceClient) SignalBuild(ctx context.Context, in *SignalBuildRequest, opts ...grpc.CallOption) (*SignalResponse, error) {
+	out := new(SignalResponse)
+	err := c.cc.Invoke(ctx, BuildService_SignalBuild_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BuildServiceServer is the server API for BuildService service.
+// All implementations must embed UnimplementedBuildServiceServer
+// for forward compatibility
+type BuildServiceServer interface {
+	StartBuild(*BuildRequest, BuildService_StartBuildServer) error
+	AttachBuild(*AttachBuildRequest, BuildService_AttachBuildServer) error
+	SignalBuild(context.Context, *SignalBuildRequest) (*SignalResponse, error)
+	mustEmbedUnimplementedBuildServiceServer()
+}
+
+// UnimplementedBuildServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedBuildServiceServer struct {
+}
+
+func (UnimplementedBuildServiceServer) StartBuild(*BuildRequest, BuildService_StartBuildServer) error {
+	return status.Errorf(codes.Unimplemented, "method StartBuild not implemented")
+}
+func (UnimplementedBuildServiceServer) AttachBuild(*AttachBuildRequest, BuildService_AttachBuildServer) error {
+	return status.Errorf(codes.Unimplemented, "method AttachBuild not implemented")
+}
+func (UnimplementedBuildServiceServer) SignalBuild(context.Context, *SignalBuildRequest) (*SignalResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SignalBuild not implemented")
+}
+func (UnimplementedBuildServiceServer) mustEmbedUnimplementedBuildServiceServer() {}
+
+// UnsafeBuildServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BuildServiceServer will
+// result in compilation errors.
+type UnsafeBuildServiceServer interface {
+	mustEmbedUnimplementedBuildServiceServer()
+}
+
+func RegisterBuildServiceServer(s grpc.ServiceRegistrar, srv BuildServiceServer) {
+	s.RegisterService(&BuildService_ServiceDesc, srv)
+}
+
+func _BuildService_StartBuild_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BuildRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BuildServiceServer).StartBuild(m, &buildServiceStartBuildServer{stream})
+}
+
+type BuildService_StartBuildServer interface {
+	Send(*StatusMessage) error
+	grpc.ServerStream
+}
+
+type buildServiceStartBuildServer struct {
+	grpc.ServerStream
+}
+
+func (x *buildServiceStartBuildServer) Send(m *StatusMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _BuildService_AttachBuild_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AttachBuildRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BuildServiceServer).AttachBuild(m, &buildServiceAttachBuildServer{stream})
+}
+
+type BuildService_AttachBuildServer interface {
+	Send(*StatusMessage) error
+	grpc.ServerStream
+}
+
+type buildServiceAttachBuildServer struct {
+	grpc.ServerStream
+}
+
+func (x *buildServiceAttachBuildServer) Send(m *StatusMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _BuildService_SignalBuild_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignalBuildRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BuildServiceServer).SignalBuild(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BuildService_SignalBuild_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BuildServiceServer).SignalBuild(ctx, req.(*SignalBuildRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BuildService_ServiceDesc is the grpc.ServiceDesc for BuildService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BuildService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "distbuild.api.BuildService",
+	HandlerType: (*BuildServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SignalBuild",
+			Handler:    _BuildService_SignalBuild_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StartBuild",
+			Handler:       _BuildService_StartBuild_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "AttachBuild",
+			Handler:       _BuildService_AttachBuild_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "apipb/api.proto",
+}
+
+const (
+	HeartbeatService_Heartbeat_FullMethodName = "/distbuild.api.HeartbeatService/Heartbeat"
+)
+
+// HeartbeatServiceClient is the client API for HeartbeatService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type HeartbeatServiceClient interface {
+	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
+}
+
+type heartbeatServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewHeartbeatServiceClient(cc grpc.ClientConnInterface) HeartbeatServiceClient {
+	return &heartbeatServiceClient{cc}
+}
+
+func (c *heartbeatServiceClient) Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error) {
+	out := new(HeartbeatResponse)
+	err := c.cc.Invoke(ctx, HeartbeatService_Heartbeat_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HeartbeatServiceServer is the server API for HeartbeatService service.
+// All implementations must embed UnimplementedHeartbeatServiceServer
+// for forward compatibility
+type HeartbeatServiceServer interface {
+	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
+	mustEmbedUnimplementedHeartbeatServiceServer()
+}
+
+// UnimplementedHeartbeatServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedHeartbeatServiceServer struct {
+}
+
+func (UnimplementedHeartbeatServiceServer) Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
+}
+func (UnimplementedHeartbeatServiceServer) mustEmbedUnimplementedHeartbeatServiceServer() {}
+
+// UnsafeHeartbeatServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to HeartbeatServiceServer will
+// result in compilation errors.
+type UnsafeHeartbeatServiceServer interface {
+	mustEmbedUnimplementedHeartbeatServiceServer()
+}
+
+func RegisterHeartbeatServiceServer(s grpc.ServiceRegistrar, srv HeartbeatServiceServer) {
+	s.RegisterService(&HeartbeatService_ServiceDesc, srv)
+}
+
+func _HeartbeatService_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HeartbeatServiceServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HeartbeatService_Heartbeat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HeartbeatServiceServer).Heartbeat(ctx, req.(*HeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// HeartbeatService_ServiceDesc is the grpc.ServiceDesc for HeartbeatService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var HeartbeatService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "distbuild.api.HeartbeatService",
+	HandlerType: (*HeartbeatServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Heartbeat",
+			Handler:    _HeartbeatService_Heartbeat_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "apipb/api.proto",
+}
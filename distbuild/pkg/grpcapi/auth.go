@@ -0,0 +1,124 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/auth"
+	"gitlab.com/slon/shad-go/distbuild/pkg/grpcapi/apipb"
+)
+
+const authorizationKey = "authorization"
+
+// ServerAuth возвращает опции gRPC сервера координатора, которые повторяют dist.Coordinator.SetAuth.
+//
+// Вызовы BuildService принимаются только с токеном из tokens в метаданных authorization. Heartbeat
+// принимается только с сертификатом CA кластера, причём HeartbeatRequest.WorkerID должен совпадать
+// с идентификатором из сертификата. Сам TLS включается отдельно, через
+// grpc.Creds(credentials.NewTLS(auth.ServerConfig(...))).
+func ServerAuth(l *zap.Logger, tokens []string) []grpc.ServerOption {
+	a := &serverAuth{l: l, tokens: tokens}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(a.unary),
+		grpc.ChainStreamInterceptor(a.stream),
+	}
+}
+
+type serverAuth struct {
+	l      *zap.Logger
+	tokens []string
+}
+
+func isHeartbeatMethod(method string) bool {
+	return strings.HasPrefix(method, "/"+apipb.HeartbeatService_ServiceDesc.ServiceName+"/")
+}
+
+func (a *serverAuth) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var err error
+	if isHeartbeatMethod(info.FullMethod) {
+		err = a.checkWorker(ctx, req)
+	} else {
+		err = a.checkToken(ctx, info.FullMethod)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (a *serverAuth) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	// Все потоковые вызовы принадлежат BuildService.
+	if err := a.checkToken(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+
+	return handler(srv, ss)
+}
+
+func (a *serverAuth) checkToken(ctx context.Context, method string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, header := range md.Get(authorizationKey) {
+		if auth.ValidBearer(a.tokens, header) {
+			return nil
+		}
+	}
+
+	a.l.Warn("call without valid token", zap.String("method", method))
+	return status.Error(codes.Unauthenticated, "invalid or missing bearer token")
+}
+
+func (a *serverAuth) checkWorker(ctx context.Context, req any) error {
+	id, ok := peerIdentity(ctx)
+	if !ok {
+		a.l.Warn("heartbeat without client certificate")
+		return status.Error(codes.PermissionDenied, "client certificate required")
+	}
+
+	if hb, isHeartbeat := req.(*apipb.HeartbeatRequest); isHeartbeat && hb.WorkerId != id {
+		a.l.Warn("heartbeat from wrong worker", zap.String("worker_id", hb.WorkerId), zap.String("peer", id))
+		return status.Error(codes.PermissionDenied, "worker id does not match certificate")
+	}
+	return nil
+}
+
+// peerIdentity возвращает идентификатор клиента из проверенного сертификата вызова, как auth.PeerIdentity.
+func peerIdentity(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 {
+		return "", false
+	}
+
+	id, err := auth.Identity(info.State.VerifiedChains[0][0])
+	return id, err == nil
+}
+
+// TokenCredentials передаёт токен клиента сборки в метаданных каждого вызова.
+//
+// Токен передаётся только по TLS соединению. Используется вместе с grpc.WithPerRPCCredentials.
+func TokenCredentials(token string) credentials.PerRPCCredentials {
+	return tokenCredentials(token)
+}
+
+type tokenCredentials string
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{authorizationKey: auth.Bearer(string(t))}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return true
+}
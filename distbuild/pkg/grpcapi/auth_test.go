@@ -0,0 +1,119 @@
+package grpcapi_test
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"gitlab.com/slon/shad-go/distbuild/disttest"
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	mock "gitlab.com/slon/shad-go/distbuild/pkg/api/mock"
+	"gitlab.com/slon/shad-go/distbuild/pkg/auth"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/grpcapi"
+)
+
+const token = "secret"
+
+type tlsEnv struct {
+	ca   *disttest.TestCA
+	addr string
+
+	build     *mock.MockService
+	heartbeat *mock.MockHeartbeatService
+}
+
+func newTLSEnv(t *testing.T) *tlsEnv {
+	ctrl := gomock.NewController(t)
+	l := zaptest.NewLogger(t)
+
+	env := &tlsEnv{
+		ca:        disttest.NewTestCA(t),
+		build:     mock.NewMockService(ctrl),
+		heartbeat: mock.NewMockHeartbeatService(ctrl),
+	}
+
+	lsn, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	env.addr = lsn.Addr().String()
+
+	opts := grpcapi.ServerAuth(l, []string{token})
+	opts = append(opts, grpc.Creds(credentials.NewTLS(auth.ServerConfig(env.ca.Issue(t, ""), env.ca.Pool()))))
+
+	server := grpc.NewServer(opts...)
+	grpcapi.RegisterBuildService(server, l, env.build)
+	grpcapi.RegisterHeartbeatService(server, l, env.heartbeat)
+
+	go func() { _ = server.Serve(lsn) }()
+	t.Cleanup(server.Stop)
+	return env
+}
+
+func (env *tlsEnv) dial(t *testing.T, cert *tls.Certificate, token string) *grpc.ClientConn {
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(auth.ClientConfig(cert, env.ca.Pool())))}
+	if token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(grpcapi.TokenCredentials(token)))
+	}
+
+	conn, err := grpc.Dial(env.addr, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestTokenAuth(t *testing.T) {
+	env := newTLSEnv(t)
+	ctx := context.Background()
+	l := zaptest.NewLogger(t)
+
+	req := &api.SignalRequest{Cancel: &api.Cancel{}}
+	env.build.EXPECT().SignalBuild(gomock.Any(), build.ID{01}, req).Return(&api.SignalResponse{}, nil)
+
+	client := grpcapi.NewBuildClient(l, env.dial(t, nil, token))
+	_, err := client.SignalBuild(ctx, build.ID{01}, req)
+	require.NoError(t, err)
+
+	for _, bad := range []string{"", "other"} {
+		client := grpcapi.NewBuildClient(l, env.dial(t, nil, bad))
+
+		_, err := client.SignalBuild(ctx, build.ID{01}, req)
+		require.Equal(t, codes.Unauthenticated, status.Code(err), "%v", err)
+
+		_, _, err = client.StartBuild(ctx, &api.BuildRequest{})
+		require.Equal(t, codes.Unauthenticated, status.Code(err), "%v", err)
+	}
+}
+
+func TestHeartbeatAuth(t *testing.T) {
+	env := newTLSEnv(t)
+	ctx := context.Background()
+	l := zaptest.NewLogger(t)
+
+	const workerID = "https://127.0.0.1/worker/0"
+
+	env.heartbeat.EXPECT().Heartbeat(gomock.Any(), &api.HeartbeatRequest{WorkerID: workerID}).Return(&api.HeartbeatResponse{}, nil)
+
+	cert := env.ca.Issue(t, workerID)
+	worker := grpcapi.NewHeartbeatClient(l, env.dial(t, &cert, ""))
+
+	_, err := worker.Heartbeat(ctx, &api.HeartbeatRequest{WorkerID: workerID})
+	require.NoError(t, err)
+
+	// Воркер не может прислать heartbeat от чужого имени.
+	_, err = worker.Heartbeat(ctx, &api.HeartbeatRequest{WorkerID: "https://127.0.0.1/worker/1"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err), "%v", err)
+
+	// Токен клиента сборки не заменяет сертификат.
+	client := grpcapi.NewHeartbeatClient(l, env.dial(t, nil, token))
+	_, err = client.Heartbeat(ctx, &api.HeartbeatRequest{WorkerID: workerID})
+	require.Equal(t, codes.PermissionDenied, status.Code(err), "%v", err)
+}
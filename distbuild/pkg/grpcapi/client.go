@@ -0,0 +1,151 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/grpcapi/apipb"
+)
+
+var ErrProtocol = errors.New("grpcapi: protocol error")
+
+// BuildClient - клиент api.Service поверх gRPC. Повторяет методы api.BuildClient.
+type BuildClient struct {
+	l      *zap.Logger
+	client apipb.BuildServiceClient
+}
+
+var _ api.BuildServiceClient = (*BuildClient)(nil)
+
+func NewBuildClient(l *zap.Logger, conn grpc.ClientConnInterface) *BuildClient {
+	return &BuildClient{l: l, client: apipb.NewBuildServiceClient(conn)}
+}
+
+type statusMessageStream interface {
+	Recv() (*apipb.StatusMessage, error)
+}
+
+// statusReader - это api.StatusReader поверх клиентского потока.
+type statusReader struct {
+	stream statusMessageStream
+	cancel context.CancelFunc
+}
+
+func (r *statusReader) Close() error {
+	r.cancel()
+	return nil
+}
+
+func (r *statusReader) Next() (*api.StatusUpdate, error) {
+	msg, err := r.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+
+	update, ok := msg.Status.(*apipb.StatusMessage_Update)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected status update", ErrProtocol)
+	}
+
+	var d decoder
+	u := d.statusUpdate(update.Update)
+	if d.err != nil {
+		return nil, d.err
+	}
+	return u, nil
+}
+
+// started дочитывает из потока первое сообщение BuildStarted.
+func started(stream statusMessageStream, cancel context.CancelFunc) (*api.BuildStarted, api.StatusReader, error) {
+	msg, err := stream.Recv()
+	if err == io.EOF {
+		err = fmt.Errorf("%w: stream closed before build started", ErrProtocol)
+	}
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	s, ok := msg.Status.(*apipb.StatusMessage_Started)
+	if !ok {
+		cancel()
+		return nil, nil, fmt.Errorf("%w: expected build started", ErrProtocol)
+	}
+
+	var d decoder
+	rsp := d.buildStarted(s.Started)
+	if d.err != nil {
+		cancel()
+		return nil, nil, d.err
+	}
+
+	return rsp, &statusReader{stream: stream, cancel: cancel}, nil
+}
+
+func (c *BuildClient) StartBuild(ctx context.Context, request *api.BuildRequest) (*api.BuildStarted, api.StatusReader, error) {
+	c.l.Debug("starting build", zap.Int("jobs", len(request.Graph.Jobs)))
+
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.client.StartBuild(ctx, buildRequestToProto(request))
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	return started(stream, cancel)
+}
+
+func (c *BuildClient) AttachBuild(ctx context.Context, buildID build.ID) (*api.BuildStarted, api.StatusReader, error) {
+	c.l.Debug("attaching to build", zap.String("build_id", buildID.String()))
+
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.client.AttachBuild(ctx, &apipb.AttachBuildRequest{BuildId: buildID[:]})
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	return started(stream, cancel)
+}
+
+func (c *BuildClient) SignalBuild(ctx context.Context, buildID build.ID, signal *api.SignalRequest) (*api.SignalResponse, error) {
+	_, err := c.client.SignalBuild(ctx, &apipb.SignalBuildRequest{BuildId: buildID[:], Signal: signalToProto(signal)})
+	if err != nil {
+		return nil, err
+	}
+	return &api.SignalResponse{}, nil
+}
+
+// HeartbeatClient - клиент api.HeartbeatService поверх gRPC. Повторяет методы api.HeartbeatClient.
+type HeartbeatClient struct {
+	l      *zap.Logger
+	client apipb.HeartbeatServiceClient
+}
+
+var _ api.HeartbeatService = (*HeartbeatClient)(nil)
+
+func NewHeartbeatClient(l *zap.Logger, conn grpc.ClientConnInterface) *HeartbeatClient {
+	return &HeartbeatClient{l: l, client: apipb.NewHeartbeatServiceClient(conn)}
+}
+
+func (c *HeartbeatClient) Heartbeat(ctx context.Context, req *api.HeartbeatRequest) (*api.HeartbeatResponse, error) {
+	rsp, err := c.client.Heartbeat(ctx, heartbeatRequestToProto(req))
+	if err != nil {
+		c.l.Warn("heartbeat failed", zap.Error(err))
+		return nil, err
+	}
+
+	var d decoder
+	r := d.heartbeatResponse(rsp)
+	if d.err != nil {
+		return nil, d.err
+	}
+	return r, nil
+}
@@ -0,0 +1,564 @@
+package grpcapi
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/grpcapi/apipb"
+	"gitlab.com/slon/shad-go/distbuild/pkg/verify"
+)
+
+// Конвертеры между типами api и apipb.
+//
+// Сообщения из сети разбирает decoder. Он запоминает первую ошибку, поэтому конвертеры не проверяют
+// ошибку после каждого поля, а вызывающий код смотрит на decoder.err в конце.
+
+func idToProto(id build.ID) []byte {
+	return append([]byte(nil), id[:]...)
+}
+
+func idsToProto(ids []build.ID) [][]byte {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	out := make([][]byte, len(ids))
+	for i, id := range ids {
+		out[i] = idToProto(id)
+	}
+	return out
+}
+
+func timeToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func workerIDsToProto(ids []api.WorkerID) []string {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = string(id)
+	}
+	return out
+}
+
+func sourceFilesToProto(files map[build.ID]string) []*apipb.SourceFile {
+	if len(files) == 0 {
+		return nil
+	}
+
+	out := make([]*apipb.SourceFile, 0, len(files))
+	for id, path := range files {
+		out = append(out, &apipb.SourceFile{Id: idToProto(id), Path: path})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return bytes.Compare(out[i].Id, out[j].Id) < 0
+	})
+	return out
+}
+
+func resourcesToProto(r build.Resources) *apipb.Resources {
+	if r == (build.Resources{}) {
+		return nil
+	}
+	return &apipb.Resources{Cpu: int64(r.CPU), Memory: r.Memory}
+}
+
+func cmdToProto(cmd *build.Cmd) *apipb.Cmd {
+	return &apipb.Cmd{
+		Exec:             cmd.Exec,
+		Environ:          cmd.Environ,
+		WorkingDirectory: cmd.WorkingDirectory,
+		CatTemplate:      cmd.CatTemplate,
+		CatOutput:        cmd.CatOutput,
+		CopySource:       cmd.CopySource,
+		CopyOutput:       cmd.CopyOutput,
+		SymlinkTarget:    cmd.SymlinkTarget,
+		SymlinkOutput:    cmd.SymlinkOutput,
+		MkdirOutput:      cmd.MkdirOutput,
+		EnvFileOutput:    cmd.EnvFileOutput,
+	}
+}
+
+func jobToProto(job *build.Job) *apipb.Job {
+	out := &apipb.Job{
+		Id:      idToProto(job.ID),
+		Name:    job.Name,
+		Inputs:  job.Inputs,
+		Deps:    idsToProto(job.Deps),
+		NoCache: job.NoCache,
+		Output:  job.Output,
+	}
+
+	for i := range job.Cmds {
+		out.Cmds = append(out.Cmds, cmdToProto(&job.Cmds[i]))
+	}
+
+	if req := job.Requirements; req.Resources != (build.Resources{}) || len(req.Labels) != 0 {
+		out.Requirements = &apipb.Requirements{Resources: resourcesToProto(req.Resources), Labels: req.Labels}
+	}
+
+	if retry := job.Retry; retry.MaxAttempts != 0 || len(retry.ExitCodes) != 0 || len(retry.Errors) != 0 {
+		out.Retry = &apipb.RetryPolicy{MaxAttempts: int64(retry.MaxAttempts), Errors: retry.Errors}
+		for _, code := range retry.ExitCodes {
+			out.Retry.ExitCodes = append(out.Retry.ExitCodes, int64(code))
+		}
+	}
+	return out
+}
+
+func graphToProto(g *build.Graph) *apipb.Graph {
+	out := &apipb.Graph{SourceFiles: sourceFilesToProto(g.SourceFiles)}
+	for i := range g.Jobs {
+		out.Jobs = append(out.Jobs, jobToProto(&g.Jobs[i]))
+	}
+	return out
+}
+
+func buildRequestToProto(req *api.BuildRequest) *apipb.BuildRequest {
+	return &apipb.BuildRequest{Graph: graphToProto(&req.Graph), Verify: req.Verify}
+}
+
+func buildStartedToProto(rsp *api.BuildStarted) *apipb.BuildStarted {
+	return &apipb.BuildStarted{Id: idToProto(rsp.ID), MissingFiles: idsToProto(rsp.MissingFiles)}
+}
+
+func jobOutputToProto(out *api.JobOutput) *apipb.JobOutput {
+	return &apipb.JobOutput{Id: idToProto(out.ID), Stdout: out.Stdout, Stderr: out.Stderr}
+}
+
+func jobTraceToProto(trace *api.JobTrace) *apipb.JobTrace {
+	out := &apipb.JobTrace{
+		WorkerId:       string(trace.WorkerID),
+		Slot:           int64(trace.Slot),
+		Scheduled:      timeToProto(trace.Scheduled),
+		Picked:         timeToProto(trace.Picked),
+		DepsDownloaded: timeToProto(trace.DepsDownloaded),
+		Committed:      timeToProto(trace.Committed),
+	}
+	for _, cmd := range trace.Cmds {
+		out.Cmds = append(out.Cmds, &apipb.CmdTrace{Start: timeToProto(cmd.Start), End: timeToProto(cmd.End)})
+	}
+	return out
+}
+
+func jobResultToProto(res *api.JobResult) *apipb.JobResult {
+	out := &apipb.JobResult{
+		Id:       idToProto(res.ID),
+		Stdout:   res.Stdout,
+		Stderr:   res.Stderr,
+		ExitCode: int64(res.ExitCode),
+		Error:    res.Error,
+		Cached:   res.Cached,
+	}
+	if res.Trace != nil {
+		out.Trace = jobTraceToProto(res.Trace)
+	}
+	if res.Digest != nil {
+		out.Digest = idToProto(*res.Digest)
+	}
+	return out
+}
+
+func jobResultsToProto(results []api.JobResult) []*apipb.JobResult {
+	var out []*apipb.JobResult
+	for i := range results {
+		out = append(out, jobResultToProto(&results[i]))
+	}
+	return out
+}
+
+func statusUpdateToProto(u *api.StatusUpdate) *apipb.StatusUpdate {
+	out := &apipb.StatusUpdate{}
+	if u.JobOutput != nil {
+		out.JobOutput = jobOutputToProto(u.JobOutput)
+	}
+	if u.JobFinished != nil {
+		out.JobFinished = jobResultToProto(u.JobFinished)
+	}
+	if u.JobFlaky != nil {
+		out.JobFlaky = &apipb.JobFlaky{Id: idToProto(u.JobFlaky.ID), Failures: jobResultsToProto(u.JobFlaky.Failures)}
+	}
+	if m := u.JobMismatch; m != nil {
+		out.JobMismatch = &apipb.JobMismatch{
+			Id:      idToProto(m.ID),
+			Workers: []string{string(m.Workers[0]), string(m.Workers[1])},
+		}
+		for _, d := range m.Diffs {
+			out.JobMismatch.Diffs = append(out.JobMismatch.Diffs, &apipb.FileDiff{Path: d.Path, Kind: string(d.Kind), Offset: d.Offset})
+		}
+	}
+	if u.BuildFailed != nil {
+		out.BuildFailed = &apipb.BuildFailed{Error: u.BuildFailed.Error, Cancelled: u.BuildFailed.Cancelled}
+	}
+	if u.BuildFinished != nil {
+		out.BuildFinished = &apipb.BuildFinished{}
+	}
+	return out
+}
+
+func signalToProto(signal *api.SignalRequest) *apipb.SignalRequest {
+	out := &apipb.SignalRequest{}
+	if signal.UploadDone != nil {
+		out.UploadDone = &apipb.UploadDone{}
+	}
+	if signal.Cancel != nil {
+		out.Cancel = &apipb.Cancel{}
+	}
+	return out
+}
+
+func heartbeatRequestToProto(req *api.HeartbeatRequest) *apipb.HeartbeatRequest {
+	out := &apipb.HeartbeatRequest{
+		WorkerId:         string(req.WorkerID),
+		RunningJobs:      idsToProto(req.RunningJobs),
+		FreeSlots:        int64(req.FreeSlots),
+		Capacity:         resourcesToProto(req.Capacity),
+		FreeResources:    resourcesToProto(req.FreeResources),
+		Labels:           req.Labels,
+		FinishedJob:      jobResultsToProto(req.FinishedJob),
+		AddedArtifacts:   idsToProto(req.AddedArtifacts),
+		RemovedArtifacts: idsToProto(req.RemovedArtifacts),
+	}
+	for i := range req.JobOutput {
+		out.JobOutput = append(out.JobOutput, jobOutputToProto(&req.JobOutput[i]))
+	}
+	return out
+}
+
+func jobSpecToProto(spec *api.JobSpec) *apipb.JobSpec {
+	out := &apipb.JobSpec{
+		SourceFiles:   sourceFilesToProto(spec.SourceFiles),
+		Verify:        spec.Verify,
+		ExcludeWorker: string(spec.ExcludeWorker),
+		Job:           jobToProto(&spec.Job),
+	}
+	if spec.Priority != 0 {
+		out.Priority = durationpb.New(spec.Priority)
+	}
+
+	for id, worker := range spec.Artifacts {
+		out.Artifacts = append(out.Artifacts, &apipb.Artifact{Id: idToProto(id), WorkerId: string(worker)})
+	}
+	sort.Slice(out.Artifacts, func(i, j int) bool {
+		return bytes.Compare(out.Artifacts[i].Id, out.Artifacts[j].Id) < 0
+	})
+	return out
+}
+
+func heartbeatResponseToProto(rsp *api.HeartbeatResponse) *apipb.HeartbeatResponse {
+	out := &apipb.HeartbeatResponse{
+		JobsToKill:   idsToProto(rsp.JobsToKill),
+		Peers:        workerIDsToProto(rsp.Peers),
+		Coordinator:  rsp.Coordinator,
+		PausedOutput: idsToProto(rsp.PausedOutput),
+	}
+
+	for _, spec := range rsp.JobsToRun {
+		out.JobsToRun = append(out.JobsToRun, jobSpecToProto(&spec))
+	}
+	sort.Slice(out.JobsToRun, func(i, j int) bool {
+		return bytes.Compare(out.JobsToRun[i].Job.Id, out.JobsToRun[j].Job.Id) < 0
+	})
+	return out
+}
+
+type decoder struct {
+	err error
+}
+
+func (d *decoder) fail(format string, args ...any) {
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: "+format, append([]any{ErrProtocol}, args...)...)
+	}
+}
+
+func (d *decoder) id(raw []byte) build.ID {
+	var id build.ID
+	if len(raw) != len(id) {
+		d.fail("invalid id size %d", len(raw))
+		return id
+	}
+
+	copy(id[:], raw)
+	return id
+}
+
+func (d *decoder) ids(raw [][]byte) []build.ID {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	out := make([]build.ID, len(raw))
+	for i, id := range raw {
+		out[i] = d.id(id)
+	}
+	return out
+}
+
+func (d *decoder) time(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	if err := t.CheckValid(); err != nil {
+		d.fail("%v", err)
+		return time.Time{}
+	}
+	return t.AsTime()
+}
+
+func workerIDsFromProto(ids []string) []api.WorkerID {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	out := make([]api.WorkerID, len(ids))
+	for i, id := range ids {
+		out[i] = api.WorkerID(id)
+	}
+	return out
+}
+
+func (d *decoder) sourceFiles(files []*apipb.SourceFile) map[build.ID]string {
+	if len(files) == 0 {
+		return nil
+	}
+
+	out := make(map[build.ID]string, len(files))
+	for _, f := range files {
+		out[d.id(f.Id)] = f.Path
+	}
+	return out
+}
+
+func resourcesFromProto(r *apipb.Resources) build.Resources {
+	return build.Resources{CPU: int(r.GetCpu()), Memory: r.GetMemory()}
+}
+
+func cmdFromProto(cmd *apipb.Cmd) build.Cmd {
+	return build.Cmd{
+		Exec:             cmd.Exec,
+		Environ:          cmd.Environ,
+		WorkingDirectory: cmd.WorkingDirectory,
+		CatTemplate:      cmd.CatTemplate,
+		CatOutput:        cmd.CatOutput,
+		CopySource:       cmd.CopySource,
+		CopyOutput:       cmd.CopyOutput,
+		SymlinkTarget:    cmd.SymlinkTarget,
+		SymlinkOutput:    cmd.SymlinkOutput,
+		MkdirOutput:      cmd.MkdirOutput,
+		EnvFileOutput:    cmd.EnvFileOutput,
+	}
+}
+
+func (d *decoder) job(job *apipb.Job) build.Job {
+	if job == nil {
+		d.fail("missing job")
+		return build.Job{}
+	}
+
+	out := build.Job{
+		ID:      d.id(job.Id),
+		Name:    job.Name,
+		Inputs:  job.Inputs,
+		Deps:    d.ids(job.Deps),
+		NoCache: job.NoCache,
+		Output:  job.Output,
+	}
+
+	for _, cmd := range job.Cmds {
+		out.Cmds = append(out.Cmds, cmdFromProto(cmd))
+	}
+
+	if req := job.Requirements; req != nil {
+		out.Requirements = build.Requirements{Resources: resourcesFromProto(req.Resources), Labels: req.Labels}
+	}
+
+	if retry := job.Retry; retry != nil {
+		out.Retry = build.RetryPolicy{MaxAttempts: int(retry.MaxAttempts), Errors: retry.Errors}
+		for _, code := range retry.ExitCodes {
+			out.Retry.ExitCodes = append(out.Retry.ExitCodes, int(code))
+		}
+	}
+	return out
+}
+
+func (d *decoder) graph(g *apipb.Graph) build.Graph {
+	out := build.Graph{SourceFiles: d.sourceFiles(g.GetSourceFiles())}
+	for _, job := range g.GetJobs() {
+		out.Jobs = append(out.Jobs, d.job(job))
+	}
+	return out
+}
+
+func (d *decoder) buildRequest(req *apipb.BuildRequest) *api.BuildRequest {
+	return &api.BuildRequest{Graph: d.graph(req.Graph), Verify: req.Verify}
+}
+
+func (d *decoder) buildStarted(rsp *apipb.BuildStarted) *api.BuildStarted {
+	return &api.BuildStarted{ID: d.id(rsp.Id), MissingFiles: d.ids(rsp.MissingFiles)}
+}
+
+func (d *decoder) jobOutput(out *apipb.JobOutput) api.JobOutput {
+	return api.JobOutput{ID: d.id(out.Id), Stdout: out.Stdout, Stderr: out.Stderr}
+}
+
+func (d *decoder) jobTrace(trace *apipb.JobTrace) *api.JobTrace {
+	out := &api.JobTrace{
+		WorkerID:       api.WorkerID(trace.WorkerId),
+		Slot:           int(trace.Slot),
+		Scheduled:      d.time(trace.Scheduled),
+		Picked:         d.time(trace.Picked),
+		DepsDownloaded: d.time(trace.DepsDownloaded),
+		Committed:      d.time(trace.Committed),
+	}
+	for _, cmd := range trace.Cmds {
+		out.Cmds = append(out.Cmds, api.CmdTrace{Start: d.time(cmd.Start), End: d.time(cmd.End)})
+	}
+	return out
+}
+
+func (d *decoder) jobResult(res *apipb.JobResult) api.JobResult {
+	out := api.JobResult{
+		ID:       d.id(res.Id),
+		Stdout:   res.Stdout,
+		Stderr:   res.Stderr,
+		ExitCode: int(res.ExitCode),
+		Error:    res.Error,
+		Cached:   res.Cached,
+	}
+	if res.Trace != nil {
+		out.Trace = d.jobTrace(res.Trace)
+	}
+	if len(res.Digest) != 0 {
+		digest := d.id(res.Digest)
+		out.Digest = &digest
+	}
+	return out
+}
+
+func (d *decoder) jobResults(results []*apipb.JobResult) []api.JobResult {
+	var out []api.JobResult
+	for _, res := range results {
+		out = append(out, d.jobResult(res))
+	}
+	return out
+}
+
+func (d *decoder) statusUpdate(u *apipb.StatusUpdate) *api.StatusUpdate {
+	out := &api.StatusUpdate{}
+	if u.JobOutput != nil {
+		jobOutput := d.jobOutput(u.JobOutput)
+		out.JobOutput = &jobOutput
+	}
+	if u.JobFinished != nil {
+		res := d.jobResult(u.JobFinished)
+		out.JobFinished = &res
+	}
+	if u.JobFlaky != nil {
+		out.JobFlaky = &api.JobFlaky{ID: d.id(u.JobFlaky.Id), Failures: d.jobResults(u.JobFlaky.Failures)}
+	}
+	if m := u.JobMismatch; m != nil {
+		if len(m.Workers) != 2 {
+			d.fail("mismatch must have 2 workers, got %d", len(m.Workers))
+		} else {
+			out.JobMismatch = &api.JobMismatch{
+				ID:      d.id(m.Id),
+				Workers: [2]api.WorkerID{api.WorkerID(m.Workers[0]), api.WorkerID(m.Workers[1])},
+			}
+			for _, diff := range m.Diffs {
+				out.JobMismatch.Diffs = append(out.JobMismatch.Diffs, verify.FileDiff{
+					Path:   diff.Path,
+					Kind:   verify.DiffKind(diff.Kind),
+					Offset: diff.Offset,
+				})
+			}
+		}
+	}
+	if u.BuildFailed != nil {
+		out.BuildFailed = &api.BuildFailed{Error: u.BuildFailed.Error, Cancelled: u.BuildFailed.Cancelled}
+	}
+	if u.BuildFinished != nil {
+		out.BuildFinished = &api.BuildFinished{}
+	}
+	return out
+}
+
+func signalFromProto(signal *apipb.SignalRequest) *api.SignalRequest {
+	out := &api.SignalRequest{}
+	if signal.GetUploadDone() != nil {
+		out.UploadDone = &api.UploadDone{}
+	}
+	if signal.GetCancel() != nil {
+		out.Cancel = &api.Cancel{}
+	}
+	return out
+}
+
+func (d *decoder) heartbeatRequest(req *apipb.HeartbeatRequest) *api.HeartbeatRequest {
+	out := &api.HeartbeatRequest{
+		WorkerID:         api.WorkerID(req.WorkerId),
+		RunningJobs:      d.ids(req.RunningJobs),
+		FreeSlots:        int(req.FreeSlots),
+		Capacity:         resourcesFromProto(req.Capacity),
+		FreeResources:    resourcesFromProto(req.FreeResources),
+		Labels:           req.Labels,
+		FinishedJob:      d.jobResults(req.FinishedJob),
+		AddedArtifacts:   d.ids(req.AddedArtifacts),
+		RemovedArtifacts: d.ids(req.RemovedArtifacts),
+	}
+	for _, jobOutput := range req.JobOutput {
+		out.JobOutput = append(out.JobOutput, d.jobOutput(jobOutput))
+	}
+	return out
+}
+
+func (d *decoder) jobSpec(spec *apipb.JobSpec) api.JobSpec {
+	out := api.JobSpec{
+		SourceFiles:   d.sourceFiles(spec.SourceFiles),
+		Priority:      spec.Priority.AsDuration(),
+		Verify:        spec.Verify,
+		ExcludeWorker: api.WorkerID(spec.ExcludeWorker),
+		Job:           d.job(spec.Job),
+	}
+
+	if len(spec.Artifacts) != 0 {
+		out.Artifacts = make(map[build.ID]api.WorkerID, len(spec.Artifacts))
+		for _, a := range spec.Artifacts {
+			out.Artifacts[d.id(a.Id)] = api.WorkerID(a.WorkerId)
+		}
+	}
+	return out
+}
+
+func (d *decoder) heartbeatResponse(rsp *apipb.HeartbeatResponse) *api.HeartbeatResponse {
+	out := &api.HeartbeatResponse{
+		JobsToKill:   d.ids(rsp.JobsToKill),
+		Peers:        workerIDsFromProto(rsp.Peers),
+		Coordinator:  rsp.Coordinator,
+		PausedOutput: d.ids(rsp.PausedOutput),
+	}
+
+	if len(rsp.JobsToRun) != 0 {
+		out.JobsToRun = make(map[build.ID]api.JobSpec, len(rsp.JobsToRun))
+		for _, spec := range rsp.JobsToRun {
+			s := d.jobSpec(spec)
+			out.JobsToRun[s.ID] = s
+		}
+	}
+	return out
+}
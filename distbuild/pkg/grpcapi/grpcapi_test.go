@@ -0,0 +1,339 @@
+package grpcapi_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	mock "gitlab.com/slon/shad-go/distbuild/pkg/api/mock"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/grpcapi"
+	"gitlab.com/slon/shad-go/distbuild/pkg/verify"
+)
+
+type env struct {
+	build     *mock.MockService
+	heartbeat *mock.MockHeartbeatService
+
+	client          *grpcapi.BuildClient
+	heartbeatClient *grpcapi.HeartbeatClient
+}
+
+func newEnv(t *testing.T) *env {
+	ctrl := gomock.NewController(t)
+	l := zaptest.NewLogger(t)
+
+	env := &env{
+		build:     mock.NewMockService(ctrl),
+		heartbeat: mock.NewMockHeartbeatService(ctrl),
+	}
+
+	lsn := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	grpcapi.RegisterBuildService(server, l, env.build)
+	grpcapi.RegisterHeartbeatService(server, l, env.heartbeat)
+
+	go func() { _ = server.Serve(lsn) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lsn.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	env.client = grpcapi.NewBuildClient(l, conn)
+	env.heartbeatClient = grpcapi.NewHeartbeatClient(l, conn)
+	return env
+}
+
+func TestBuildSignal(t *testing.T) {
+	env := newEnv(t)
+	ctx := context.Background()
+
+	req := &api.SignalRequest{Cancel: &api.Cancel{}}
+
+	env.build.EXPECT().SignalBuild(gomock.Any(), build.ID{01}, req).Return(&api.SignalResponse{}, nil)
+	env.build.EXPECT().SignalBuild(gomock.Any(), build.ID{02}, req).Return(nil, fmt.Errorf("foo bar error"))
+
+	rsp, err := env.client.SignalBuild(ctx, build.ID{01}, req)
+	require.NoError(t, err)
+	require.Equal(t, &api.SignalResponse{}, rsp)
+
+	_, err = env.client.SignalBuild(ctx, build.ID{02}, req)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "foo bar error")
+}
+
+func TestBuildStartError(t *testing.T) {
+	env := newEnv(t)
+
+	env.build.EXPECT().StartBuild(gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("foo bar error"))
+
+	_, _, err := env.client.StartBuild(context.Background(), &api.BuildRequest{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "foo bar error")
+}
+
+func TestBuildRunning(t *testing.T) {
+	env := newEnv(t)
+
+	req := &api.BuildRequest{
+		Graph: build.Graph{SourceFiles: map[build.ID]string{{01}: "a.txt"}},
+	}
+
+	started := &api.BuildStarted{ID: build.ID{02}, MissingFiles: []build.ID{{01}}}
+	finished := &api.StatusUpdate{BuildFinished: &api.BuildFinished{}}
+
+	env.build.EXPECT().StartBuild(gomock.Any(), req, gomock.Any()).
+		DoAndReturn(func(_ context.Context, req *api.BuildRequest, w api.StatusWriter) error {
+			if err := w.Started(started); err != nil {
+				return err
+			}
+
+			if err := w.Updated(finished); err != nil {
+				return err
+			}
+
+			return fmt.Errorf("foo bar error")
+		})
+
+	rsp, r, err := env.client.StartBuild(context.Background(), req)
+	require.NoError(t, err)
+	defer r.Close()
+
+	require.Equal(t, started, rsp)
+
+	u, err := r.Next()
+	require.NoError(t, err)
+	require.Equal(t, finished, u)
+
+	u, err = r.Next()
+	require.NoError(t, err)
+	require.Contains(t, u.BuildFailed.Error, "foo bar error")
+
+	_, err = r.Next()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestBuildCloseCancelsContext(t *testing.T) {
+	env := newEnv(t)
+
+	done := make(chan struct{})
+	env.build.EXPECT().StartBuild(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req *api.BuildRequest, w api.StatusWriter) error {
+			defer close(done)
+
+			if err := w.Started(&api.BuildStarted{}); err != nil {
+				return err
+			}
+
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+	_, r, err := env.client.StartBuild(context.Background(), &api.BuildRequest{})
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	<-done
+}
+
+func TestBuildAttach(t *testing.T) {
+	env := newEnv(t)
+	ctx := context.Background()
+
+	buildID := build.ID{02}
+	started := &api.BuildStarted{ID: buildID}
+	finished := &api.StatusUpdate{BuildFinished: &api.BuildFinished{}}
+
+	env.build.EXPECT().AttachBuild(gomock.Any(), buildID, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ build.ID, w api.StatusWriter) error {
+			if err := w.Started(started); err != nil {
+				return err
+			}
+
+			return w.Updated(finished)
+		})
+	env.build.EXPECT().AttachBuild(gomock.Any(), build.ID{03}, gomock.Any()).Return(fmt.Errorf("build not found"))
+
+	rsp, r, err := env.client.AttachBuild(ctx, buildID)
+	require.NoError(t, err)
+	defer r.Close()
+
+	require.Equal(t, started, rsp)
+
+	u, err := r.Next()
+	require.NoError(t, err)
+	require.Equal(t, finished, u)
+
+	_, err = r.Next()
+	require.Equal(t, io.EOF, err)
+
+	_, _, err = env.client.AttachBuild(ctx, build.ID{03})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "build not found")
+}
+
+func TestHeartbeat(t *testing.T) {
+	env := newEnv(t)
+	ctx := context.Background()
+
+	req := &api.HeartbeatRequest{WorkerID: "worker0", FreeSlots: 2, RunningJobs: []build.ID{{01}}}
+	rsp := &api.HeartbeatResponse{
+		JobsToRun: map[build.ID]api.JobSpec{
+			{02}: {Job: build.Job{ID: build.ID{02}, Name: "echo"}},
+		},
+		Peers: []api.WorkerID{"worker0"},
+	}
+
+	env.heartbeat.EXPECT().Heartbeat(gomock.Any(), req).Return(rsp, nil)
+	env.heartbeat.EXPECT().Heartbeat(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("foo bar error"))
+
+	actual, err := env.heartbeatClient.Heartbeat(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, rsp, actual)
+
+	_, err = env.heartbeatClient.Heartbeat(ctx, req)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "foo bar error")
+}
+
+// fullGraph заполняет все поля графа, чтобы проверить конвертацию в protobuf и обратно.
+var fullGraph = build.Graph{
+	SourceFiles: map[build.ID]string{{01}: "a.txt", {02}: "b/c.txt"},
+	Jobs: []build.Job{
+		{
+			ID:     build.ID{03},
+			Name:   "compile",
+			Inputs: []string{"a.txt"},
+			Deps:   []build.ID{{04}},
+			Cmds: []build.Cmd{
+				{Exec: []string{"cc", "a.c"}, Environ: []string{"A=B"}, WorkingDirectory: "/tmp"},
+				{CatTemplate: "OK", CatOutput: "{{.OutputDir}}/ok"},
+				{CopySource: "{{.SourceDir}}/a.txt", CopyOutput: "{{.OutputDir}}/a.txt"},
+				{SymlinkTarget: "a.txt", SymlinkOutput: "{{.OutputDir}}/link"},
+				{MkdirOutput: "{{.OutputDir}}/dir"},
+				{Environ: []string{"X=Y"}, EnvFileOutput: "{{.OutputDir}}/env"},
+			},
+			Requirements: build.Requirements{Resources: build.Resources{CPU: 2, Memory: 1 << 30}, Labels: []string{"os=linux"}},
+			NoCache:      true,
+			Retry:        build.RetryPolicy{MaxAttempts: 3, ExitCodes: []int{3}, Errors: []string{"oom"}},
+			Output:       "bin/app",
+		},
+	},
+}
+
+func TestBuildStatusUpdates(t *testing.T) {
+	env := newEnv(t)
+
+	errorString := "failed"
+	digest := build.ID{05}
+	start := time.Unix(100, 5).UTC()
+
+	req := &api.BuildRequest{Graph: fullGraph, Verify: true}
+	updates := []*api.StatusUpdate{
+		{JobOutput: &api.JobOutput{ID: build.ID{03}, Stdout: []byte("out"), Stderr: []byte("err")}},
+		{JobFlaky: &api.JobFlaky{ID: build.ID{03}, Failures: []api.JobResult{{ID: build.ID{03}, ExitCode: 3, Error: &errorString}}}},
+		{JobMismatch: &api.JobMismatch{
+			ID:      build.ID{03},
+			Workers: [2]api.WorkerID{"w0", "w1"},
+			Diffs:   []verify.FileDiff{{Path: "stamp", Kind: verify.DiffContent, Offset: 9}},
+		}},
+		{JobFinished: &api.JobResult{
+			ID:     build.ID{03},
+			Stdout: []byte("tail"),
+			Cached: true,
+			Digest: &digest,
+			Trace: &api.JobTrace{
+				WorkerID:       "w0",
+				Slot:           1,
+				Scheduled:      start,
+				Picked:         start.Add(time.Second),
+				DepsDownloaded: start.Add(2 * time.Second),
+				Cmds:           []api.CmdTrace{{Start: start.Add(3 * time.Second), End: start.Add(4 * time.Second)}},
+				Committed:      start.Add(5 * time.Second),
+			},
+		}},
+		{BuildFailed: &api.BuildFailed{Error: "cancelled", Cancelled: true}},
+		{BuildFinished: &api.BuildFinished{}},
+	}
+
+	env.build.EXPECT().StartBuild(gomock.Any(), req, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ *api.BuildRequest, w api.StatusWriter) error {
+			if err := w.Started(&api.BuildStarted{ID: build.ID{06}}); err != nil {
+				return err
+			}
+
+			for _, u := range updates {
+				if err := w.Updated(u); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	_, r, err := env.client.StartBuild(context.Background(), req)
+	require.NoError(t, err)
+	defer r.Close()
+
+	for _, expected := range updates {
+		u, err := r.Next()
+		require.NoError(t, err)
+		require.Equal(t, expected, u)
+	}
+}
+
+func TestHeartbeatAllFields(t *testing.T) {
+	env := newEnv(t)
+
+	req := &api.HeartbeatRequest{
+		WorkerID:         "worker0",
+		RunningJobs:      []build.ID{{01}},
+		FreeSlots:        1,
+		Capacity:         build.Resources{CPU: 4, Memory: 1 << 30},
+		FreeResources:    build.Resources{CPU: 2},
+		Labels:           []string{"os=linux"},
+		FinishedJob:      []api.JobResult{{ID: build.ID{02}, Stdout: []byte("OK")}},
+		JobOutput:        []api.JobOutput{{ID: build.ID{01}, Stdout: []byte("partial")}},
+		AddedArtifacts:   []build.ID{{02}},
+		RemovedArtifacts: []build.ID{{03}},
+	}
+
+	job := fullGraph.Jobs[0]
+	rsp := &api.HeartbeatResponse{
+		JobsToRun: map[build.ID]api.JobSpec{
+			job.ID: {
+				SourceFiles:   fullGraph.SourceFiles,
+				Artifacts:     map[build.ID]api.WorkerID{{04}: "worker1"},
+				Priority:      time.Minute,
+				Verify:        true,
+				ExcludeWorker: "worker2",
+				Job:           job,
+			},
+		},
+		JobsToKill:   []build.ID{{05}},
+		Peers:        []api.WorkerID{"worker0", "worker1"},
+		Coordinator:  "https://127.0.0.1/coordinator",
+		PausedOutput: []build.ID{{01}},
+	}
+
+	env.heartbeat.EXPECT().Heartbeat(gomock.Any(), req).Return(rsp, nil)
+
+	actual, err := env.heartbeatClient.Heartbeat(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, rsp, actual)
+}
@@ -0,0 +1,153 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/grpcapi/apipb"
+)
+
+// RegisterBuildService регистрирует service на сервере s.
+func RegisterBuildService(s grpc.ServiceRegistrar, l *zap.Logger, service api.Service) {
+	apipb.RegisterBuildServiceServer(s, &buildServer{l: l, service: service})
+}
+
+// RegisterHeartbeatService регистрирует service на сервере s.
+func RegisterHeartbeatService(s grpc.ServiceRegistrar, l *zap.Logger, service api.HeartbeatService) {
+	apipb.RegisterHeartbeatServiceServer(s, &heartbeatServer{l: l, service: service})
+}
+
+type buildServer struct {
+	apipb.UnimplementedBuildServiceServer
+
+	l       *zap.Logger
+	service api.Service
+}
+
+// statusStream - это api.StatusWriter поверх серверного потока.
+type statusStream struct {
+	stream  grpc.ServerStream
+	started bool
+}
+
+func (s *statusStream) send(msg *apipb.StatusMessage) error {
+	return s.stream.SendMsg(msg)
+}
+
+func (s *statusStream) Started(rsp *api.BuildStarted) error {
+	s.started = true
+	return s.send(&apipb.StatusMessage{Status: &apipb.StatusMessage_Started{Started: buildStartedToProto(rsp)}})
+}
+
+func (s *statusStream) Updated(update *api.StatusUpdate) error {
+	return s.send(&apipb.StatusMessage{Status: &apipb.StatusMessage_Update{Update: statusUpdateToProto(update)}})
+}
+
+// finish превращает ошибку сервиса в ответ на вызов.
+//
+// Пока клиент не получил BuildStarted, ошибка возвращается как статус вызова. После этого
+// ошибка передаётся в потоке через StatusUpdate.BuildFailed, так же как в HTTP транспорте.
+func (s *statusStream) finish(l *zap.Logger, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if !s.started {
+		return toStatus(err)
+	}
+
+	l.Warn("build failed after start", zap.Error(err))
+	if sendErr := s.Updated(&api.StatusUpdate{BuildFailed: &api.BuildFailed{Error: err.Error()}}); sendErr != nil {
+		return toStatus(err)
+	}
+	return nil
+}
+
+func (s *buildServer) StartBuild(req *apipb.BuildRequest, stream apipb.BuildService_StartBuildServer) error {
+	var d decoder
+	request := d.buildRequest(req)
+	if d.err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid build request: %v", d.err)
+	}
+
+	s.l.Debug("start build", zap.Int("jobs", len(request.Graph.Jobs)))
+
+	w := &statusStream{stream: stream}
+	return w.finish(s.l, s.service.StartBuild(stream.Context(), request, w))
+}
+
+func (s *buildServer) AttachBuild(req *apipb.AttachBuildRequest, stream apipb.BuildService_AttachBuildServer) error {
+	buildID, err := parseBuildID(req.BuildId)
+	if err != nil {
+		return err
+	}
+
+	s.l.Debug("attach build", zap.String("build_id", buildID.String()))
+
+	w := &statusStream{stream: stream}
+	return w.finish(s.l, s.service.AttachBuild(stream.Context(), buildID, w))
+}
+
+func (s *buildServer) SignalBuild(ctx context.Context, req *apipb.SignalBuildRequest) (*apipb.SignalResponse, error) {
+	buildID, err := parseBuildID(req.BuildId)
+	if err != nil {
+		return nil, err
+	}
+
+	s.l.Debug("signal build", zap.String("build_id", buildID.String()))
+
+	if _, err := s.service.SignalBuild(ctx, buildID, signalFromProto(req.Signal)); err != nil {
+		return nil, toStatus(err)
+	}
+	return &apipb.SignalResponse{}, nil
+}
+
+type heartbeatServer struct {
+	apipb.UnimplementedHeartbeatServiceServer
+
+	l       *zap.Logger
+	service api.HeartbeatService
+}
+
+func (s *heartbeatServer) Heartbeat(ctx context.Context, req *apipb.HeartbeatRequest) (*apipb.HeartbeatResponse, error) {
+	var d decoder
+	request := d.heartbeatRequest(req)
+	if d.err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid heartbeat: %v", d.err)
+	}
+
+	rsp, err := s.service.Heartbeat(ctx, request)
+	if err != nil {
+		s.l.Warn("heartbeat failed", zap.String("worker_id", request.WorkerID.String()), zap.Error(err))
+		return nil, toStatus(err)
+	}
+	return heartbeatResponseToProto(rsp), nil
+}
+
+func parseBuildID(raw []byte) (build.ID, error) {
+	var d decoder
+	id := d.id(raw)
+	if d.err != nil {
+		return id, status.Errorf(codes.InvalidArgument, "invalid build id: %v", d.err)
+	}
+	return id, nil
+}
+
+// toStatus передаёт текст ошибки сервиса клиенту. Ошибки отмены контекста сохраняют свой код.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Unknown, err.Error())
+	}
+}
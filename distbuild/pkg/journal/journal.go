@@ -0,0 +1,146 @@
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+// Record описывает одну запись журнала координатора.
+//
+// В каждой записи заполнено ровно одно поле.
+type Record struct {
+	BuildStarted     *BuildStarted     `json:",omitempty"`
+	JobFinished      *api.JobResult    `json:",omitempty"`
+	ArtifactsAdded   *ArtifactsAdded   `json:",omitempty"`
+	ArtifactsRemoved *ArtifactsRemoved `json:",omitempty"`
+	BuildFinished    *BuildFinished    `json:",omitempty"`
+}
+
+// BuildStarted записывается, когда координатор принял новый BuildRequest.
+type BuildStarted struct {
+	ID      build.ID
+	Request api.BuildRequest
+}
+
+// ArtifactsAdded записывается, когда воркер сообщил о новых артефактах в HeartbeatRequest.
+type ArtifactsAdded struct {
+	WorkerID  api.WorkerID
+	Artifacts []build.ID
+}
+
+// ArtifactsRemoved записывается, когда воркер сообщил о вытеснении артефактов в HeartbeatRequest.
+type ArtifactsRemoved struct {
+	WorkerID  api.WorkerID
+	Artifacts []build.ID
+}
+
+// BuildFinished записывается, когда сборка завершилась. Успешно или с ошибкой - не важно.
+type BuildFinished struct {
+	ID build.ID
+}
+
+// Journal - append-only журнал, который координатор пишет на диск.
+//
+// Записи хранятся в формате json, по одной записи на строку. Каждая запись
+// сбрасывается на диск до того, как Append вернёт управление.
+type Journal struct {
+	path string
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// Open открывает журнал по пути path, создавая файл если его нет.
+//
+// Если координатор упал посередине записи, последняя строка журнала окажется неполной.
+// Open отрезает такой хвост.
+func Open(path string) (*Journal, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := truncateTail(f); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("journal %s: %w", path, err)
+	}
+
+	return &Journal{path: path, f: f}, nil
+}
+
+func truncateTail(f *os.File) error {
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	size := int64(bytes.LastIndexByte(content, '\n') + 1)
+	if size != int64(len(content)) {
+		if err := f.Truncate(size); err != nil {
+			return err
+		}
+	}
+
+	_, err = f.Seek(size, io.SeekStart)
+	return err
+}
+
+// Append дописывает запись в конец журнала.
+func (j *Journal) Append(r *Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, err := j.f.Write(line); err != nil {
+		return err
+	}
+	return j.f.Sync()
+}
+
+// Replay вызывает fn для всех записей журнала в порядке их добавления.
+func (j *Journal) Replay(fn func(r *Record) error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	f, err := os.Open(j.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 1<<30)
+
+	for s.Scan() {
+		var r Record
+		if err := json.Unmarshal(s.Bytes(), &r); err != nil {
+			return fmt.Errorf("journal %s: invalid record: %w", j.path, err)
+		}
+
+		if err := fn(&r); err != nil {
+			return err
+		}
+	}
+
+	return s.Err()
+}
+
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.f.Close()
+}
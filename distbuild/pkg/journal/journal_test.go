@@ -0,0 +1,88 @@
+package journal_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/journal"
+)
+
+func newJournal(t *testing.T) (*journal.Journal, string) {
+	path := filepath.Join(t.TempDir(), "journal")
+
+	j, err := journal.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = j.Close() })
+
+	return j, path
+}
+
+func TestJournalRestore(t *testing.T) {
+	j, path := newJournal(t)
+
+	buildA, buildB := build.ID{'a'}, build.ID{'b'}
+	request := api.BuildRequest{
+		Graph: build.Graph{Jobs: []build.Job{{ID: build.ID{'j'}, Name: "echo"}}},
+	}
+
+	require.NoError(t, j.Append(&journal.Record{BuildStarted: &journal.BuildStarted{ID: buildA, Request: request}}))
+	require.NoError(t, j.Append(&journal.Record{BuildStarted: &journal.BuildStarted{ID: buildB}}))
+	require.NoError(t, j.Append(&journal.Record{JobFinished: &api.JobResult{ID: build.ID{'j'}, Stdout: []byte("OK")}}))
+	require.NoError(t, j.Append(&journal.Record{ArtifactsAdded: &journal.ArtifactsAdded{
+		WorkerID:  "w0",
+		Artifacts: []build.ID{{'j'}},
+	}}))
+	require.NoError(t, j.Append(&journal.Record{ArtifactsAdded: &journal.ArtifactsAdded{
+		WorkerID:  "w0",
+		Artifacts: []build.ID{{'j'}},
+	}}))
+	require.NoError(t, j.Append(&journal.Record{ArtifactsAdded: &journal.ArtifactsAdded{
+		WorkerID:  "w1",
+		Artifacts: []build.ID{{'j'}, {'k'}},
+	}}))
+	require.NoError(t, j.Append(&journal.Record{ArtifactsRemoved: &journal.ArtifactsRemoved{
+		WorkerID:  "w1",
+		Artifacts: []build.ID{{'k'}},
+	}}))
+	require.NoError(t, j.Append(&journal.Record{BuildFinished: &journal.BuildFinished{ID: buildB}}))
+	require.NoError(t, j.Close())
+
+	reopened, err := journal.Open(path)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	state, err := reopened.Restore()
+	require.NoError(t, err)
+
+	require.Equal(t, map[build.ID]*api.BuildRequest{buildA: &request}, state.Builds)
+	require.Equal(t, []byte("OK"), state.JobResults[build.ID{'j'}].Stdout)
+	require.Equal(t, map[build.ID][]api.WorkerID{{'j'}: {"w0", "w1"}}, state.Artifacts)
+}
+
+func TestJournalTruncatedTail(t *testing.T) {
+	j, path := newJournal(t)
+
+	require.NoError(t, j.Append(&journal.Record{BuildStarted: &journal.BuildStarted{ID: build.ID{'a'}}}))
+	require.NoError(t, j.Close())
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"BuildFinished":{"ID":`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reopened, err := journal.Open(path)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	require.NoError(t, reopened.Append(&journal.Record{BuildStarted: &journal.BuildStarted{ID: build.ID{'b'}}}))
+
+	state, err := reopened.Restore()
+	require.NoError(t, err)
+	require.Len(t, state.Builds, 2)
+}
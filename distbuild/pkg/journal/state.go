@@ -0,0 +1,90 @@
+package journal
+
+import (
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+// State описывает состояние координатора, восстановленное из журнала.
+type State struct {
+	// Builds содержит сборки, которые были начаты, но не завершились.
+	Builds map[build.ID]*api.BuildRequest
+
+	// JobResults содержит результаты всех завершённых джобов.
+	JobResults map[build.ID]*api.JobResult
+
+	// Artifacts для каждого артефакта перечисляет воркеров, на которых он был замечен.
+	//
+	// Эта информация может быть устаревшей. Воркер мог потерять артефакт, пока координатор
+	// был выключен.
+	Artifacts map[build.ID][]api.WorkerID
+}
+
+// Restore перечитывает журнал и восстанавливает по нему состояние координатора.
+func (j *Journal) Restore() (*State, error) {
+	s := &State{
+		Builds:     map[build.ID]*api.BuildRequest{},
+		JobResults: map[build.ID]*api.JobResult{},
+		Artifacts:  map[build.ID][]api.WorkerID{},
+	}
+
+	err := j.Replay(func(r *Record) error {
+		s.apply(r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *State) apply(r *Record) {
+	switch {
+	case r.BuildStarted != nil:
+		req := r.BuildStarted.Request
+		s.Builds[r.BuildStarted.ID] = &req
+
+	case r.JobFinished != nil:
+		s.JobResults[r.JobFinished.ID] = r.JobFinished
+
+	case r.ArtifactsAdded != nil:
+		for _, id := range r.ArtifactsAdded.Artifacts {
+			s.addArtifact(id, r.ArtifactsAdded.WorkerID)
+		}
+
+	case r.ArtifactsRemoved != nil:
+		for _, id := range r.ArtifactsRemoved.Artifacts {
+			s.removeArtifact(id, r.ArtifactsRemoved.WorkerID)
+		}
+
+	case r.BuildFinished != nil:
+		delete(s.Builds, r.BuildFinished.ID)
+	}
+}
+
+func (s *State) addArtifact(id build.ID, workerID api.WorkerID) {
+	for _, w := range s.Artifacts[id] {
+		if w == workerID {
+			return
+		}
+	}
+
+	s.Artifacts[id] = append(s.Artifacts[id], workerID)
+}
+
+func (s *State) removeArtifact(id build.ID, workerID api.WorkerID) {
+	workers := s.Artifacts[id]
+	for i, w := range workers {
+		if w == workerID {
+			workers = append(workers[:i], workers[i+1:]...)
+			break
+		}
+	}
+
+	if len(workers) == 0 {
+		delete(s.Artifacts, id)
+	} else {
+		s.Artifacts[id] = workers
+	}
+}
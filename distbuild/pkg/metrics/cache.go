@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/artifact"
+)
+
+type cacheCollector struct {
+	stats func() artifact.Stats
+
+	hits         *prometheus.Desc
+	misses       *prometheus.Desc
+	entries      *prometheus.Desc
+	size         *prometheus.Desc
+	addedBytes   *prometheus.Desc
+	evictions    *prometheus.Desc
+	evictedBytes *prometheus.Desc
+}
+
+// NewCacheCollector создаёт коллектор, который отдаёт статистику кеша.
+//
+// name попадает в метку cache и отличает кеши одного компонента друг от друга, например artifacts и files.
+// stats - это метод Stats у artifact.Cache или filecache.Cache.
+func NewCacheCollector(name string, stats func() artifact.Stats) prometheus.Collector {
+	labels := prometheus.Labels{"cache": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", metric), help, nil, labels)
+	}
+
+	return &cacheCollector{
+		stats: stats,
+
+		hits:         desc("hits_total", "Number of cache lookups that found an entry."),
+		misses:       desc("misses_total", "Number of cache lookups that found nothing."),
+		entries:      desc("entries", "Number of entries in cache."),
+		size:         desc("size_bytes", "Total size of entries in cache."),
+		addedBytes:   desc("added_bytes_total", "Total size of entries added to cache."),
+		evictions:    desc("evictions_total", "Number of entries evicted from cache."),
+		evictedBytes: desc("evicted_bytes_total", "Total size of entries evicted from cache."),
+	}
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.entries
+	ch <- c.size
+	ch <- c.addedBytes
+	ch <- c.evictions
+	ch <- c.evictedBytes
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Entries))
+	ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(stats.Size))
+	ch <- prometheus.MustNewConstMetric(c.addedBytes, prometheus.CounterValue, float64(stats.AddedBytes))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.evictedBytes, prometheus.CounterValue, float64(stats.EvictedBytes))
+}
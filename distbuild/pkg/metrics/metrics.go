@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "distbuild"
+
+// Path - путь, по которому компоненты отдают метрики.
+const Path = "/metrics"
+
+// Очереди шедулера. Подробности в README пакета scheduler.
+const (
+	TierGlobal = "global"
+	TierLocal1 = "local1"
+	TierLocal2 = "local2"
+)
+
+// Значения метки locality у гистограммы времени выполнения джобов.
+const (
+	LocalityHit  = "hit"
+	LocalityMiss = "miss"
+)
+
+// Locality возвращает значение метки locality для джоба, взятого из очереди tier.
+//
+// Джоб из локальной очереди попал на воркер, у которого уже были его артефакт или зависимости.
+func Locality(tier string) string {
+	if tier == TierGlobal {
+		return LocalityMiss
+	}
+	return LocalityHit
+}
+
+// NewRegistry создаёт реестр компонента с метриками Go рантайма и процесса.
+//
+// Каждый компонент использует свой реестр, поэтому несколько компонентов могут жить в одном процессе.
+func NewRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return reg
+}
+
+// Handler отдаёт метрики из реестра в текстовом формате Prometheus.
+func Handler(reg *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg})
+}
+
+// Scheduler содержит метрики шедулера.
+type Scheduler struct {
+	// QueueDepth - число джобов в очередях. Метка tier принимает значения TierGlobal, TierLocal1 и TierLocal2.
+	// Для локальных очередей значение суммируется по всем воркерам.
+	QueueDepth *prometheus.GaugeVec
+
+	// JobLatency - время от ScheduleJob до OnJobComplete. Метка locality показывает,
+	// из какой очереди воркер взял джоб (см. Locality).
+	JobLatency *prometheus.HistogramVec
+}
+
+func NewScheduler(reg prometheus.Registerer) *Scheduler {
+	f := promauto.With(reg)
+
+	return &Scheduler{
+		QueueDepth: f.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "queue_depth",
+			Help:      "Number of jobs waiting in scheduler queues.",
+		}, []string{"tier"}),
+
+		JobLatency: f.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "job_latency_seconds",
+			Help:      "Time from job scheduling to job completion.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 16),
+		}, []string{"locality"}),
+	}
+}
+
+// Coordinator содержит метрики координатора.
+type Coordinator struct {
+	// Workers - число живых воркеров.
+	Workers prometheus.Gauge
+
+	// FreeSlots - сумма HeartbeatRequest.FreeSlots по всем живым воркерам.
+	FreeSlots prometheus.Gauge
+}
+
+func NewCoordinator(reg prometheus.Registerer) *Coordinator {
+	f := promauto.With(reg)
+
+	return &Coordinator{
+		Workers: f.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "coordinator",
+			Name:      "workers",
+			Help:      "Number of live workers.",
+		}),
+
+		FreeSlots: f.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "coordinator",
+			Name:      "free_slots",
+			Help:      "Number of free job slots across all live workers.",
+		}),
+	}
+}
+
+// Worker содержит метрики воркера.
+type Worker struct {
+	// FreeSlots - число незанятых слотов воркера.
+	FreeSlots prometheus.Gauge
+
+	// HeartbeatRTT - время от отправки heartbeat-а до получения ответа координатора.
+	HeartbeatRTT prometheus.Histogram
+}
+
+func NewWorker(reg prometheus.Registerer) *Worker {
+	f := promauto.With(reg)
+
+	return &Worker{
+		FreeSlots: f.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "worker",
+			Name:      "free_slots",
+			Help:      "Number of free job slots.",
+		}),
+
+		HeartbeatRTT: f.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "worker",
+			Name:      "heartbeat_rtt_seconds",
+			Help:      "Round trip time of heartbeat requests to coordinator.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 16),
+		}),
+	}
+}
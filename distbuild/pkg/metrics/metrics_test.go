@@ -0,0 +1,83 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/artifact"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/metrics"
+)
+
+func TestCacheCollector(t *testing.T) {
+	cache, err := artifact.NewCache(t.TempDir())
+	require.NoError(t, err)
+
+	path, commit, _, err := cache.Create(build.ID{'a'})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(path, "out"), []byte("foo"), 0666))
+	require.NoError(t, commit())
+
+	_, unlock, err := cache.Get(build.ID{'a'})
+	require.NoError(t, err)
+	unlock()
+
+	_, _, err = cache.Get(build.ID{'b'})
+	require.Error(t, err)
+
+	expected := `
+# HELP distbuild_cache_hits_total Number of cache lookups that found an entry.
+# TYPE distbuild_cache_hits_total counter
+distbuild_cache_hits_total{cache="artifacts"} 1
+# HELP distbuild_cache_misses_total Number of cache lookups that found nothing.
+# TYPE distbuild_cache_misses_total counter
+distbuild_cache_misses_total{cache="artifacts"} 1
+# HELP distbuild_cache_size_bytes Total size of entries in cache.
+# TYPE distbuild_cache_size_bytes gauge
+distbuild_cache_size_bytes{cache="artifacts"} 3
+`
+
+	collector := metrics.NewCacheCollector("artifacts", cache.Stats)
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"distbuild_cache_hits_total",
+		"distbuild_cache_misses_total",
+		"distbuild_cache_size_bytes"))
+	require.Equal(t, 7, testutil.CollectAndCount(collector))
+}
+
+func TestHandler(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	s := metrics.NewScheduler(reg)
+	s.QueueDepth.WithLabelValues(metrics.TierGlobal).Set(3)
+	s.JobLatency.WithLabelValues(metrics.Locality(metrics.TierLocal1)).Observe(0.5)
+
+	c := metrics.NewCoordinator(reg)
+	c.Workers.Set(2)
+
+	mux := http.NewServeMux()
+	mux.Handle(metrics.Path, metrics.Handler(reg))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	rsp, err := http.Get(server.URL + metrics.Path)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+
+	require.Contains(t, string(body), `distbuild_scheduler_queue_depth{tier="global"} 3`)
+	require.Contains(t, string(body), `distbuild_scheduler_job_latency_seconds_count{locality="hit"} 1`)
+	require.Contains(t, string(body), `distbuild_coordinator_workers 2`)
+	require.Contains(t, string(body), `go_goroutines`)
+}
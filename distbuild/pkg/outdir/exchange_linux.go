@@ -0,0 +1,18 @@
+//go:build linux
+
+package outdir
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// exchange атомарно меняет местами src и dst. Возвращает false, если файловая система не поддерживает обмен.
+func exchange(src, dst string) (bool, error) {
+	err := unix.Renameat2(unix.AT_FDCWD, src, unix.AT_FDCWD, dst, unix.RENAME_EXCHANGE)
+	if errors.Is(err, unix.EINVAL) || errors.Is(err, unix.ENOSYS) {
+		return false, nil
+	}
+	return err == nil, err
+}
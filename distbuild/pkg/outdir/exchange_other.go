@@ -0,0 +1,8 @@
+//go:build !linux
+
+package outdir
+
+// exchange не поддерживается вне linux, Replace использует два rename.
+func exchange(src, dst string) (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,73 @@
+package outdir
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// TempDir создаёт временную директорию рядом с dst.
+//
+// Временная директория лежит на той же файловой системе, что и dst, поэтому её содержимое
+// можно переместить на место dst через Replace.
+func TempDir(dst string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+		return "", err
+	}
+
+	return os.MkdirTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp-")
+}
+
+// Replace атомарно заменяет dst на src. src и dst должны лежать на одной файловой системе.
+//
+// Читатель dst видит либо старое содержимое целиком, либо новое целиком, но никогда не их смесь.
+// На linux замена делается одним вызовом renameat2(RENAME_EXCHANGE). На других системах и файловых
+// системах без его поддержки dst сначала переносится в сторону, поэтому на короткое время dst отсутствует.
+//
+// Если Replace вернул ошибку, dst остаётся нетронутым. Ошибки удаления старого содержимого после подмены
+// игнорируются: dst к этому моменту уже заменён, а остатки лежат в скрытой директории рядом с dst.
+func Replace(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+		return err
+	}
+
+	if _, err := os.Lstat(dst); os.IsNotExist(err) {
+		return os.Rename(src, dst)
+	} else if err != nil {
+		return err
+	}
+
+	exchanged, err := exchange(src, dst)
+	if err != nil {
+		return err
+	}
+
+	if exchanged {
+		// После обмена в src лежит старое содержимое dst.
+		_ = os.RemoveAll(src)
+		return nil
+	}
+
+	return replaceByRename(src, dst)
+}
+
+func replaceByRename(src, dst string) error {
+	tmp, err := os.MkdirTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".old-")
+	if err != nil {
+		return err
+	}
+
+	old := filepath.Join(tmp, "old")
+	if err := os.Rename(dst, old); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(src, dst); err != nil {
+		_ = os.Rename(old, dst)
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	_ = os.RemoveAll(tmp)
+	return nil
+}
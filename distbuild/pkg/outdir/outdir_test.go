@@ -0,0 +1,121 @@
+package outdir_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/outdir"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0777))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0666))
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestReplaceNew(t *testing.T) {
+	out := t.TempDir()
+	dst := filepath.Join(out, "bin", "app")
+
+	src, err := outdir.TempDir(dst)
+	require.NoError(t, err)
+	writeFile(t, filepath.Join(src, "app"), "v1")
+
+	require.NoError(t, outdir.Replace(src, dst))
+	require.Equal(t, "v1", readFile(t, filepath.Join(dst, "app")))
+	require.Equal(t, []string{"app"}, listDir(t, filepath.Join(out, "bin")))
+}
+
+func TestReplaceExisting(t *testing.T) {
+	out := t.TempDir()
+	dst := filepath.Join(out, "app")
+
+	writeFile(t, filepath.Join(dst, "app"), "v1")
+	writeFile(t, filepath.Join(dst, "stale"), "v1")
+
+	src, err := outdir.TempDir(dst)
+	require.NoError(t, err)
+	writeFile(t, filepath.Join(src, "app"), "v2")
+
+	require.NoError(t, outdir.Replace(src, dst))
+	require.Equal(t, "v2", readFile(t, filepath.Join(dst, "app")))
+	require.NoFileExists(t, filepath.Join(dst, "stale"))
+
+	// Ни временных директорий, ни старого содержимого не осталось.
+	require.Equal(t, []string{"app"}, listDir(t, out))
+}
+
+func TestReplaceFile(t *testing.T) {
+	out := t.TempDir()
+	dst := filepath.Join(out, "app")
+	writeFile(t, dst, "not a directory")
+
+	src, err := outdir.TempDir(dst)
+	require.NoError(t, err)
+	writeFile(t, filepath.Join(src, "app"), "v2")
+
+	require.NoError(t, outdir.Replace(src, dst))
+	require.Equal(t, "v2", readFile(t, filepath.Join(dst, "app")))
+}
+
+func TestReplaceMissingSource(t *testing.T) {
+	out := t.TempDir()
+	dst := filepath.Join(out, "app")
+	writeFile(t, filepath.Join(dst, "app"), "v1")
+
+	require.Error(t, outdir.Replace(filepath.Join(out, "missing"), dst))
+	require.Equal(t, "v1", readFile(t, filepath.Join(dst, "app")))
+	require.Equal(t, []string{"app"}, listDir(t, out))
+}
+
+func TestReplaceIgnoresCleanupError(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root ignores directory permissions")
+	}
+
+	out := t.TempDir()
+	dst := filepath.Join(out, "app")
+
+	// Старое содержимое нельзя удалить: файл лежит в директории без права записи.
+	locked := filepath.Join(dst, "locked")
+	writeFile(t, filepath.Join(locked, "app"), "v1")
+	require.NoError(t, os.Chmod(locked, 0555))
+	t.Cleanup(func() {
+		_ = filepath.Walk(out, func(path string, info os.FileInfo, err error) error {
+			if err == nil && info.IsDir() {
+				_ = os.Chmod(path, 0777)
+			}
+			return nil
+		})
+	})
+
+	src, err := outdir.TempDir(dst)
+	require.NoError(t, err)
+	writeFile(t, filepath.Join(src, "app"), "v2")
+
+	require.NoError(t, outdir.Replace(src, dst))
+	require.Equal(t, "v2", readFile(t, filepath.Join(dst, "app")))
+}
@@ -0,0 +1,150 @@
+package remotecache
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/filecache"
+)
+
+// Client хранит файлы на сервере remotecache.Handler или любом другом сервере с той же раскладкой /cas/.
+//
+// Файлы хранятся в /cas/, поэтому ID файла должен совпадать с sha1 его содержимого. Download проверяет
+// sha1 скачанного содержимого и не кладёт в локальный кеш файл, который не совпадает с ключом.
+type Client struct {
+	l        *zap.Logger
+	endpoint string
+	client   *http.Client
+}
+
+var _ filecache.Remote = (*Client)(nil)
+
+func NewClient(l *zap.Logger, endpoint string) *Client {
+	return &Client{
+		l:        l,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   http.DefaultClient,
+	}
+}
+
+// retryDelay задаёт паузу перед повтором запроса, который сервер отклонил из-за
+// параллельной записи того же ключа.
+const retryDelay = time.Millisecond * 10
+
+func (c *Client) do(ctx context.Context, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	for {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		rsp, err := c.client.Do(req.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		if rsp.StatusCode != http.StatusConflict {
+			return rsp, nil
+		}
+		_ = rsp.Body.Close()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+func checkResponse(rsp *http.Response) error {
+	if rsp.StatusCode == http.StatusOK || rsp.StatusCode == http.StatusCreated || rsp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(rsp.Body, 1024))
+	if rsp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", filecache.ErrNotFound, strings.TrimSpace(string(msg)))
+	}
+	return fmt.Errorf("remote cache: %s: %s", rsp.Status, strings.TrimSpace(string(msg)))
+}
+
+func (c *Client) Upload(ctx context.Context, id build.ID, localPath string) error {
+	c.l.Debug("uploading file", zap.Stringer("id", id), zap.String("path", localPath))
+
+	url := c.endpoint + "/cas/" + id.String()
+	rsp, err := c.do(ctx, func() (*http.Request, error) {
+		f, err := os.Open(localPath)
+		if err != nil {
+			return nil, err
+		}
+
+		st, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+
+		req, err := http.NewRequest(http.MethodPut, url, f)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		req.ContentLength = st.Size()
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	return checkResponse(rsp)
+}
+
+func (c *Client) Download(ctx context.Context, localCache *filecache.Cache, id build.ID) error {
+	c.l.Debug("downloading file", zap.Stringer("id", id))
+
+	url := c.endpoint + "/cas/" + id.String()
+	rsp, err := c.do(ctx, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, url, nil)
+	})
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if err := checkResponse(rsp); err != nil {
+		return err
+	}
+
+	w, abort, err := localCache.Write(id)
+	if errors.Is(err, filecache.ErrExists) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	hash := sha1.New()
+	if _, err := io.Copy(io.MultiWriter(w, hash), rsp.Body); err != nil {
+		_ = abort()
+		return err
+	}
+
+	// Сервер мог отдать чужое содержимое, такой файл нельзя коммитить под ключом id.
+	if sum := hash.Sum(nil); string(sum) != string(id[:]) {
+		_ = abort()
+		return fmt.Errorf("%w: got %s", errDigestMismatch, hex.EncodeToString(sum))
+	}
+
+	return w.Close()
+}
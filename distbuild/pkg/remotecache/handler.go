@@ -0,0 +1,179 @@
+package remotecache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/artifact"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/filecache"
+	"gitlab.com/slon/shad-go/distbuild/pkg/tarstream"
+)
+
+// Handler отдаёт filecache.Cache и artifact.Cache в раскладке URL Bazel HTTP remote cache.
+// Ключи и формат артефактов остаются своими, поэтому Bazel с этим сервером работать не может.
+//
+//	/cas/<sha1> - файлы из filecache.Cache. Ключ обязан совпадать с sha1 содержимого.
+//	/ac/<id>    - артефакты из artifact.Cache в формате tarstream.
+//
+// Поддерживаются методы GET, HEAD и PUT.
+type Handler struct {
+	l         *zap.Logger
+	files     *filecache.Cache
+	artifacts *artifact.Cache
+}
+
+func NewHandler(l *zap.Logger, files *filecache.Cache, artifacts *artifact.Cache) *Handler {
+	return &Handler{l: l, files: files, artifacts: artifacts}
+}
+
+func (h *Handler) Register(mux *http.ServeMux) {
+	if h.files != nil {
+		mux.HandleFunc("/cas/", h.cas)
+	}
+
+	if h.artifacts != nil {
+		mux.HandleFunc("/ac/", h.ac)
+	}
+}
+
+// parseKey разбирает ключ как build.ID. Ключи другой длины, например sha256, получают 400.
+func parseKey(w http.ResponseWriter, r *http.Request, prefix string) (build.ID, bool) {
+	var id build.ID
+	if err := id.UnmarshalText([]byte(strings.TrimPrefix(r.URL.Path, prefix))); err != nil {
+		http.Error(w, fmt.Sprintf("invalid key: %v", err), http.StatusBadRequest)
+		return id, false
+	}
+	return id, true
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, filecache.ErrNotFound), errors.Is(err, artifact.ErrNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, filecache.ErrWriteLocked), errors.Is(err, artifact.ErrWriteLocked):
+		code = http.StatusConflict
+	case errors.Is(err, errDigestMismatch):
+		code = http.StatusBadRequest
+	default:
+		h.l.Warn("remote cache request failed",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Error(err))
+	}
+
+	http.Error(w, err.Error(), code)
+}
+
+func (h *Handler) cas(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseKey(w, r, "/cas/")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		path, unlock, err := h.files.Get(id)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		defer unlock()
+
+		http.ServeFile(w, r, path)
+
+	case http.MethodPut:
+		if err := h.putFile(id, r.Body); err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+var errDigestMismatch = errors.New("content digest does not match the key")
+
+func (h *Handler) putFile(id build.ID, body io.Reader) error {
+	f, abort, err := h.files.Write(id)
+	if errors.Is(err, filecache.ErrExists) {
+		_, _ = io.Copy(io.Discard, body)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	hash := sha1.New()
+	if _, err := io.Copy(io.MultiWriter(f, hash), body); err != nil {
+		_ = abort()
+		return err
+	}
+
+	if sum := hash.Sum(nil); string(sum) != string(id[:]) {
+		_ = abort()
+		return fmt.Errorf("%w: got %s", errDigestMismatch, hex.EncodeToString(sum))
+	}
+
+	return f.Close()
+}
+
+func (h *Handler) ac(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseKey(w, r, "/ac/")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		dir, unlock, err := h.artifacts.Get(id)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		defer unlock()
+
+		w.Header().Set("Content-Type", "application/x-tar")
+		if r.Method == http.MethodHead {
+			return
+		}
+
+		if err := tarstream.Send(dir, w); err != nil {
+			h.l.Warn("failed to send artifact", zap.Stringer("id", id), zap.Error(err))
+		}
+
+	case http.MethodPut:
+		if err := h.putArtifact(id, r.Body); err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) putArtifact(id build.ID, body io.Reader) error {
+	dir, commit, abort, err := h.artifacts.Create(id)
+	if errors.Is(err, artifact.ErrExists) {
+		_, _ = io.Copy(io.Discard, body)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := tarstream.Receive(dir, body); err != nil {
+		_ = abort()
+		return err
+	}
+
+	return commit()
+}
@@ -0,0 +1,213 @@
+package remotecache_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/artifact"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/filecache"
+	"gitlab.com/slon/shad-go/distbuild/pkg/remotecache"
+	"gitlab.com/slon/shad-go/distbuild/pkg/tarstream"
+)
+
+type env struct {
+	files     *filecache.Cache
+	artifacts *artifact.Cache
+	server    *httptest.Server
+	client    *remotecache.Client
+}
+
+func newEnv(t *testing.T) *env {
+	l := zaptest.NewLogger(t)
+
+	files, err := filecache.New(t.TempDir())
+	require.NoError(t, err)
+
+	artifacts, err := artifact.NewCache(t.TempDir())
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	remotecache.NewHandler(l, files, artifacts).Register(mux)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return &env{
+		files:     files,
+		artifacts: artifacts,
+		server:    server,
+		client:    remotecache.NewClient(l, server.URL),
+	}
+}
+
+func put(t *testing.T, url string, body []byte) int {
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(body))
+	require.NoError(t, err)
+
+	rsp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+
+	return rsp.StatusCode
+}
+
+func get(t *testing.T, url string) (int, []byte) {
+	rsp, err := http.Get(url)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	return rsp.StatusCode, body
+}
+
+func TestCAS(t *testing.T) {
+	env := newEnv(t)
+
+	content := []byte("foobar")
+	id := build.ID(sha1.Sum(content))
+	url := env.server.URL + "/cas/" + id.String()
+
+	code, _ := get(t, url)
+	require.Equal(t, http.StatusNotFound, code)
+
+	require.Equal(t, http.StatusBadRequest, put(t, url, []byte("corrupted")))
+	require.Equal(t, http.StatusOK, put(t, url, content))
+	require.Equal(t, http.StatusOK, put(t, url, content))
+
+	code, body := get(t, url)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, content, body)
+
+	code, _ = get(t, env.server.URL+"/cas/not-a-digest")
+	require.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestClient(t *testing.T) {
+	env := newEnv(t)
+	ctx := context.Background()
+
+	content := bytes.Repeat([]byte("foobar"), 1024)
+	id := build.ID(sha1.Sum(content))
+
+	localPath := filepath.Join(t.TempDir(), "foo.txt")
+	require.NoError(t, os.WriteFile(localPath, content, 0666))
+
+	require.NoError(t, env.client.Upload(ctx, id, localPath))
+
+	localCache, err := filecache.New(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, env.client.Download(ctx, localCache, id))
+
+	path, unlock, err := localCache.Get(id)
+	require.NoError(t, err)
+	defer unlock()
+
+	downloaded, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, content, downloaded)
+
+	err = env.client.Download(ctx, localCache, build.ID{0x01})
+	require.Truef(t, errors.Is(err, filecache.ErrNotFound), "%v", err)
+}
+
+func TestClientDigestMismatch(t *testing.T) {
+	env := newEnv(t)
+	ctx := context.Background()
+
+	// Кладём в кеш сервера содержимое под чужим ключом, минуя проверку в хендлере.
+	id := build.ID(sha1.Sum([]byte("foo")))
+	w, _, err := env.files.Write(id)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("bar"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	localCache, err := filecache.New(t.TempDir())
+	require.NoError(t, err)
+
+	require.Error(t, env.client.Download(ctx, localCache, id))
+
+	_, _, err = localCache.Get(id)
+	require.Truef(t, errors.Is(err, filecache.ErrNotFound), "%v", err)
+}
+
+func TestCacheRemote(t *testing.T) {
+	env := newEnv(t)
+
+	content := []byte("foobar")
+	id := build.ID(sha1.Sum(content))
+
+	writer, err := filecache.New(t.TempDir())
+	require.NoError(t, err)
+	writer.SetRemote(env.client)
+
+	w, _, err := writer.Write(id)
+	require.NoError(t, err)
+	_, err = w.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	code, body := get(t, env.server.URL+"/cas/"+id.String())
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, content, body)
+
+	reader, err := filecache.New(t.TempDir())
+	require.NoError(t, err)
+	reader.SetRemote(env.client)
+
+	path, unlock, err := reader.Get(id)
+	require.NoError(t, err)
+	defer unlock()
+
+	downloaded, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, content, downloaded)
+
+	_, _, err = reader.Get(build.ID{0x01})
+	require.Truef(t, errors.Is(err, filecache.ErrNotFound), "%v", err)
+}
+
+func TestAC(t *testing.T) {
+	env := newEnv(t)
+
+	id := build.ID{0x01}
+	url := env.server.URL + "/ac/" + id.String()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "out.txt"), []byte("OK"), 0666))
+
+	var tar bytes.Buffer
+	require.NoError(t, tarstream.Send(dir, &tar))
+	require.Equal(t, http.StatusOK, put(t, url, tar.Bytes()))
+
+	path, unlock, err := env.artifacts.Get(id)
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(path, "out.txt"))
+	unlock()
+	require.NoError(t, err)
+	require.Equal(t, []byte("OK"), content)
+
+	code, body := get(t, url)
+	require.Equal(t, http.StatusOK, code)
+
+	received := t.TempDir()
+	require.NoError(t, tarstream.Receive(received, bytes.NewReader(body)))
+
+	content, err = os.ReadFile(filepath.Join(received, "out.txt"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("OK"), content)
+}
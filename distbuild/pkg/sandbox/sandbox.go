@@ -0,0 +1,46 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultSystemDirs перечисляет каталоги хоста, которые видны в песочнице только на чтение.
+//
+// Без них внутри песочницы нельзя запустить ни шелл, ни компилятор.
+var DefaultSystemDirs = []string{"/bin", "/sbin", "/usr", "/lib", "/lib32", "/lib64"}
+
+// Config задаёт ограничения песочницы.
+type Config struct {
+	// CPUTime ограничивает процессорное время джоба. Ноль означает отсутствие ограничения.
+	//
+	// Ограничение выставляется через RLIMIT_CPU и округляется вверх до секунды.
+	CPUTime time.Duration
+
+	// Memory ограничивает размер памяти джоба в байтах. Ноль означает отсутствие ограничения.
+	//
+	// Ограничение выставляется через RLIMIT_DATA.
+	Memory int64
+
+	// SystemDirs перечисляет каталоги хоста, доступные джобу на чтение. Если поле
+	// равно nil, используется DefaultSystemDirs.
+	SystemDirs []string
+}
+
+var ErrNotSupported = errors.New("sandbox is not supported on this platform")
+
+// LimitError означает, что джоб был остановлен из-за превышения ограничения песочницы.
+type LimitError struct {
+	// Limit называет ограничение: "cpu time" или "memory".
+	Limit string
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("sandbox: %s limit exceeded", e.Limit)
+}
+
+const (
+	LimitCPUTime = "cpu time"
+	LimitMemory  = "memory"
+)
@@ -0,0 +1,388 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+const (
+	// initArg передаётся в os.Args[0] процессу, который должен подготовить песочницу.
+	initArg = "distbuild-sandbox-init"
+
+	// specEnv содержит описание песочницы для процесса initArg.
+	specEnv = "DISTBUILD_SANDBOX_SPEC"
+
+	// errorFD - номер pipe, через который init сообщает об ошибке подготовки песочницы.
+	errorFD = 3
+)
+
+// Sandbox запускает команды джобов в изолированном окружении.
+//
+// Команда запускается в отдельных mount, network, ipc и uts namespace-ах. Внутри песочницы
+// видны только SystemDirs и SourceDir с артефактами зависимостей на чтение, OutputDir на запись
+// и пустые /tmp и /dev. Сети внутри песочницы нет.
+//
+// Песочница перезапускает текущий бинарь, поэтому программа, использующая Sandbox, должна вызывать
+// Init в самом начале main.
+type Sandbox struct {
+	config Config
+}
+
+func New(config Config) *Sandbox {
+	if config.SystemDirs == nil {
+		config.SystemDirs = DefaultSystemDirs
+	}
+	return &Sandbox{config: config}
+}
+
+type mountSpec struct {
+	Path     string
+	Writable bool
+}
+
+type initSpec struct {
+	Root   string
+	Mounts []mountSpec
+	Dir    string
+
+	// Path - исполняемый файл команды. Команды без / в Argv[0] ищутся в PATH воркера ещё до
+	// входа в песочницу, как это делает os/exec без песочницы: PATH джоба обычно пуст.
+	Path    string
+	Argv    []string
+	Env     []string
+	CPUTime uint64
+	Memory  uint64
+}
+
+func (s *Sandbox) mounts(jobCtx build.JobContext) []mountSpec {
+	var mounts []mountSpec
+	for _, dir := range s.config.SystemDirs {
+		if _, err := os.Stat(dir); err == nil {
+			mounts = append(mounts, mountSpec{Path: dir})
+		}
+	}
+
+	if jobCtx.SourceDir != "" {
+		mounts = append(mounts, mountSpec{Path: jobCtx.SourceDir})
+	}
+	for _, dir := range jobCtx.Deps {
+		mounts = append(mounts, mountSpec{Path: dir})
+	}
+	mounts = append(mounts, mountSpec{Path: jobCtx.OutputDir, Writable: true})
+
+	// Родительские каталоги монтируются раньше вложенных, иначе они закроют вложенные mount-ы.
+	sort.SliceStable(mounts, func(i, j int) bool {
+		return len(mounts[i].Path) < len(mounts[j].Path)
+	})
+	return mounts
+}
+
+// Run выполняет отрендеренную команду cmd в песочнице.
+//
+// Run возвращает код выхода команды. Если команда была остановлена из-за превышения ограничения,
+// Run возвращает *LimitError.
+func (s *Sandbox) Run(ctx context.Context, jobCtx build.JobContext, cmd *build.Cmd, stdout, stderr io.Writer) (int, error) {
+	if len(cmd.Exec) == 0 {
+		return 0, fmt.Errorf("sandbox: empty command")
+	}
+
+	path := cmd.Exec[0]
+	if !strings.Contains(path, "/") {
+		var err error
+		if path, err = exec.LookPath(path); err != nil {
+			return 0, err
+		}
+	}
+
+	root, err := os.MkdirTemp("", "distbuild-sandbox-")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = os.RemoveAll(root) }()
+
+	spec := initSpec{
+		Root:   root,
+		Mounts: s.mounts(jobCtx),
+		Dir:    cmd.WorkingDirectory,
+		Path:   path,
+		Argv:   cmd.Exec,
+		Env:    cmd.Environ,
+	}
+	if spec.Dir == "" {
+		spec.Dir = jobCtx.OutputDir
+	}
+	if s.config.CPUTime > 0 {
+		spec.CPUTime = uint64(math.Ceil(s.config.CPUTime.Seconds()))
+	}
+	if s.config.Memory > 0 {
+		spec.Memory = uint64(s.config.Memory)
+	}
+
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return 0, err
+	}
+
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		return 0, err
+	}
+	defer errR.Close()
+
+	c := exec.CommandContext(ctx, "/proc/self/exe")
+	c.Args = []string{initArg}
+	c.Env = []string{specEnv + "=" + string(specJSON)}
+	c.Stdout = stdout
+	c.Stderr = stderr
+	c.ExtraFiles = []*os.File{errW}
+	c.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWNS | syscall.CLONE_NEWNET | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+		Pdeathsig:  syscall.SIGKILL,
+	}
+
+	if os.Geteuid() != 0 {
+		c.SysProcAttr.Cloneflags |= syscall.CLONE_NEWUSER
+		c.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Geteuid(), Size: 1}}
+		c.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getegid(), Size: 1}}
+		c.SysProcAttr.GidMappingsEnableSetgroups = false
+	}
+
+	err = c.Start()
+	_ = errW.Close()
+	if err != nil {
+		return 0, fmt.Errorf("sandbox: %w", err)
+	}
+
+	initErr, _ := io.ReadAll(errR)
+	err = c.Wait()
+
+	if len(initErr) != 0 {
+		return 0, fmt.Errorf("sandbox: %s", initErr)
+	}
+
+	// Отменённую команду убивает SIGKILL, который не должен выглядеть как нарушение ограничения.
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	if limitErr := s.checkLimits(c.ProcessState); limitErr != nil {
+		return 0, limitErr
+	}
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return 0, err
+	}
+
+	return c.ProcessState.ExitCode(), nil
+}
+
+// checkLimits определяет, было ли завершение процесса вызвано ограничением песочницы.
+func (s *Sandbox) checkLimits(state *os.ProcessState) error {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || state.Success() {
+		return nil
+	}
+
+	if s.config.CPUTime > 0 && status.Signaled() {
+		// Ядро посылает SIGXCPU при достижении мягкого лимита и SIGKILL при достижении жёсткого.
+		if status.Signal() == syscall.SIGXCPU {
+			return &LimitError{Limit: LimitCPUTime}
+		}
+		if status.Signal() == syscall.SIGKILL && state.UserTime()+state.SystemTime() >= s.config.CPUTime {
+			return &LimitError{Limit: LimitCPUTime}
+		}
+	}
+
+	// Превысив RLIMIT_DATA, процесс получает ENOMEM от аллокатора. Ядро не сообщает, почему процесс упал,
+	// поэтому в лимит упёрся только процесс, убитый сигналом, которым обычно заканчивается нехватка памяти,
+	// и чей пиковый RSS превысил половину лимита. RLIMIT_DATA ограничивает выделенную, а не занятую память,
+	// поэтому RSS до самого лимита обычно не доходит. Обычный ненулевой код выхода нарушением не считается.
+	if s.config.Memory > 0 && status.Signaled() {
+		switch status.Signal() {
+		case syscall.SIGKILL, syscall.SIGSEGV, syscall.SIGABRT:
+			rusage, ok := state.SysUsage().(*syscall.Rusage)
+			if ok && rusage.Maxrss*1024 >= s.config.Memory/2 {
+				return &LimitError{Limit: LimitMemory}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Init подготавливает песочницу, если текущий процесс был запущен из Sandbox.Run.
+//
+// Init должен вызываться в начале main. Если процесс был запущен из Sandbox.Run, Init
+// никогда не возвращает управление. Иначе Init сразу возвращается.
+func Init() {
+	if len(os.Args) == 0 || os.Args[0] != initArg {
+		return
+	}
+
+	errPipe := os.NewFile(errorFD, "sandbox-error")
+	syscall.CloseOnExec(errorFD)
+
+	err := runInit()
+	_, _ = fmt.Fprint(errPipe, err)
+	os.Exit(1)
+}
+
+func runInit() error {
+	var spec initSpec
+	if err := json.Unmarshal([]byte(os.Getenv(specEnv)), &spec); err != nil {
+		return fmt.Errorf("invalid spec: %w", err)
+	}
+
+	if err := setupRoot(&spec); err != nil {
+		return err
+	}
+
+	if err := setupLimits(&spec); err != nil {
+		return err
+	}
+
+	if err := os.Chdir(spec.Dir); err != nil {
+		return err
+	}
+
+	os.Clearenv()
+	for _, kv := range spec.Env {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			_ = os.Setenv(k, v)
+		}
+	}
+
+	return syscall.Exec(spec.Path, spec.Argv, spec.Env)
+}
+
+func setupRoot(spec *initSpec) error {
+	// Все изменения mount-ов не должны протекать в namespace хоста.
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make / private: %w", err)
+	}
+
+	root := spec.Root
+	if err := unix.Mount("tmpfs", root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=0755"); err != nil {
+		return fmt.Errorf("mount root: %w", err)
+	}
+
+	tmp := filepath.Join(root, "tmp")
+	if err := os.Mkdir(tmp, 0777); err != nil {
+		return err
+	}
+	if err := unix.Mount("tmpfs", tmp, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=1777"); err != nil {
+		return fmt.Errorf("mount /tmp: %w", err)
+	}
+
+	for _, m := range spec.Mounts {
+		if err := bindMount(m.Path, filepath.Join(root, m.Path), m.Writable); err != nil {
+			return fmt.Errorf("mount %s: %w", m.Path, err)
+		}
+	}
+
+	for _, dev := range []string{"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"} {
+		if err := bindMount(dev, filepath.Join(root, dev), true); err != nil {
+			return fmt.Errorf("mount %s: %w", dev, err)
+		}
+	}
+
+	if err := os.Chdir(root); err != nil {
+		return err
+	}
+	if err := unix.PivotRoot(".", "."); err != nil {
+		return fmt.Errorf("pivot_root: %w", err)
+	}
+	if err := unix.Unmount(".", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("unmount old root: %w", err)
+	}
+	if err := os.Chdir("/"); err != nil {
+		return err
+	}
+
+	if err := unix.Mount("", "/", "", unix.MS_REMOUNT|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("remount / read-only: %w", err)
+	}
+	return nil
+}
+
+func bindMount(source, target string, writable bool) error {
+	st, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+
+	if st.IsDir() {
+		err = os.MkdirAll(target, 0755)
+	} else {
+		if err = os.MkdirAll(filepath.Dir(target), 0755); err == nil {
+			err = os.WriteFile(target, nil, 0644)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := unix.Mount(source, target, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return err
+	}
+
+	if writable {
+		return nil
+	}
+
+	// Внутри user namespace перемонтирование не может снять флаги исходного mount-а, поэтому их нужно сохранить.
+	var fs unix.Statfs_t
+	if err := unix.Statfs(source, &fs); err != nil {
+		return err
+	}
+
+	flags := uintptr(unix.MS_BIND | unix.MS_REMOUNT | unix.MS_RDONLY)
+	for _, f := range []struct{ st, ms uintptr }{
+		{unix.ST_NOSUID, unix.MS_NOSUID},
+		{unix.ST_NODEV, unix.MS_NODEV},
+		{unix.ST_NOEXEC, unix.MS_NOEXEC},
+		{unix.ST_NOATIME, unix.MS_NOATIME},
+		{unix.ST_NODIRATIME, unix.MS_NODIRATIME},
+		{unix.ST_RELATIME, unix.MS_RELATIME},
+	} {
+		if uintptr(fs.Flags)&f.st != 0 {
+			flags |= f.ms
+		}
+	}
+
+	return unix.Mount("", target, "", flags, "")
+}
+
+func setupLimits(spec *initSpec) error {
+	if spec.CPUTime != 0 {
+		limit := unix.Rlimit{Cur: spec.CPUTime, Max: spec.CPUTime + 1}
+		if err := unix.Setrlimit(unix.RLIMIT_CPU, &limit); err != nil {
+			return fmt.Errorf("set cpu limit: %w", err)
+		}
+	}
+
+	if spec.Memory != 0 {
+		limit := unix.Rlimit{Cur: spec.Memory, Max: spec.Memory}
+		if err := unix.Setrlimit(unix.RLIMIT_DATA, &limit); err != nil {
+			return fmt.Errorf("set memory limit: %w", err)
+		}
+	}
+	return nil
+}
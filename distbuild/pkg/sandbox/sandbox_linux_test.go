@@ -0,0 +1,200 @@
+//go:build linux
+
+package sandbox_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/sandbox"
+)
+
+const helperEnv = "SANDBOX_TEST_HELPER"
+
+func TestMain(m *testing.M) {
+	sandbox.Init()
+
+	switch os.Getenv(helperEnv) {
+	case "":
+		os.Exit(m.Run())
+	case "alloc":
+		var chunks [][]byte
+		for {
+			chunk := bytes.Repeat([]byte{1}, 16<<20)
+			chunks = append(chunks, chunk)
+		}
+	case "dial":
+		conn, err := net.DialTimeout("tcp", os.Args[1], time.Second)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		_ = conn.Close()
+	}
+}
+
+type env struct {
+	jobCtx build.JobContext
+	self   string
+}
+
+func newEnv(t *testing.T) *env {
+	self, err := os.Executable()
+	require.NoError(t, err)
+
+	return &env{
+		jobCtx: build.JobContext{
+			SourceDir: t.TempDir(),
+			OutputDir: t.TempDir(),
+			Deps: map[build.ID]string{
+				{'a'}: t.TempDir(),
+			},
+		},
+		self: self,
+	}
+}
+
+func (e *env) run(t *testing.T, config sandbox.Config, cmd *build.Cmd) (int, string, error) {
+	config.SystemDirs = append(append([]string{}, sandbox.DefaultSystemDirs...), filepath.Dir(e.self))
+
+	var out bytes.Buffer
+	exitCode, err := sandbox.New(config).Run(context.Background(), e.jobCtx, cmd, &out, &out)
+	if err != nil && errors.Is(err, os.ErrPermission) {
+		t.Skipf("sandbox requires namespaces: %v", err)
+	}
+	return exitCode, out.String(), err
+}
+
+func sh(script string) *build.Cmd {
+	return &build.Cmd{Exec: []string{"/bin/sh", "-c", script}}
+}
+
+func TestSandboxFilesystem(t *testing.T) {
+	e := newEnv(t)
+
+	secret := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secret, []byte("secret"), 0666))
+	require.NoError(t, os.WriteFile(filepath.Join(e.jobCtx.SourceDir, "a.txt"), []byte("A"), 0666))
+	require.NoError(t, os.WriteFile(filepath.Join(e.jobCtx.Deps[build.ID{'a'}], "b.txt"), []byte("B"), 0666))
+
+	script := fmt.Sprintf(`
+		test ! -e %[1]s || exit 10
+		touch %[2]s/new && exit 11
+		touch %[3]s/new && exit 12
+		cat %[2]s/a.txt %[3]s/b.txt > out.txt
+		echo tmp > /tmp/tmp.txt
+	`, secret, e.jobCtx.SourceDir, e.jobCtx.Deps[build.ID{'a'}])
+
+	exitCode, out, err := e.run(t, sandbox.Config{}, sh(script))
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode, out)
+
+	content, err := os.ReadFile(filepath.Join(e.jobCtx.OutputDir, "out.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "AB", string(content))
+}
+
+func TestSandboxPathLookup(t *testing.T) {
+	e := newEnv(t)
+
+	// У джоба нет Environ, поэтому echo ищется в PATH воркера, как и без песочницы.
+	exitCode, out, err := e.run(t, sandbox.Config{}, &build.Cmd{Exec: []string{"echo", "OK"}})
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode, out)
+	require.Equal(t, "OK\n", out)
+}
+
+func TestSandboxNetwork(t *testing.T) {
+	e := newEnv(t)
+
+	lsn, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lsn.Close()
+
+	cmd := &build.Cmd{
+		Exec:    []string{e.self, lsn.Addr().String()},
+		Environ: []string{helperEnv + "=dial"},
+	}
+
+	exitCode, out, err := e.run(t, sandbox.Config{}, cmd)
+	require.NoError(t, err)
+	require.Equal(t, 1, exitCode, out)
+}
+
+func TestSandboxCPULimit(t *testing.T) {
+	e := newEnv(t)
+
+	_, _, err := e.run(t, sandbox.Config{CPUTime: time.Second}, sh("while :; do :; done"))
+
+	var limitErr *sandbox.LimitError
+	require.True(t, errors.As(err, &limitErr), "%v", err)
+	require.Equal(t, sandbox.LimitCPUTime, limitErr.Limit)
+}
+
+func TestSandboxMemoryLimit(t *testing.T) {
+	e := newEnv(t)
+
+	cmd := &build.Cmd{
+		Exec: []string{e.self},
+		// С GOTRACEBACK=crash рантайм Go завершается по SIGABRT, а не с кодом 2, когда кончается память.
+		Environ: []string{helperEnv + "=alloc", "GOTRACEBACK=crash"},
+	}
+
+	_, _, err := e.run(t, sandbox.Config{Memory: 256 << 20}, cmd)
+
+	var limitErr *sandbox.LimitError
+	require.True(t, errors.As(err, &limitErr), "%v", err)
+	require.Equal(t, sandbox.LimitMemory, limitErr.Limit)
+	require.Contains(t, limitErr.Error(), "memory limit exceeded")
+}
+
+func TestSandboxExitCode(t *testing.T) {
+	e := newEnv(t)
+
+	exitCode, out, err := e.run(t, sandbox.Config{CPUTime: time.Minute, Memory: 1 << 30}, sh("echo OK; exit 3"))
+	require.NoError(t, err)
+	require.Equal(t, 3, exitCode)
+	require.Equal(t, "OK\n", out)
+}
+
+func TestSandboxFailureIsNotLimit(t *testing.T) {
+	e := newEnv(t)
+
+	cmd := &build.Cmd{
+		Exec:    []string{e.self},
+		Environ: []string{helperEnv + "=alloc"},
+	}
+
+	// Без GOTRACEBACK=crash рантайм Go завершается с кодом 2, и песочница не может отличить это от обычной ошибки.
+	exitCode, _, err := e.run(t, sandbox.Config{Memory: 256 << 20}, cmd)
+	require.NoError(t, err)
+	require.Equal(t, 2, exitCode)
+}
+
+func TestSandboxCancelIsNotLimit(t *testing.T) {
+	e := newEnv(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*1500)
+	defer cancel()
+
+	config := sandbox.Config{CPUTime: time.Second}
+	config.SystemDirs = append(append([]string{}, sandbox.DefaultSystemDirs...), filepath.Dir(e.self))
+
+	// Процесс игнорирует мягкий лимит, и отмена контекста убивает его после мягкого, но до жёсткого лимита.
+	_, err := sandbox.New(config).Run(ctx, e.jobCtx, sh("trap '' XCPU; while :; do :; done"), io.Discard, io.Discard)
+	if err != nil && errors.Is(err, os.ErrPermission) {
+		t.Skipf("sandbox requires namespaces: %v", err)
+	}
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,25 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"context"
+	"io"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+// Sandbox запускает команды джобов в изолированном окружении. Песочница поддерживается только на linux.
+type Sandbox struct {
+	config Config
+}
+
+func New(config Config) *Sandbox {
+	return &Sandbox{config: config}
+}
+
+func (s *Sandbox) Run(ctx context.Context, jobCtx build.JobContext, cmd *build.Cmd, stdout, stderr io.Writer) (int, error) {
+	return 0, ErrNotSupported
+}
+
+func Init() {}
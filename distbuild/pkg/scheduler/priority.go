@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+// DurationHistory хранит оценку длительности джобов с одинаковым Name.
+//
+// Оценка - экспоненциальное скользящее среднее по всем наблюдениям.
+type DurationHistory struct {
+	defaultDuration time.Duration
+
+	mu        sync.Mutex
+	durations map[string]time.Duration
+}
+
+// historyWeight задаёт вес последнего наблюдения в скользящем среднем.
+const historyWeight = 0.3
+
+// NewDurationHistory создаёт пустую историю. Для джобов, которые ещё ни разу не
+// выполнялись, Estimate возвращает defaultDuration.
+func NewDurationHistory(defaultDuration time.Duration) *DurationHistory {
+	return &DurationHistory{
+		defaultDuration: defaultDuration,
+		durations:       map[string]time.Duration{},
+	}
+}
+
+func (h *DurationHistory) Observe(name string, d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	prev, ok := h.durations[name]
+	if !ok {
+		h.durations[name] = d
+		return
+	}
+
+	h.durations[name] = prev + time.Duration(historyWeight*float64(d-prev))
+}
+
+func (h *DurationHistory) Estimate(name string) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if d, ok := h.durations[name]; ok {
+		return d
+	}
+	return h.defaultDuration
+}
+
+// CriticalPath вычисляет приоритет каждого джоба графа.
+//
+// Приоритет джоба - оценка длины самого длинного пути от этого джоба до стока графа,
+// то есть до джоба, от которого никто не зависит. Длина пути включает длительность самого джоба.
+// Чем выше приоритет, тем раньше джоб нужно запустить, чтобы не задерживать всю сборку.
+func CriticalPath(jobs []build.Job, h *DurationHistory) map[build.ID]time.Duration {
+	dependents := map[build.ID][]build.ID{}
+	for _, job := range jobs {
+		for _, dep := range job.Deps {
+			dependents[dep] = append(dependents[dep], job.ID)
+		}
+	}
+
+	priority := make(map[build.ID]time.Duration, len(jobs))
+
+	sorted := build.TopSort(jobs)
+	for i := len(sorted) - 1; i >= 0; i-- {
+		job := &sorted[i]
+
+		var longestTail time.Duration
+		for _, d := range dependents[job.ID] {
+			longestTail = max(longestTail, priority[d])
+		}
+
+		priority[job.ID] = h.Estimate(job.Name) + longestTail
+	}
+
+	return priority
+}
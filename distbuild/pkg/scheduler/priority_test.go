@@ -0,0 +1,193 @@
+package scheduler_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/scheduler"
+)
+
+func TestDurationHistory(t *testing.T) {
+	h := scheduler.NewDurationHistory(time.Second)
+
+	require.Equal(t, time.Second, h.Estimate("link"))
+
+	h.Observe("link", time.Minute)
+	require.Equal(t, time.Minute, h.Estimate("link"))
+
+	h.Observe("link", 0)
+	require.Less(t, h.Estimate("link"), time.Minute)
+	require.Greater(t, h.Estimate("link"), time.Duration(0))
+}
+
+func TestCriticalPath(t *testing.T) {
+	h := scheduler.NewDurationHistory(time.Second)
+	h.Observe("link", time.Minute)
+
+	// a <- b <- link
+	//   <- c
+	jobs := []build.Job{
+		{ID: build.ID{'l'}, Name: "link", Deps: []build.ID{{'b'}}},
+		{ID: build.ID{'c'}, Name: "vet", Deps: []build.ID{{'a'}}},
+		{ID: build.ID{'b'}, Name: "compile b", Deps: []build.ID{{'a'}}},
+		{ID: build.ID{'a'}, Name: "compile a"},
+	}
+
+	priority := scheduler.CriticalPath(jobs, h)
+	require.Equal(t, map[build.ID]time.Duration{
+		{'l'}: time.Minute,
+		{'b'}: time.Minute + time.Second,
+		{'c'}: time.Second,
+		{'a'}: time.Minute + 2*time.Second,
+	}, priority)
+}
+
+// syntheticGraph строит граф из длинной цепочки медленных джобов и множества
+// быстрых независимых джобов. Быстрые джобы идут в графе первыми.
+func syntheticGraph() ([]build.Job, *scheduler.DurationHistory) {
+	h := scheduler.NewDurationHistory(time.Second)
+	h.Observe("link", 10*time.Second)
+
+	var jobs []build.Job
+	for i := 0; i < 200; i++ {
+		jobs = append(jobs, build.Job{ID: build.ID{'t', byte(i)}, Name: "test"})
+	}
+
+	var prev *build.ID
+	for i := 0; i < 20; i++ {
+		job := build.Job{ID: build.ID{'l', byte(i)}, Name: "link"}
+		if prev != nil {
+			job.Deps = []build.ID{*prev}
+		}
+		jobs = append(jobs, job)
+		prev = &job.ID
+	}
+
+	return jobs, h
+}
+
+// immediately заменяет time.After, чтобы CacheTimeout и DepsTimeout истекали сразу и джобы без артефактов
+// попадали в глобальную очередь без ожидания.
+func immediately(time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- time.Time{}
+	return ch
+}
+
+// makespan моделирует выполнение графа на workers воркерах и возвращает время выполнения всей сборки.
+//
+// Джобы проходят через настоящий шедулер: готовый к запуску джоб попадает в ScheduleJob с приоритетом из
+// priority, а освободившийся воркер забирает следующий джоб через PickJob. Время модельное, длительность джоба
+// берётся из h. Воркеры сразу забывают артефакты, поэтому порядок выдачи определяется только приоритетами.
+func makespan(tb testing.TB, jobs []build.Job, h *scheduler.DurationHistory, workers int, priority map[build.ID]time.Duration) time.Duration {
+	s := scheduler.NewScheduler(zap.NewNop(), scheduler.Config{}, immediately)
+	defer s.Stop()
+
+	var free []api.WorkerID
+	for i := 0; i < workers; i++ {
+		id := api.WorkerID(fmt.Sprintf("w%d", i))
+		s.RegisterWorker(id)
+		free = append(free, id)
+	}
+
+	type running struct {
+		worker api.WorkerID
+		job    *build.Job
+		end    time.Duration
+	}
+
+	finished := map[build.ID]bool{}
+	scheduled := map[build.ID]bool{}
+	queued := 0
+
+	var now time.Duration
+	var active []running
+
+	for len(finished) < len(jobs) {
+		for i := range jobs {
+			job := &jobs[i]
+			if scheduled[job.ID] {
+				continue
+			}
+
+			ready := true
+			for _, dep := range job.Deps {
+				ready = ready && finished[dep]
+			}
+
+			if ready {
+				s.ScheduleJob(&api.JobSpec{Job: *job, Priority: priority[job.ID]})
+				scheduled[job.ID] = true
+				queued++
+			}
+		}
+
+		for ; queued > 0 && len(free) > 0; queued-- {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			pending := s.PickJob(ctx, free[0])
+			cancel()
+			require.NotNil(tb, pending)
+
+			job := &pending.Job.Job
+			active = append(active, running{worker: free[0], job: job, end: now + h.Estimate(job.Name)})
+			free = free[1:]
+		}
+
+		first := 0
+		for i := range active {
+			if active[i].end < active[first].end {
+				first = i
+			}
+		}
+
+		done := active[first]
+		active = append(active[:first], active[first+1:]...)
+
+		now = done.end
+		s.OnJobComplete(done.worker, done.job.ID, &api.JobResult{ID: done.job.ID})
+		s.OnArtifactRemoved(done.worker, done.job.ID)
+		finished[done.job.ID] = true
+		free = append(free, done.worker)
+	}
+
+	return now
+}
+
+func TestCriticalPathMakespan(t *testing.T) {
+	jobs, h := syntheticGraph()
+
+	fifo := makespan(t, jobs, h, 4, nil)
+	critical := makespan(t, jobs, h, 4, scheduler.CriticalPath(jobs, h))
+
+	require.Equal(t, 250*time.Second, fifo)
+	require.Equal(t, 200*time.Second, critical)
+}
+
+func BenchmarkMakespan(b *testing.B) {
+	jobs, h := syntheticGraph()
+
+	for _, workers := range []int{2, 4, 8} {
+		b.Run(fmt.Sprintf("fifo/workers=%d", workers), func(b *testing.B) {
+			var d time.Duration
+			for i := 0; i < b.N; i++ {
+				d = makespan(b, jobs, h, workers, nil)
+			}
+			b.ReportMetric(d.Seconds(), "makespan-s")
+		})
+
+		b.Run(fmt.Sprintf("critical_path/workers=%d", workers), func(b *testing.B) {
+			var d time.Duration
+			for i := 0; i < b.N; i++ {
+				d = makespan(b, jobs, h, workers, scheduler.CriticalPath(jobs, h))
+			}
+			b.ReportMetric(d.Seconds(), "makespan-s")
+		})
+	}
+}
@@ -10,17 +10,34 @@ import (
 
 	"gitlab.com/slon/shad-go/distbuild/pkg/api"
 	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/metrics"
 )
 
 type PendingJob struct {
 	Job      *api.JobSpec
 	Finished chan struct{}
 	Result   *api.JobResult
+
+	// Failures содержит результаты упавших попыток, после которых джоб был перезапущен.
+	Failures []api.JobResult
 }
 
 type Config struct {
 	CacheTimeout time.Duration
 	DepsTimeout  time.Duration
+
+	// Metrics задаёт метрики шедулера. Если Metrics == nil, шедулер не собирает метрики.
+	//
+	// Шедулер поддерживает QueueDepth равным числу джобов в каждой из очередей и записывает
+	// в JobLatency время от ScheduleJob до OnJobComplete для каждого джоба.
+	Metrics *metrics.Scheduler
+}
+
+// WorkerInfo описывает ресурсы и метки воркера из его последнего heartbeat-а.
+type WorkerInfo struct {
+	Capacity build.Resources
+	Free     build.Resources
+	Labels   []string
 }
 
 type Scheduler struct {
@@ -30,18 +47,73 @@ func NewScheduler(l *zap.Logger, config Config, timeAfter func(d time.Duration)
 	panic("implement me")
 }
 
+func (c *Scheduler) RegisterWorker(workerID api.WorkerID) {
+	panic("implement me")
+}
+
+// RemoveWorker забывает про воркера.
+//
+// Все джобы, которые были выданы этому воркеру и ещё не завершились, возвращаются в очередь.
+// Артефакты этого воркера больше не возвращаются из LocateArtifact.
+func (c *Scheduler) RemoveWorker(workerID api.WorkerID) {
+	panic("implement me")
+}
+
+// UpdateWorker запоминает ресурсы и метки воркера.
+//
+// PickJob выдаёт воркеру только те джобы, чьи Requirements удовлетворяются info.Free и info.Labels.
+func (c *Scheduler) UpdateWorker(workerID api.WorkerID, info WorkerInfo) {
+	panic("implement me")
+}
+
+// CanSatisfy проверяет, что среди зарегистрированных воркеров есть хотя бы один,
+// чьи Capacity и Labels удовлетворяют требованиям req.
+//
+// Если зарегистрированных воркеров нет, CanSatisfy возвращает true: про возможности кластера
+// пока ничего не известно, и джоб может дождаться воркеров в очереди.
+func (c *Scheduler) CanSatisfy(req *build.Requirements) bool {
+	panic("implement me")
+}
+
 func (c *Scheduler) LocateArtifact(id build.ID) (api.WorkerID, bool) {
 	panic("implement me")
 }
 
+// OnJobComplete сообщает шедулеру результат джоба.
+//
+// Если джоб упал, а Job.Retry.Retryable разрешает ещё одну попытку, шедулер не закрывает Finished,
+// а дописывает res в PendingJob.Failures и возвращает джоб в глобальную очередь. Повторную попытку
+// PickJob отдаёт воркеру, на котором джоб ещё не падал, если такой воркер может выполнить джоб.
+//
+// Когда OnJobComplete вернул джоб в очередь, координатор выбрасывает вывод упавшей попытки через
+// actioncache.Cache.Forget, чтобы он не попал в закешированный результат следующей.
 func (c *Scheduler) OnJobComplete(workerID api.WorkerID, jobID build.ID, res *api.JobResult) bool {
 	panic("implement me")
 }
 
+// OnArtifactRemoved сообщает планировщику, что воркер больше не хранит артефакт.
+func (c *Scheduler) OnArtifactRemoved(workerID api.WorkerID, artifactID build.ID) {
+	panic("implement me")
+}
+
+// ScheduleJob ставит джоб в очередь и возвращает подписку на его результат.
+//
+// Если джоб с таким же ID уже стоит в очереди или выполняется на воркере, ScheduleJob
+// возвращает существующий PendingJob. Каждый вызов ScheduleJob нужно отменить через
+// CancelJob, если результат джоба больше не нужен.
 func (c *Scheduler) ScheduleJob(job *api.JobSpec) *PendingJob {
 	panic("implement me")
 }
 
+// CancelJob отменяет одну подписку на джоб, полученную вызовом ScheduleJob.
+//
+// Джоб убирается из очередей, только когда отменены все подписки на него. Возвращает true,
+// если подписок не осталось. Если такой джоб уже был выдан воркеру, координатор должен
+// попросить воркера его остановить.
+func (c *Scheduler) CancelJob(jobID build.ID) bool {
+	panic("implement me")
+}
+
 func (c *Scheduler) PickJob(ctx context.Context, workerID api.WorkerID) *PendingJob {
 	panic("implement me")
 }
@@ -0,0 +1,95 @@
+package scheduler_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	"go.uber.org/zap/zaptest"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/scheduler"
+)
+
+const workerID api.WorkerID = "w0"
+
+var config = scheduler.Config{
+	CacheTimeout: time.Millisecond,
+	DepsTimeout:  time.Millisecond * 2,
+}
+
+func newScheduler(t *testing.T) *scheduler.Scheduler {
+	s := scheduler.NewScheduler(zaptest.NewLogger(t), config, time.After)
+	t.Cleanup(func() {
+		s.Stop()
+		goleak.VerifyNone(t)
+	})
+
+	s.RegisterWorker(workerID)
+	return s
+}
+
+func pickJob(s *scheduler.Scheduler, timeout time.Duration) *scheduler.PendingJob {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return s.PickJob(ctx, workerID)
+}
+
+func TestScheduleJobDeduplication(t *testing.T) {
+	s := newScheduler(t)
+
+	job := &api.JobSpec{Job: build.Job{ID: build.NewID()}}
+
+	queued := s.ScheduleJob(job)
+	require.Same(t, queued, s.ScheduleJob(job))
+
+	picked := pickJob(s, time.Second)
+	require.Same(t, queued, picked)
+	require.Same(t, queued, s.ScheduleJob(job))
+
+	result := &api.JobResult{ID: job.ID}
+	s.OnJobComplete(workerID, job.ID, result)
+
+	<-queued.Finished
+	require.Equal(t, result, queued.Result)
+
+	require.Nil(t, pickJob(s, time.Millisecond*10))
+}
+
+func TestCancelSharedJob(t *testing.T) {
+	s := newScheduler(t)
+
+	shared := &api.JobSpec{Job: build.Job{ID: build.NewID()}}
+	s.ScheduleJob(shared)
+	pending := s.ScheduleJob(shared)
+
+	require.False(t, s.CancelJob(shared.ID))
+	require.Same(t, pending, pickJob(s, time.Second))
+
+	single := &api.JobSpec{Job: build.Job{ID: build.NewID()}}
+	s.ScheduleJob(single)
+
+	require.True(t, s.CancelJob(single.ID))
+	require.Nil(t, pickJob(s, time.Millisecond*10))
+}
+
+func TestCanSatisfy(t *testing.T) {
+	s := scheduler.NewScheduler(zaptest.NewLogger(t), config, time.After)
+	t.Cleanup(s.Stop)
+
+	req := &build.Requirements{Labels: []string{"has=postgres"}}
+
+	// Пока воркеров нет, джоб может их дождаться.
+	require.True(t, s.CanSatisfy(req))
+
+	s.RegisterWorker(workerID)
+	s.UpdateWorker(workerID, scheduler.WorkerInfo{})
+	require.False(t, s.CanSatisfy(req))
+
+	s.UpdateWorker(workerID, scheduler.WorkerInfo{Labels: []string{"has=postgres"}})
+	require.True(t, s.CanSatisfy(req))
+}
@@ -0,0 +1,72 @@
+package tarstream
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Значения заголовка Content-Encoding, которые поддерживает tarstream.
+const (
+	EncodingIdentity = "identity"
+	EncodingGzip     = "gzip"
+)
+
+var ErrUnsupportedEncoding = errors.New("tarstream: unsupported encoding")
+
+// NegotiateEncoding выбирает сжатие потока по заголовку Accept-Encoding клиента.
+//
+// Возвращает EncodingGzip, если клиент его принимает, и EncodingIdentity в остальных случаях.
+func NegotiateEncoding(acceptEncoding string) string {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), EncodingGzip) {
+			continue
+		}
+
+		q, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !ok {
+			return EncodingGzip
+		}
+
+		if v, err := strconv.ParseFloat(q, 64); err == nil && v > 0 {
+			return EncodingGzip
+		}
+	}
+
+	return EncodingIdentity
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// NewEncoder оборачивает w в сжатие encoding. Close обязательно нужно позвать после записи всего потока,
+// но он не закрывает сам w.
+func NewEncoder(w io.Writer, encoding string) (io.WriteCloser, error) {
+	switch encoding {
+	case "", EncodingIdentity:
+		return nopWriteCloser{w}, nil
+	case EncodingGzip:
+		return gzip.NewWriter(w), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, encoding)
+	}
+}
+
+// NewDecoder оборачивает r в распаковку encoding.
+func NewDecoder(r io.Reader, encoding string) (io.ReadCloser, error) {
+	switch encoding {
+	case "", EncodingIdentity:
+		return io.NopCloser(r), nil
+	case EncodingGzip:
+		return gzip.NewReader(r)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, encoding)
+	}
+}
@@ -0,0 +1,50 @@
+package tarstream_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/tarstream"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	for header, expected := range map[string]string{
+		"":                   tarstream.EncodingIdentity,
+		"gzip":               tarstream.EncodingGzip,
+		"br, GZIP;q=0.5":     tarstream.EncodingGzip,
+		"gzip;q=0, identity": tarstream.EncodingIdentity,
+		"zstd":               tarstream.EncodingIdentity,
+	} {
+		require.Equal(t, expected, tarstream.NegotiateEncoding(header), header)
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	payload := bytes.Repeat([]byte("distbuild"), 1024)
+
+	for _, encoding := range []string{tarstream.EncodingIdentity, tarstream.EncodingGzip} {
+		var buf bytes.Buffer
+
+		enc, err := tarstream.NewEncoder(&buf, encoding)
+		require.NoError(t, err)
+		_, err = enc.Write(payload)
+		require.NoError(t, err)
+		require.NoError(t, enc.Close())
+
+		if encoding == tarstream.EncodingGzip {
+			require.Less(t, buf.Len(), len(payload))
+		}
+
+		dec, err := tarstream.NewDecoder(&buf, encoding)
+		require.NoError(t, err)
+		decoded, err := io.ReadAll(dec)
+		require.NoError(t, err)
+		require.Equal(t, payload, decoded)
+	}
+
+	_, err := tarstream.NewEncoder(io.Discard, "zstd")
+	require.ErrorIs(t, err, tarstream.ErrUnsupportedEncoding)
+}
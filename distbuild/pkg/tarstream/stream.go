@@ -2,14 +2,38 @@ package tarstream
 
 import (
 	"archive/tar"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 )
 
+// ChecksumRecord - имя PAX записи, в которой Send передаёт sha1 содержимого файла.
+const ChecksumRecord = "DISTBUILD.sha1"
+
+var (
+	// ErrChecksum возвращается из Receive, если содержимое файла не совпало с переданной контрольной суммой.
+	ErrChecksum = errors.New("tarstream: checksum mismatch")
+
+	// ErrUnknownEntry возвращается из SendFrom, если в директории нет записи after.
+	ErrUnknownEntry = errors.New("tarstream: unknown entry")
+)
+
 // Send рекурсивно обходит директорию и сериализует её содержимое в поток w.
 func Send(dir string, w io.Writer) error {
+	return SendFrom(dir, w, "")
+}
+
+// SendFrom работает как Send, но пропускает все записи до after включительно.
+//
+// after - это имя последней записи, которую получатель успел материализовать (см. ReceiveFrom).
+// Пустой after означает, что директорию нужно отправить целиком.
+func SendFrom(dir string, w io.Writer, after string) error {
 	tw := tar.NewWriter(w)
+	skip := after != ""
 
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -25,6 +49,11 @@ func Send(dir string, w io.Writer) error {
 			return nil
 		}
 
+		if skip {
+			skip = rel != after
+			return nil
+		}
+
 		switch {
 		case info.IsDir():
 			return tw.WriteHeader(&tar.Header{
@@ -33,24 +62,35 @@ func Send(dir string, w io.Writer) error {
 			})
 
 		default:
-			h := &tar.Header{
-				Typeflag: tar.TypeReg,
-				Name:     rel,
-				Size:     info.Size(),
-				Mode:     int64(info.Mode()),
+			f, err := os.Open(path)
+			if err != nil {
+				return err
 			}
+			defer f.Close()
 
-			if err := tw.WriteHeader(h); err != nil {
+			checksum := sha1.New()
+			if _, err := io.Copy(checksum, f); err != nil {
 				return err
 			}
 
-			f, err := os.Open(path)
-			if err != nil {
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
 				return err
 			}
-			defer f.Close()
 
-			_, err = io.Copy(tw, f)
+			h := &tar.Header{
+				Typeflag:   tar.TypeReg,
+				Name:       rel,
+				Size:       info.Size(),
+				Mode:       int64(info.Mode()),
+				Format:     tar.FormatPAX,
+				PAXRecords: map[string]string{ChecksumRecord: hex.EncodeToString(checksum.Sum(nil))},
+			}
+
+			if err := tw.WriteHeader(h); err != nil {
+				return err
+			}
+
+			_, err = io.CopyN(tw, f, info.Size())
 			return err
 		}
 	})
@@ -59,42 +99,67 @@ func Send(dir string, w io.Writer) error {
 		return err
 	}
 
+	if skip {
+		return fmt.Errorf("%w: %s", ErrUnknownEntry, after)
+	}
+
 	return tw.Close()
 }
 
 // Receive читает поток r и материализует содержимое потока внутри dir.
 func Receive(dir string, r io.Reader) error {
+	_, err := ReceiveFrom(dir, r)
+	return err
+}
+
+// ReceiveFrom работает как Receive и дополнительно возвращает имя последней записи,
+// которая была полностью материализована и прошла проверку контрольной суммы.
+//
+// Если поток оборвался посередине, недописанный файл удаляется, а last можно передать
+// в SendFrom, чтобы докачать остаток директории.
+func ReceiveFrom(dir string, r io.Reader) (last string, err error) {
 	tr := tar.NewReader(r)
 
 	for {
 		h, err := tr.Next()
 		if err == io.EOF {
-			return nil
+			return last, nil
 		} else if err != nil {
-			return err
+			return last, err
 		}
 
 		absPath := filepath.Join(dir, h.Name)
 
 		if h.Typeflag == tar.TypeDir {
 			if err := os.Mkdir(absPath, 0777); err != nil {
-				return err
+				return last, err
 			}
 		} else {
 			writeFile := func() error {
-				f, err := os.OpenFile(absPath, os.O_CREATE|os.O_WRONLY, os.FileMode(h.Mode))
+				f, err := os.OpenFile(absPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(h.Mode))
 				if err != nil {
 					return err
 				}
 				defer f.Close()
 
-				_, err = io.Copy(f, tr)
-				return err
+				checksum := sha1.New()
+				if _, err := io.Copy(io.MultiWriter(f, checksum), tr); err != nil {
+					return err
+				}
+
+				if expected, ok := h.PAXRecords[ChecksumRecord]; ok && expected != hex.EncodeToString(checksum.Sum(nil)) {
+					return fmt.Errorf("%w: %s", ErrChecksum, h.Name)
+				}
+
+				return f.Close()
 			}
 
 			if err := writeFile(); err != nil {
-				return err
+				_ = os.Remove(absPath)
+				return last, err
 			}
 		}
+
+		last = h.Name
 	}
 }
@@ -1,7 +1,9 @@
 package tarstream_test
 
 import (
+	"archive/tar"
 	"bytes"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -61,6 +63,85 @@ func TestTarStream(t *testing.T) {
 	checkFile(filepath.Join(to, "b", "c", "y.txt"), []byte("yyy"), 0644)
 }
 
+func writeTree(t *testing.T, dir string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "a"), 0777))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "b"), 0777))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a", "x.bin"), bytes.Repeat([]byte("x"), 4096), 0777))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), []byte("aaa"), 0666))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b", "y.txt"), bytes.Repeat([]byte("y"), 4096), 0666))
+}
+
+func TestChecksum(t *testing.T) {
+	from := t.TempDir()
+	writeTree(t, from)
+
+	var buf bytes.Buffer
+	require.NoError(t, tarstream.Send(from, &buf))
+
+	tr := tar.NewReader(bytes.NewReader(buf.Bytes()))
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		if h.Typeflag == tar.TypeReg {
+			require.Len(t, h.PAXRecords[tarstream.ChecksumRecord], 40, h.Name)
+		}
+	}
+
+	corrupted := bytes.Replace(buf.Bytes(), []byte("aaa"), []byte("aab"), 1)
+
+	to := t.TempDir()
+	_, err := tarstream.ReceiveFrom(to, bytes.NewReader(corrupted))
+	require.ErrorIs(t, err, tarstream.ErrChecksum)
+
+	_, err = os.Stat(filepath.Join(to, "a.txt"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestResume(t *testing.T) {
+	from := t.TempDir()
+	writeTree(t, from)
+
+	var buf bytes.Buffer
+	require.NoError(t, tarstream.Send(from, &buf))
+
+	to := t.TempDir()
+	last, err := tarstream.ReceiveFrom(to, io.LimitReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()/2)))
+	require.Error(t, err)
+	require.Equal(t, filepath.Join("a", "x.bin"), last)
+
+	_, err = os.Stat(filepath.Join(to, "a.txt"))
+	require.True(t, os.IsNotExist(err), "partially received file must be removed")
+
+	buf.Reset()
+	require.NoError(t, tarstream.SendFrom(from, &buf, last))
+
+	last, err = tarstream.ReceiveFrom(to, &buf)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join("b", "y.txt"), last)
+
+	for _, name := range []string{"a/x.bin", "a.txt", "b/y.txt"} {
+		expected, err := os.ReadFile(filepath.Join(from, name))
+		require.NoError(t, err)
+
+		actual, err := os.ReadFile(filepath.Join(to, name))
+		require.NoError(t, err)
+		require.Equal(t, expected, actual, name)
+	}
+}
+
+func TestSendFromUnknownEntry(t *testing.T) {
+	from := t.TempDir()
+	writeTree(t, from)
+
+	require.ErrorIs(t, tarstream.SendFrom(from, io.Discard, "missing"), tarstream.ErrUnknownEntry)
+}
+
 func init() {
 	unix.Umask(0022)
 }
@@ -0,0 +1,211 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+// Trace собирает таймлайн сборки и записывает его в формате Chrome trace event.
+//
+// Получившийся файл можно открыть в https://ui.perfetto.dev или chrome://tracing.
+// Каждый воркер становится отдельным процессом, а каждый его слот - отдельным потоком.
+// Ожидание джобов в очереди координатора рисуется асинхронными событиями процесса coordinator,
+// а фазы клиента, например заливка файлов, - событиями процесса client.
+//
+// Все методы Trace concurrency safe.
+type Trace struct {
+	mu          sync.Mutex
+	clientSpans []clientSpan
+	jobs        []job
+}
+
+type clientSpan struct {
+	name       string
+	start, end time.Time
+}
+
+type job struct {
+	name  string
+	id    build.ID
+	trace *api.JobTrace
+}
+
+func New() *Trace {
+	return &Trace{}
+}
+
+// AddClientSpan записывает фазу работы клиента.
+func (t *Trace) AddClientSpan(name string, start, end time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.clientSpans = append(t.clientSpans, clientSpan{name: name, start: start, end: end})
+}
+
+// AddJob записывает фазы джоба. Джобы без trace (например, взятые из кеша) игнорируются.
+func (t *Trace) AddJob(name string, id build.ID, trace *api.JobTrace) {
+	if trace == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.jobs = append(t.jobs, job{name: name, id: id, trace: trace})
+}
+
+// event описывает одно событие формата Chrome trace event.
+//
+// https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU
+type event struct {
+	Name string         `json:"name"`
+	Cat  string         `json:"cat,omitempty"`
+	Ph   string         `json:"ph"`
+	TS   float64        `json:"ts"`
+	Dur  *float64       `json:"dur,omitempty"`
+	PID  int            `json:"pid"`
+	TID  int            `json:"tid"`
+	ID   string         `json:"id,omitempty"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+type file struct {
+	TraceEvents     []event `json:"traceEvents"`
+	DisplayTimeUnit string  `json:"displayTimeUnit"`
+}
+
+const (
+	clientPID      = 0
+	coordinatorPID = 1
+	firstWorkerPID = 2
+)
+
+func metadata(kind string, pid, tid int, name string) event {
+	return event{Name: kind, Ph: "M", PID: pid, TID: tid, Args: map[string]any{"name": name}}
+}
+
+// events возвращает события трейса, отсортированные по времени.
+func (t *Trace) events() []event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var origin time.Time
+	observe := func(ts time.Time) {
+		if !ts.IsZero() && (origin.IsZero() || ts.Before(origin)) {
+			origin = ts
+		}
+	}
+	for _, s := range t.clientSpans {
+		observe(s.start)
+	}
+	for _, j := range t.jobs {
+		observe(j.trace.Scheduled)
+		observe(j.trace.Picked)
+	}
+
+	us := func(ts time.Time) float64 {
+		return float64(ts.Sub(origin).Nanoseconds()) / 1e3
+	}
+
+	var events []event
+	span := func(name, cat string, pid, tid int, start, end time.Time, args map[string]any) {
+		if start.IsZero() || end.IsZero() {
+			return
+		}
+
+		dur := us(end) - us(start)
+		events = append(events, event{Name: name, Cat: cat, Ph: "X", TS: us(start), Dur: &dur, PID: pid, TID: tid, Args: args})
+	}
+
+	events = append(events, metadata("process_name", clientPID, 0, "client"))
+	for _, s := range t.clientSpans {
+		span(s.name, "client", clientPID, 0, s.start, s.end, nil)
+	}
+
+	events = append(events, metadata("process_name", coordinatorPID, 0, "coordinator"))
+
+	workers := map[api.WorkerID]int{}
+	var workerIDs []api.WorkerID
+	for _, j := range t.jobs {
+		if _, ok := workers[j.trace.WorkerID]; !ok {
+			workers[j.trace.WorkerID] = 0
+			workerIDs = append(workerIDs, j.trace.WorkerID)
+		}
+	}
+	sort.Slice(workerIDs, func(i, j int) bool { return workerIDs[i] < workerIDs[j] })
+	for i, w := range workerIDs {
+		workers[w] = firstWorkerPID + i
+		events = append(events, metadata("process_name", firstWorkerPID+i, 0, "worker "+string(w)))
+	}
+
+	slots := map[[2]int]bool{}
+	for _, j := range t.jobs {
+		tr := j.trace
+		pid, tid := workers[tr.WorkerID], tr.Slot
+		args := map[string]any{"id": j.id.String()}
+
+		if !slots[[2]int{pid, tid}] {
+			slots[[2]int{pid, tid}] = true
+			events = append(events, metadata("thread_name", pid, tid, fmt.Sprintf("slot %d", tid)))
+		}
+
+		if !tr.Scheduled.IsZero() && !tr.Picked.IsZero() {
+			events = append(events,
+				event{Name: j.name, Cat: "queue", Ph: "b", TS: us(tr.Scheduled), PID: coordinatorPID, ID: j.id.String(), Args: args},
+				event{Name: j.name, Cat: "queue", Ph: "e", TS: us(tr.Picked), PID: coordinatorPID, ID: j.id.String()},
+			)
+		}
+
+		end := tr.Committed
+		if end.IsZero() && len(tr.Cmds) != 0 {
+			end = tr.Cmds[len(tr.Cmds)-1].End
+		}
+		span(j.name, "job", pid, tid, tr.Picked, end, args)
+		span("download deps", "download", pid, tid, tr.Picked, tr.DepsDownloaded, nil)
+
+		for i, cmd := range tr.Cmds {
+			span(fmt.Sprintf("cmd %d", i), "exec", pid, tid, cmd.Start, cmd.End, nil)
+		}
+
+		if len(tr.Cmds) != 0 {
+			span("commit", "commit", pid, tid, tr.Cmds[len(tr.Cmds)-1].End, tr.Committed, nil)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if (events[i].Ph == "M") != (events[j].Ph == "M") {
+			return events[i].Ph == "M"
+		}
+		return events[i].TS < events[j].TS
+	})
+	return events
+}
+
+// Write записывает трейс в w в формате Chrome trace event.
+func (t *Trace) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	return enc.Encode(file{TraceEvents: t.events(), DisplayTimeUnit: "ms"})
+}
+
+// WriteFile записывает трейс в файл path.
+func (t *Trace) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := t.Write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
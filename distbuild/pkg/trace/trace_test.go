@@ -0,0 +1,128 @@
+package trace_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/api"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+	"gitlab.com/slon/shad-go/distbuild/pkg/trace"
+)
+
+type event struct {
+	Name string         `json:"name"`
+	Cat  string         `json:"cat"`
+	Ph   string         `json:"ph"`
+	TS   float64        `json:"ts"`
+	Dur  float64        `json:"dur"`
+	PID  int            `json:"pid"`
+	TID  int            `json:"tid"`
+	ID   string         `json:"id"`
+	Args map[string]any `json:"args"`
+}
+
+func decode(t *testing.T, tr *trace.Trace) []event {
+	var buf bytes.Buffer
+	require.NoError(t, tr.Write(&buf))
+
+	var file struct {
+		TraceEvents []event `json:"traceEvents"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &file))
+	return file.TraceEvents
+}
+
+func find(events []event, ph, name string) []event {
+	var result []event
+	for _, e := range events {
+		if e.Ph == ph && e.Name == name {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func TestTrace(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time {
+		return start.Add(time.Duration(ms) * time.Millisecond)
+	}
+
+	tr := trace.New()
+	tr.AddClientSpan("upload", at(0), at(10))
+	tr.AddJob("compile", build.ID{'a'}, &api.JobTrace{
+		WorkerID:       "w1",
+		Slot:           1,
+		Scheduled:      at(10),
+		Picked:         at(15),
+		DepsDownloaded: at(20),
+		Cmds:           []api.CmdTrace{{Start: at(20), End: at(50)}},
+		Committed:      at(55),
+	})
+	tr.AddJob("link", build.ID{'b'}, &api.JobTrace{
+		WorkerID:  "w0",
+		Scheduled: at(10),
+		Picked:    at(60),
+		Cmds:      []api.CmdTrace{{Start: at(60), End: at(70)}, {Start: at(70), End: at(90)}},
+		Committed: at(90),
+	})
+	tr.AddJob("cached", build.ID{'c'}, nil)
+
+	events := decode(t, tr)
+
+	var processes []string
+	for _, e := range find(events, "M", "process_name") {
+		processes = append(processes, e.Args["name"].(string))
+	}
+	require.Equal(t, []string{"client", "coordinator", "worker w0", "worker w1"}, processes)
+
+	upload := find(events, "X", "upload")
+	require.Len(t, upload, 1)
+	require.Equal(t, 0.0, upload[0].TS)
+	require.Equal(t, 10000.0, upload[0].Dur)
+
+	compile := find(events, "X", "compile")
+	require.Len(t, compile, 1)
+	require.Equal(t, 3, compile[0].PID)
+	require.Equal(t, 1, compile[0].TID)
+	require.Equal(t, 15000.0, compile[0].TS)
+	require.Equal(t, 40000.0, compile[0].Dur)
+	require.Equal(t, build.ID{'a'}.String(), compile[0].Args["id"])
+
+	require.Len(t, find(events, "X", "download deps"), 1)
+	require.Len(t, find(events, "X", "cmd 0"), 2)
+	require.Len(t, find(events, "X", "cmd 1"), 1)
+	require.Len(t, find(events, "X", "commit"), 2)
+	require.Empty(t, find(events, "X", "cached"))
+
+	queued := find(events, "b", "link")
+	require.Len(t, queued, 1)
+	require.Equal(t, 10000.0, queued[0].TS)
+	require.Equal(t, 1, queued[0].PID)
+
+	dequeued := find(events, "e", "link")
+	require.Len(t, dequeued, 1)
+	require.Equal(t, 60000.0, dequeued[0].TS)
+	require.Equal(t, queued[0].ID, dequeued[0].ID)
+
+	threads := find(events, "M", "thread_name")
+	require.Len(t, threads, 2)
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.json")
+
+	tr := trace.New()
+	tr.AddClientSpan("upload", time.Now(), time.Now())
+	require.NoError(t, tr.WriteFile(path))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.True(t, json.Valid(content))
+}
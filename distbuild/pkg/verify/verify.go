@@ -0,0 +1,282 @@
+package verify
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
+)
+
+// Entry описывает один файл, директорию или симлинк внутри артефакта.
+type Entry struct {
+	// Path - путь относительно корня артефакта, через "/".
+	Path string
+
+	// Mode содержит тип записи и биты исполнения. Остальные права зависят от umask воркера,
+	// поэтому не сравниваются.
+	Mode fs.FileMode
+
+	// Size и Hash описывают содержимое файла или цель симлинка. У директорий они нулевые.
+	Size int64
+	Hash build.ID
+}
+
+// Manifest описывает содержимое артефакта. Записи отсортированы по Path.
+type Manifest []Entry
+
+// HashDir обходит директорию dir и строит её Manifest.
+func HashDir(dir string) (Manifest, error) {
+	var m Manifest
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path == dir {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		e := Entry{
+			Path: filepath.ToSlash(rel),
+			Mode: info.Mode().Type() | info.Mode().Perm()&0111,
+		}
+
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			e.Size = int64(len(target))
+			e.Hash = sha1.Sum([]byte(target))
+
+		case d.Type().IsRegular():
+			if e.Size, e.Hash, err = hashFile(path); err != nil {
+				return err
+			}
+		}
+
+		m = append(m, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(m, func(i, j int) bool { return m[i].Path < m[j].Path })
+	return m, nil
+}
+
+func hashFile(path string) (int64, build.ID, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, build.ID{}, err
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, build.ID{}, err
+	}
+
+	var id build.ID
+	copy(id[:], h.Sum(nil))
+	return n, id, nil
+}
+
+// Sum возвращает хеш всего артефакта. Артефакты с одинаковым содержимым имеют одинаковый Sum.
+func (m Manifest) Sum() build.ID {
+	h := sha1.New()
+	for _, e := range m {
+		_, _ = fmt.Fprintf(h, "%s\x00%o\x00%d\x00%s\n", e.Path, uint32(e.Mode), e.Size, e.Hash)
+	}
+
+	var id build.ID
+	copy(id[:], h.Sum(nil))
+	return id
+}
+
+// DiffKind описывает, чем отличается файл в двух артефактах.
+type DiffKind string
+
+const (
+	// DiffOnlyFirst - файл есть только в первом артефакте.
+	DiffOnlyFirst DiffKind = "only-first"
+	// DiffOnlySecond - файл есть только во втором артефакте.
+	DiffOnlySecond DiffKind = "only-second"
+	// DiffType - запись имеет разный тип, например файл в одном артефакте и директория в другом.
+	DiffType DiffKind = "type"
+	// DiffMode - у файла отличаются биты исполнения.
+	DiffMode DiffKind = "mode"
+	// DiffContent - у файла отличается содержимое, у симлинка - цель.
+	DiffContent DiffKind = "content"
+)
+
+// FileDiff описывает один отличающийся файл.
+type FileDiff struct {
+	Path string
+	Kind DiffKind
+
+	// Offset - смещение первого отличающегося байта. Заполняется только для DiffContent.
+	// Если один файл - префикс другого, Offset равен длине более короткого.
+	Offset int64 `json:",omitempty"`
+}
+
+func (d FileDiff) String() string {
+	switch d.Kind {
+	case DiffOnlyFirst:
+		return d.Path + ": only in first artifact"
+	case DiffOnlySecond:
+		return d.Path + ": only in second artifact"
+	case DiffType:
+		return d.Path + ": file type differs"
+	case DiffMode:
+		return d.Path + ": executable bit differs"
+	default:
+		return fmt.Sprintf("%s: content differs at byte %d", d.Path, d.Offset)
+	}
+}
+
+// DiffDirs сравнивает два артефакта и возвращает отличающиеся файлы в порядке путей.
+//
+// Если артефакты совпадают, DiffDirs возвращает пустой список.
+func DiffDirs(first, second string) ([]FileDiff, error) {
+	a, err := HashDir(first)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := HashDir(second)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []FileDiff
+	for len(a) != 0 || len(b) != 0 {
+		switch {
+		case len(b) == 0 || len(a) != 0 && a[0].Path < b[0].Path:
+			diffs = append(diffs, FileDiff{Path: a[0].Path, Kind: DiffOnlyFirst})
+			a = a[1:]
+			continue
+
+		case len(a) == 0 || b[0].Path < a[0].Path:
+			diffs = append(diffs, FileDiff{Path: b[0].Path, Kind: DiffOnlySecond})
+			b = b[1:]
+			continue
+		}
+
+		x, y := a[0], b[0]
+		a, b = a[1:], b[1:]
+
+		switch {
+		case x.Mode.Type() != y.Mode.Type():
+			diffs = append(diffs, FileDiff{Path: x.Path, Kind: DiffType})
+
+		case x.Size != y.Size || x.Hash != y.Hash:
+			offset, err := firstDiff(filepath.Join(first, x.Path), filepath.Join(second, y.Path), x.Mode)
+			if err != nil {
+				return nil, err
+			}
+			diffs = append(diffs, FileDiff{Path: x.Path, Kind: DiffContent, Offset: offset})
+
+		case x.Mode != y.Mode:
+			diffs = append(diffs, FileDiff{Path: x.Path, Kind: DiffMode})
+		}
+	}
+
+	return diffs, nil
+}
+
+// firstDiff находит смещение первого отличающегося байта двух файлов или целей двух симлинков.
+func firstDiff(a, b string, mode fs.FileMode) (int64, error) {
+	if mode&fs.ModeSymlink != 0 {
+		x, err := os.Readlink(a)
+		if err != nil {
+			return 0, err
+		}
+		y, err := os.Readlink(b)
+		if err != nil {
+			return 0, err
+		}
+		return commonPrefix(strings.NewReader(x), strings.NewReader(y))
+	}
+
+	x, err := os.Open(a)
+	if err != nil {
+		return 0, err
+	}
+	defer x.Close()
+
+	y, err := os.Open(b)
+	if err != nil {
+		return 0, err
+	}
+	defer y.Close()
+
+	return commonPrefix(x, y)
+}
+
+func commonPrefix(x, y io.Reader) (int64, error) {
+	const blockSize = 32 << 10
+
+	bx := make([]byte, blockSize)
+	by := make([]byte, blockSize)
+
+	var offset int64
+	for {
+		nx, errx := io.ReadFull(x, bx)
+		ny, erry := io.ReadFull(y, by)
+
+		n := min(nx, ny)
+		if i := mismatch(bx[:n], by[:n]); i != -1 {
+			return offset + int64(i), nil
+		}
+		offset += int64(n)
+
+		if nx != ny {
+			return offset, nil
+		}
+
+		if errx == io.EOF || errx == io.ErrUnexpectedEOF {
+			return offset, nil
+		} else if errx != nil {
+			return 0, errx
+		}
+		if erry != nil && erry != io.EOF && erry != io.ErrUnexpectedEOF {
+			return 0, erry
+		}
+	}
+}
+
+// mismatch возвращает индекс первого отличающегося байта или -1, если слайсы равны.
+func mismatch(x, y []byte) int {
+	if bytes.Equal(x, y) {
+		return -1
+	}
+
+	for i := range x {
+		if x[i] != y[i] {
+			return i
+		}
+	}
+	return -1
+}
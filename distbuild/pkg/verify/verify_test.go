@@ -0,0 +1,130 @@
+package verify_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/verify"
+)
+
+func writeFile(t *testing.T, path string, content []byte, perm os.FileMode) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0777))
+	require.NoError(t, os.WriteFile(path, content, perm))
+}
+
+// artifact создаёт директорию с типичным выходом джоба.
+func artifact(t *testing.T) string {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "bin", "app"), []byte("#!/bin/sh\necho app\n"), 0755)
+	writeFile(t, filepath.Join(dir, "lib", "a.txt"), []byte("a"), 0644)
+	require.NoError(t, os.Symlink("bin/app", filepath.Join(dir, "app")))
+	return dir
+}
+
+func TestHashDir(t *testing.T) {
+	dir := artifact(t)
+
+	m, err := verify.HashDir(dir)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, e := range m {
+		paths = append(paths, e.Path)
+	}
+	require.Equal(t, []string{"app", "bin", "bin/app", "lib", "lib/a.txt"}, paths)
+
+	require.Equal(t, os.ModeSymlink, m[0].Mode.Type())
+	require.Equal(t, int64(len("bin/app")), m[0].Size)
+	require.Equal(t, os.FileMode(0111), m[2].Mode)
+	require.Equal(t, os.FileMode(0), m[4].Mode)
+}
+
+func TestSum(t *testing.T) {
+	a, err := verify.HashDir(artifact(t))
+	require.NoError(t, err)
+
+	// Права, кроме битов исполнения, на хеш не влияют.
+	dir := artifact(t)
+	require.NoError(t, os.Chmod(filepath.Join(dir, "lib", "a.txt"), 0600))
+	b, err := verify.HashDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, a.Sum(), b.Sum())
+
+	require.NoError(t, os.Chmod(filepath.Join(dir, "lib", "a.txt"), 0700))
+	b, err = verify.HashDir(dir)
+	require.NoError(t, err)
+	require.NotEqual(t, a.Sum(), b.Sum())
+
+	empty, err := verify.HashDir(t.TempDir())
+	require.NoError(t, err)
+	require.Empty(t, empty)
+	require.NotEqual(t, a.Sum(), empty.Sum())
+}
+
+func TestDiffDirsEqual(t *testing.T) {
+	diffs, err := verify.DiffDirs(artifact(t), artifact(t))
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+}
+
+func TestDiffDirs(t *testing.T) {
+	a, b := artifact(t), artifact(t)
+
+	writeFile(t, filepath.Join(a, "stamp"), []byte("built at 12:00:00"), 0644)
+	writeFile(t, filepath.Join(b, "stamp"), []byte("built at 12:00:01"), 0644)
+
+	writeFile(t, filepath.Join(a, "only-a"), nil, 0644)
+	writeFile(t, filepath.Join(b, "only-b", "x"), nil, 0644)
+
+	require.NoError(t, os.Chmod(filepath.Join(b, "bin", "app"), 0644))
+
+	require.NoError(t, os.Remove(filepath.Join(b, "lib", "a.txt")))
+	require.NoError(t, os.Mkdir(filepath.Join(b, "lib", "a.txt"), 0777))
+
+	require.NoError(t, os.Remove(filepath.Join(b, "app")))
+	require.NoError(t, os.Symlink("bin/app2", filepath.Join(b, "app")))
+
+	diffs, err := verify.DiffDirs(a, b)
+	require.NoError(t, err)
+	require.Equal(t, []verify.FileDiff{
+		{Path: "app", Kind: verify.DiffContent, Offset: 7},
+		{Path: "bin/app", Kind: verify.DiffMode},
+		{Path: "lib/a.txt", Kind: verify.DiffType},
+		{Path: "only-a", Kind: verify.DiffOnlyFirst},
+		{Path: "only-b", Kind: verify.DiffOnlySecond},
+		{Path: "only-b/x", Kind: verify.DiffOnlySecond},
+		{Path: "stamp", Kind: verify.DiffContent, Offset: 16},
+	}, diffs)
+
+	require.Equal(t, "stamp: content differs at byte 16", diffs[6].String())
+}
+
+func TestDiffOffset(t *testing.T) {
+	large := bytes.Repeat([]byte("0123456789"), 10000)
+
+	for _, tc := range []struct {
+		name   string
+		a, b   []byte
+		offset int64
+	}{
+		{name: "first byte", a: []byte("abc"), b: []byte("xbc"), offset: 0},
+		{name: "prefix", a: []byte("abc"), b: []byte("abcdef"), offset: 3},
+		{name: "empty", a: nil, b: []byte("a"), offset: 0},
+		{name: "second block", a: large, b: append(append([]byte(nil), large[:70000]...), 'x'), offset: 70000},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, b := t.TempDir(), t.TempDir()
+			writeFile(t, filepath.Join(a, "f"), tc.a, 0644)
+			writeFile(t, filepath.Join(b, "f"), tc.b, 0644)
+
+			diffs, err := verify.DiffDirs(a, b)
+			require.NoError(t, err)
+			require.Equal(t, []verify.FileDiff{{Path: "f", Kind: verify.DiffContent, Offset: tc.offset}}, diffs)
+		})
+	}
+}
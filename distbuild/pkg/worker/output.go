@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"errors"
+	"sync"
+)
+
+// DefaultOutputLimit задаёт размер буфера вывода одного потока джоба по умолчанию.
+const DefaultOutputLimit = 64 * 1024
+
+var ErrOutputClosed = errors.New("job output is closed")
+
+// OutputBuffer накапливает вывод бегущего джоба до следующего heartbeat-а.
+//
+// Размер буфера ограничен. Если буфер заполнен, Write блокируется до тех пор,
+// пока воркер не заберёт накопленный вывод вызовом Take. Так медленный координатор
+// притормаживает процесс джоба, вместо того чтобы копить его вывод в памяти.
+type OutputBuffer struct {
+	limit int
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	buf    []byte
+	closed bool
+}
+
+// NewOutputBuffer создаёт буфер размера limit. Если limit не положительный, используется DefaultOutputLimit.
+func NewOutputBuffer(limit int) *OutputBuffer {
+	if limit <= 0 {
+		limit = DefaultOutputLimit
+	}
+
+	b := &OutputBuffer{limit: limit}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+func (b *OutputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	written := 0
+	for written < len(p) {
+		for !b.closed && len(b.buf) == b.limit {
+			b.cond.Wait()
+		}
+
+		if b.closed {
+			return written, ErrOutputClosed
+		}
+
+		n := min(b.limit-len(b.buf), len(p)-written)
+		b.buf = append(b.buf, p[written:written+n]...)
+		written += n
+	}
+
+	return written, nil
+}
+
+// Take забирает весь накопленный вывод.
+//
+// Возвращает nil, если с прошлого вызова ничего не было записано.
+func (b *OutputBuffer) Take() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.buf) == 0 {
+		return nil
+	}
+
+	out := b.buf
+	b.buf = nil
+	b.cond.Broadcast()
+	return out
+}
+
+// Close будит всех заблокированных писателей. Последующие вызовы Write возвращают ErrOutputClosed.
+//
+// Уже накопленный вывод можно забрать через Take.
+func (b *OutputBuffer) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	b.cond.Broadcast()
+}
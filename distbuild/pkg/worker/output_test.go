@@ -0,0 +1,84 @@
+package worker_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/slon/shad-go/distbuild/pkg/worker"
+)
+
+func TestOutputBuffer(t *testing.T) {
+	b := worker.NewOutputBuffer(4)
+
+	require.Nil(t, b.Take())
+
+	n, err := b.Write([]byte("ab"))
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []byte("ab"), b.Take())
+
+	input := bytes.Repeat([]byte("x"), 10)
+	written := make(chan error, 1)
+	go func() {
+		_, err := b.Write(input)
+		written <- err
+	}()
+
+	var output []byte
+	for len(output) < len(input) {
+		chunk := b.Take()
+		require.LessOrEqual(t, len(chunk), 4)
+		output = append(output, chunk...)
+
+		time.Sleep(time.Millisecond)
+	}
+
+	require.NoError(t, <-written)
+	require.Equal(t, input, output)
+}
+
+func TestOutputBufferClose(t *testing.T) {
+	b := worker.NewOutputBuffer(2)
+
+	written := make(chan error, 1)
+	go func() {
+		_, err := b.Write([]byte("abcd"))
+		written <- err
+	}()
+
+	select {
+	case err := <-written:
+		t.Fatalf("write must block on full buffer: %v", err)
+	case <-time.After(time.Millisecond * 10):
+	}
+
+	b.Close()
+
+	err := <-written
+	require.Truef(t, errors.Is(err, worker.ErrOutputClosed), "%v", err)
+	require.Equal(t, []byte("ab"), b.Take())
+}
+
+func TestOutputBufferDefaultLimit(t *testing.T) {
+	for _, limit := range []int{0, -1} {
+		b := worker.NewOutputBuffer(limit)
+
+		written := make(chan error, 1)
+		go func() {
+			_, err := b.Write([]byte("ab"))
+			written <- err
+		}()
+
+		select {
+		case err := <-written:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatalf("write blocked with limit %d", limit)
+		}
+		require.Equal(t, []byte("ab"), b.Take())
+	}
+}
@@ -4,13 +4,16 @@ package worker
 
 import (
 	"context"
+	"crypto/tls"
 	"net/http"
 
 	"go.uber.org/zap"
 
 	"gitlab.com/slon/shad-go/distbuild/pkg/api"
 	"gitlab.com/slon/shad-go/distbuild/pkg/artifact"
+	"gitlab.com/slon/shad-go/distbuild/pkg/build"
 	"gitlab.com/slon/shad-go/distbuild/pkg/filecache"
+	"gitlab.com/slon/shad-go/distbuild/pkg/sandbox"
 )
 
 type Worker struct {
@@ -26,6 +29,53 @@ func New(
 	panic("implement me")
 }
 
+// SetResources задаёт ресурсы и метки, которые воркер сообщает координатору.
+//
+// Должен вызываться до Run. По умолчанию воркер сообщает runtime.NumCPU() ядер, весь объём
+// физической памяти и метки os=$GOOS и arch=$GOARCH. Нулевые поля capacity тоже заменяются
+// значениями по умолчанию.
+func (w *Worker) SetResources(capacity build.Resources, labels []string) {
+	panic("implement me")
+}
+
+// SetSlots задаёт число джобов, которые воркер может выполнять одновременно.
+//
+// Должен вызываться до Run. Воркер сообщает координатору число незанятых слотов в HeartbeatRequest.FreeSlots.
+// По умолчанию у воркера один слот.
+func (w *Worker) SetSlots(slots int) {
+	panic("implement me")
+}
+
+// SetHeartbeatService задаёт транспорт, через который воркер посылает heartbeat-ы координатору.
+//
+// Должен вызываться до Run. По умолчанию воркер ходит по HTTP через api.HeartbeatClient на coordinatorEndpoint,
+// а с gRPC транспортом сюда передаётся grpcapi.HeartbeatClient. Файлы и артефакты всегда передаются по HTTP.
+func (w *Worker) SetHeartbeatService(s api.HeartbeatService) {
+	panic("implement me")
+}
+
+// SetTLS включает mTLS между воркером и остальным кластером.
+//
+// Должен вызываться до Run. config - клиентская конфигурация с сертификатом воркера (auth.ClientConfig),
+// через неё воркер ходит к координатору и к другим воркерам. Сертификат содержит WorkerID воркера в URI SAN.
+//
+// После SetTLS воркер отдаёт артефакты только воркерам из последнего HeartbeatResponse.Peers и
+// координатору с идентификатором HeartbeatResponse.Coordinator (auth.Peers).
+func (w *Worker) SetTLS(config *tls.Config) {
+	panic("implement me")
+}
+
+// SetSandbox включает запуск команд джобов в песочнице.
+//
+// Должен вызываться до Run. Команды из Cmd.Exec выполняются через s.Run, а *sandbox.LimitError
+// превращается в JobResult.Error. По умолчанию песочница выключена, и команды запускаются прямо на хосте.
+func (w *Worker) SetSandbox(s *sandbox.Sandbox) {
+	panic("implement me")
+}
+
+// ServeHTTP отдаёт артефакты другим воркерам.
+//
+// Кроме того, по пути metrics.Path воркер отдаёт свои метрики и статистику кешей артефактов и файлов.
 func (w *Worker) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 	panic("implement me")
 }